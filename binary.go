@@ -0,0 +1,60 @@
+package godelta
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/amterp/go-delta/internal/render"
+)
+
+// binarySniffLen is how much of an input is inspected to decide whether
+// it is binary. Git uses the same limit.
+const binarySniffLen = 8000
+
+// isBinary reports whether s looks like binary data rather than text:
+// it contains a NUL byte, or more than 30% of its first binarySniffLen
+// bytes are control characters other than common whitespace, or are not
+// valid UTF-8.
+func isBinary(s string) bool {
+	if len(s) > binarySniffLen {
+		s = s[:binarySniffLen]
+	}
+	suspicious := 0
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == 0:
+			return true
+		case r == utf8.RuneError && size == 1:
+			// A multibyte character cut off by the sniff limit is
+			// still text.
+			if !(len(s) == binarySniffLen && len(s)-i < utf8.UTFMax && !utf8.FullRuneInString(s[i:])) {
+				suspicious++
+			}
+		case r < 0x20 && r != '\t' && r != '\n' && r != '\r' && r != '\f' && r != '\b' && r != '\x1b':
+			suspicious++
+		}
+		i += size
+	}
+	return suspicious*10 > len(s)*3
+}
+
+// binarySummary reports that two binary inputs differ, with the size
+// and a short SHA-256 hash of each side.
+func binarySummary(old, new string, s render.Styles) string {
+	return s.Separator("~~~ binary files differ ~~~") + "\n" +
+		s.Removed("- "+binaryInfo(old)) + "\n" +
+		s.Added("+ "+binaryInfo(new)) + "\n"
+}
+
+// binaryInfo describes one binary input as "N bytes, sha256 XXXX".
+func binaryInfo(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	noun := "bytes"
+	if len(data) == 1 {
+		noun = "byte"
+	}
+	return fmt.Sprintf("%d %s, sha256 %s", len(data), noun, hex.EncodeToString(sum[:])[:16])
+}
@@ -0,0 +1,61 @@
+package godelta
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{"empty", "", false},
+		{"text", "hello\nworld\n", false},
+		{"unicode", "héllo wörld ✓", false},
+		{"ansi", "\x1b[31mred\x1b[0m", false},
+		{"nul", "abc\x00def", true},
+		{"mostly control", "\x01\x02\x03\x04abc", true},
+		{"stray invalid byte", "caf\xe9 au lait", false},
+		{"mostly invalid", "\xff\xfe\xfd\xfc\xfbab", true},
+		{"cut at sniff limit", strings.Repeat("a", binarySniffLen-1) + "é", false},
+	}
+	for _, tt := range tests {
+		if got := isBinary(tt.input); got != tt.want {
+			t.Errorf("%s: isBinary = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDiffBinary(t *testing.T) {
+	old, new := "PNG\x00\x01\x02", "PNG\x00\x01\x03\x04"
+	result := DiffWith(old, new, WithColor(false))
+	want := "~~~ binary files differ ~~~\n- " + binaryInfo(old) + "\n+ " + binaryInfo(new) + "\n"
+	if result != want {
+		t.Errorf("got:\n%s\nwant:\n%s", result, want)
+	}
+	if strings.Contains(result, "\x00") {
+		t.Error("binary content leaked into the output")
+	}
+}
+
+func TestBinaryInfo(t *testing.T) {
+	// sha256("") = e3b0c44298fc1c14...
+	if got := binaryInfo(""); got != "0 bytes, sha256 e3b0c44298fc1c14" {
+		t.Errorf("got %q", got)
+	}
+	if got := binaryInfo("a"); !strings.HasPrefix(got, "1 byte, ") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestDiffInvalidUTF8Escaped(t *testing.T) {
+	result := DiffWith("name: caf\xe9\n", "name: cafe\n", WithColor(false))
+	if !strings.Contains(result, `- name: caf\xe9`) {
+		t.Errorf("expected escaped byte, got:\n%s", result)
+	}
+	if strings.Contains(result, "\xe9") || strings.Contains(result, "�") {
+		t.Errorf("raw or replaced byte in output:\n%s", result)
+	}
+}
@@ -43,7 +43,19 @@ func main() {
 	fmt.Println()
 	fmt.Println(gd.DiffWith(old, new_, gd.WithColor(true), gd.WithLayout(gd.LayoutSideBySide)))
 
+	fmt.Println("=== Word Diff Mode ===")
+	fmt.Println()
+	fmt.Println(gd.DiffWith(old, new_, gd.WithColor(true), gd.WithLayout(gd.LayoutWordDiff)))
+
 	fmt.Println("=== Prefer Side-by-Side Mode ===")
 	fmt.Println()
 	fmt.Println(gd.DiffWith(old, new_, gd.WithColor(true), gd.WithLayout(gd.LayoutPreferSideBySide)))
+
+	fmt.Println("=== Structural JSON ===")
+	fmt.Println()
+	out, err := gd.DiffJSON([]byte(old), []byte(new_), gd.WithColor(true))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(out)
 }
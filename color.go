@@ -2,77 +2,276 @@ package godelta
 
 import (
 	"os"
+	"strings"
 
 	"github.com/amterp/color"
 	"github.com/amterp/go-delta/internal/render"
 	"golang.org/x/term"
 )
 
-// newStyles builds the color objects for all visual elements.
-// Callers should use buildStyles to get render.Styles closures.
-type colorSet struct {
-	removed     *color.Color
-	added       *color.Color
-	removedEmph *color.Color
-	addedEmph   *color.Color
-	lineNum     *color.Color
-	separator   *color.Color
-}
+// ColorLevel is the color depth of the output terminal.
+type ColorLevel int
+
+const (
+	// ColorLevelNone disables color output entirely.
+	ColorLevelNone ColorLevel = iota
+	// ColorLevel16 uses only the 16 basic ANSI colors.
+	ColorLevel16
+	// ColorLevel256 uses the xterm 256-color palette.
+	ColorLevel256
+	// ColorLevelTrueColor uses 24-bit RGB colors.
+	ColorLevelTrueColor
+)
 
-func newColorSet() colorSet {
-	return colorSet{
-		removed:     color.New(color.FgRed),
-		added:       color.New(color.FgGreen),
-		removedEmph: color.New(color.FgRed, color.ReverseVideo),
-		addedEmph:   color.New(color.FgGreen, color.ReverseVideo),
-		lineNum:     color.New(color.Faint),
-		separator:   color.New(color.Faint),
+// resolveStyles builds the styles for cfg: theme colors at the
+// resolved color level, plus textual emphasis markers if requested.
+// LayoutWordDiff draws its changes with the Word* theme styles, and
+// always uses markers without color since emphasis is all it has.
+func resolveStyles(cfg config) render.Styles {
+	theme := cfg.theme
+	level := resolveColorLevel(cfg)
+	markers := cfg.emphasis == EmphasisMarkers
+	if cfg.layout == LayoutWordDiff {
+		theme.RemovedEmph = theme.WordRemoved
+		theme.AddedEmph = theme.WordAdded
+		markers = markers || level == ColorLevelNone
 	}
+
+	styles := buildStyles(theme, level)
+	if markers {
+		styles = render.WithEmphasisMarkers(styles)
+	}
+	return styles
 }
 
-// buildStyles produces render.Styles from the color set, with colors
-// enabled or disabled based on the resolved mode.
-func buildStyles(useColor bool) render.Styles {
-	if !useColor {
+// buildStyles produces render.Styles from the theme, downgrading each
+// color to what the resolved color level supports.
+func buildStyles(theme Theme, level ColorLevel) render.Styles {
+	if level == ColorLevelNone {
 		return render.NoColorStyles()
 	}
 
-	cs := newColorSet()
-	// Force color on each object so we don't depend on the global
-	// NoColor flag (which auto-detects TTY). We've already made the
-	// color decision ourselves in resolveColor.
-	allColors := []*color.Color{
-		cs.removed, cs.added, cs.removedEmph, cs.addedEmph,
-		cs.lineNum, cs.separator,
-	}
-	for _, c := range allColors {
+	wrap := func(st Style) func(string) string {
+		c := color.New(styleAttributes(st, level)...)
+		// Force color on each object so we don't depend on the global
+		// NoColor flag (which auto-detects TTY). We've already made the
+		// color decision ourselves in resolveColorLevel.
 		c.EnableColor()
-	}
-	wrap := func(c *color.Color) func(string) string {
 		return func(s string) string { return c.Sprint(s) }
 	}
 	return render.Styles{
-		Removed:     wrap(cs.removed),
-		Added:       wrap(cs.added),
-		RemovedEmph: wrap(cs.removedEmph),
-		AddedEmph:   wrap(cs.addedEmph),
-		LineNum:     wrap(cs.lineNum),
-		Separator:   wrap(cs.separator),
+		Removed:     wrap(theme.Removed),
+		Added:       wrap(theme.Added),
+		RemovedEmph: wrap(theme.RemovedEmph),
+		AddedEmph:   wrap(theme.AddedEmph),
+		LineNum:     wrap(theme.LineNum),
+		Separator:   wrap(theme.Separator),
 		Plain:       func(s string) string { return s },
+		Whitespace:  wrap(theme.Whitespace),
 	}
 }
 
-// resolveColor determines whether to use color output.
-// Priority: explicit override > FORCE_COLOR > NO_COLOR > TTY detection.
-func resolveColor(mode *bool) bool {
-	if mode != nil {
-		return *mode
+// styleAttributes converts a style into SGR attributes: foreground,
+// background, then text attributes.
+func styleAttributes(st Style, level ColorLevel) []color.Attribute {
+	var attrs []color.Attribute
+	attrs = append(attrs, colorAttributes(downgrade(st.Fg, level), false)...)
+	attrs = append(attrs, colorAttributes(downgrade(st.Bg, level), true)...)
+	flags := []struct {
+		on   bool
+		attr color.Attribute
+	}{
+		{st.Bold, color.Bold},
+		{st.Faint, color.Faint},
+		{st.Italic, color.Italic},
+		{st.Underline, color.Underline},
+		{st.Reverse, color.ReverseVideo},
+		{st.Strikethrough, color.CrossedOut},
 	}
-	if _, ok := os.LookupEnv("FORCE_COLOR"); ok {
-		return true
+	for _, f := range flags {
+		if f.on {
+			attrs = append(attrs, f.attr)
+		}
+	}
+	return attrs
+}
+
+// colorAttributes returns the SGR parameters selecting c as the
+// foreground (or background) color.
+func colorAttributes(c Color, bg bool) []color.Attribute {
+	switch c.kind {
+	case colorANSI16:
+		base := color.FgBlack
+		if bg {
+			base = color.BgBlack
+		}
+		n := color.Attribute(c.index)
+		if n >= 8 {
+			// bright variants live at 90-97 / 100-107
+			return []color.Attribute{base + 60 + n - 8}
+		}
+		return []color.Attribute{base + n}
+	case colorANSI256:
+		return []color.Attribute{extendedColor(bg), 5, color.Attribute(c.index)}
+	case colorRGB:
+		return []color.Attribute{extendedColor(bg), 2,
+			color.Attribute(c.r), color.Attribute(c.g), color.Attribute(c.b)}
+	}
+	return nil
+}
+
+// extendedColor returns the SGR code introducing a 256-color or RGB
+// foreground (38) or background (48).
+func extendedColor(bg bool) color.Attribute {
+	if bg {
+		return 48
+	}
+	return 38
+}
+
+// resolveColorLevel determines the color depth to render with.
+// Priority: explicit level > explicit on/off > FORCE_COLOR > NO_COLOR >
+// TTY detection. Whenever color ends up on, the depth itself comes
+// from the environment (see envColorLevel).
+func resolveColorLevel(cfg config) ColorLevel {
+	if cfg.colorLevel != nil {
+		return *cfg.colorLevel
+	}
+	if cfg.colorMode != nil {
+		if !*cfg.colorMode {
+			return ColorLevelNone
+		}
+		return max(ColorLevel16, envColorLevel())
+	}
+	if v, ok := os.LookupEnv("FORCE_COLOR"); ok {
+		// Same convention as the supports-color npm package: 0-3 pick
+		// a level, anything else just forces color on.
+		switch strings.ToLower(v) {
+		case "0", "false":
+			return ColorLevelNone
+		case "1":
+			return ColorLevel16
+		case "2":
+			return ColorLevel256
+		case "3":
+			return ColorLevelTrueColor
+		}
+		return max(ColorLevel16, envColorLevel())
 	}
 	if _, ok := os.LookupEnv("NO_COLOR"); ok {
-		return false
+		return ColorLevelNone
+	}
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return ColorLevelNone
 	}
-	return term.IsTerminal(int(os.Stdout.Fd()))
+	return envColorLevel()
+}
+
+// envColorLevel infers the terminal's color depth from COLORTERM and
+// TERM. Terminals that advertise nothing are assumed to handle the 16
+// basic colors.
+func envColorLevel() ColorLevel {
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return ColorLevelTrueColor
+	}
+	t := strings.ToLower(os.Getenv("TERM"))
+	switch {
+	case t == "dumb":
+		return ColorLevelNone
+	case strings.Contains(t, "truecolor"), strings.Contains(t, "24bit"),
+		strings.HasSuffix(t, "-direct"):
+		return ColorLevelTrueColor
+	case strings.Contains(t, "256color"):
+		return ColorLevel256
+	}
+	return ColorLevel16
+}
+
+// --- Color downgrading ---
+
+// downgrade converts c to the nearest color representable at level.
+func downgrade(c Color, level ColorLevel) Color {
+	switch {
+	case c.kind == colorDefault || level == ColorLevelTrueColor:
+		return c
+	case level == ColorLevel256:
+		if c.kind == colorRGB {
+			return ANSI256(nearest256(c.r, c.g, c.b))
+		}
+		return c
+	case level == ColorLevel16:
+		switch c.kind {
+		case colorRGB:
+			return ANSI(nearest16(c.r, c.g, c.b))
+		case colorANSI256:
+			if c.index < 16 {
+				return ANSI(int(c.index))
+			}
+			r, g, b := palette256(c.index)
+			return ANSI(nearest16(r, g, b))
+		}
+		return c
+	}
+	return Color{}
+}
+
+// basic16 holds the xterm default RGB values of the 16 basic colors.
+var basic16 = [16][3]uint8{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// cubeLevels are the component values of the 6x6x6 color cube that
+// occupies indices 16-231 of the 256-color palette.
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// palette256 returns the RGB value of a 256-color palette index.
+func palette256(i uint8) (r, g, b uint8) {
+	switch {
+	case i < 16:
+		c := basic16[i]
+		return c[0], c[1], c[2]
+	case i < 232:
+		i -= 16
+		return cubeLevels[i/36], cubeLevels[(i/6)%6], cubeLevels[i%6]
+	default:
+		v := 8 + 10*(i-232)
+		return v, v, v
+	}
+}
+
+// nearest256 maps an RGB color to the closest entry of the color cube
+// or grayscale ramp. The first 16 entries are skipped because terminals
+// commonly redefine them.
+func nearest256(r, g, b uint8) int {
+	best, bestDist := 16, -1
+	for i := 16; i < 256; i++ {
+		pr, pg, pb := palette256(uint8(i))
+		if d := colorDistance(r, g, b, pr, pg, pb); bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// nearest16 maps an RGB color to the closest basic color.
+func nearest16(r, g, b uint8) int {
+	best, bestDist := 0, -1
+	for i, c := range basic16 {
+		if d := colorDistance(r, g, b, c[0], c[1], c[2]); bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// colorDistance is the squared euclidean distance between two colors.
+func colorDistance(r1, g1, b1, r2, g2, b2 uint8) int {
+	dr := int(r1) - int(r2)
+	dg := int(g1) - int(g2)
+	db := int(b1) - int(b2)
+	return dr*dr + dg*dg + db*db
 }
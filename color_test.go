@@ -0,0 +1,146 @@
+package godelta
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// clearColorEnv unsets every variable resolveColorLevel looks at, so
+// tests don't depend on the developer's terminal. An empty value isn't
+// the same as unset: an empty FORCE_COLOR or NO_COLOR still counts.
+func clearColorEnv(t *testing.T) {
+	t.Helper()
+	for _, k := range []string{"FORCE_COLOR", "NO_COLOR", "COLORTERM", "TERM"} {
+		t.Setenv(k, "") // restores the variable after the test
+		os.Unsetenv(k)
+	}
+}
+
+func TestEnvColorLevel(t *testing.T) {
+	tests := []struct {
+		colorterm, term string
+		want            ColorLevel
+	}{
+		{"truecolor", "xterm", ColorLevelTrueColor},
+		{"24bit", "", ColorLevelTrueColor},
+		{"", "xterm-256color", ColorLevel256},
+		{"", "xterm-direct", ColorLevelTrueColor},
+		{"", "dumb", ColorLevelNone},
+		{"", "xterm", ColorLevel16},
+		{"", "", ColorLevel16},
+	}
+	for _, tt := range tests {
+		clearColorEnv(t)
+		t.Setenv("COLORTERM", tt.colorterm)
+		t.Setenv("TERM", tt.term)
+		if got := envColorLevel(); got != tt.want {
+			t.Errorf("COLORTERM=%q TERM=%q: got %d, want %d", tt.colorterm, tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestResolveColorLevelForceColor(t *testing.T) {
+	tests := []struct {
+		value string
+		want  ColorLevel
+	}{
+		{"0", ColorLevelNone},
+		{"false", ColorLevelNone},
+		{"1", ColorLevel16},
+		{"2", ColorLevel256},
+		{"3", ColorLevelTrueColor},
+		{"", ColorLevel256}, // falls back to TERM, below
+		{"true", ColorLevel256},
+	}
+	for _, tt := range tests {
+		clearColorEnv(t)
+		t.Setenv("TERM", "xterm-256color")
+		t.Setenv("FORCE_COLOR", tt.value)
+		if got := resolveColorLevel(defaultConfig()); got != tt.want {
+			t.Errorf("FORCE_COLOR=%q: got %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestResolveColorLevelForcedOnRespectsDepth(t *testing.T) {
+	clearColorEnv(t)
+	t.Setenv("COLORTERM", "truecolor")
+	cfg := defaultConfig()
+	WithColor(true)(&cfg)
+	if got := resolveColorLevel(cfg); got != ColorLevelTrueColor {
+		t.Errorf("got %d, want truecolor", got)
+	}
+
+	// TERM=dumb can't veto an explicit request for color
+	clearColorEnv(t)
+	t.Setenv("TERM", "dumb")
+	if got := resolveColorLevel(cfg); got != ColorLevel16 {
+		t.Errorf("got %d, want 16 colors", got)
+	}
+}
+
+func TestResolveColorLevelExplicitLevelWins(t *testing.T) {
+	clearColorEnv(t)
+	t.Setenv("FORCE_COLOR", "3")
+	cfg := defaultConfig()
+	WithColor(false)(&cfg)
+	WithColorLevel(ColorLevel256)(&cfg)
+	if got := resolveColorLevel(cfg); got != ColorLevel256 {
+		t.Errorf("got %d, want 256 colors", got)
+	}
+}
+
+func TestDowngradeRGB(t *testing.T) {
+	red := RGB(255, 0, 0)
+	if got := downgrade(red, ColorLevelTrueColor); got != red {
+		t.Errorf("truecolor should keep RGB, got %+v", got)
+	}
+	if got := downgrade(red, ColorLevel256); got != ANSI256(196) {
+		t.Errorf("expected 256-color index 196, got %+v", got)
+	}
+	if got := downgrade(red, ColorLevel16); got != ANSI(9) {
+		t.Errorf("expected bright red, got %+v", got)
+	}
+	if got := downgrade(RGB(128, 128, 128), ColorLevel256); got != ANSI256(244) {
+		t.Errorf("expected gray ramp index 244, got %+v", got)
+	}
+}
+
+func TestDowngrade256To16(t *testing.T) {
+	if got := downgrade(ANSI256(4), ColorLevel16); got != ANSI(4) {
+		t.Errorf("low palette indices should map directly, got %+v", got)
+	}
+	if got := downgrade(ANSI256(46), ColorLevel16); got != ANSI(10) {
+		t.Errorf("expected bright green, got %+v", got)
+	}
+	if got := downgrade(ANSI(3), ColorLevel16); got != ANSI(3) {
+		t.Errorf("basic colors should be unchanged, got %+v", got)
+	}
+}
+
+func TestThemeRendersAtEachLevel(t *testing.T) {
+	theme := DefaultTheme()
+	theme.Removed = Style{Fg: RGB(255, 0, 0)}
+	tests := []struct {
+		level ColorLevel
+		want  string
+	}{
+		{ColorLevelTrueColor, "\x1b[38;2;255;0;0m"},
+		{ColorLevel256, "\x1b[38;5;196m"},
+		{ColorLevel16, "\x1b[91m"},
+	}
+	for _, tt := range tests {
+		result := DiffWith("a", "xyz", WithTheme(theme), WithColorLevel(tt.level))
+		if !strings.Contains(result, tt.want) {
+			t.Errorf("level %d: expected %q in output, got:\n%q", tt.level, tt.want, result)
+		}
+	}
+}
+
+func TestColorLevelNoneHasNoEscapes(t *testing.T) {
+	result := DiffWith("a", "b", WithColorLevel(ColorLevelNone))
+	if strings.Contains(result, "\x1b[") {
+		t.Errorf("ColorLevelNone should not produce ANSI escapes, got:\n%q", result)
+	}
+}
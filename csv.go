@@ -0,0 +1,45 @@
+package godelta
+
+import (
+	"github.com/amterp/go-delta/internal/csvdiff"
+)
+
+// DiffCSV compares two CSV or TSV tables, whose first row is their
+// header, row by row and cell by cell. Both are laid out as one table
+// with aligned columns, and changed cells are emphasized whole. Rows are
+// matched in order, or with WithCSVKey, by a key column wherever they
+// are. Columns are matched by header name, so added, removed, and
+// reordered columns show as such rather than as every row changing. The
+// header row is shown at the top of every hunk, and gutters show each
+// row's line number in its file. The delimiter is detected from
+// the first line unless set with WithCSVDelimiter. Returns an empty
+// string if the tables are equal, or an error if either can't be parsed
+// or lacks the key column.
+//
+// LayoutWordDiff is treated as LayoutInline, since cells are the unit
+// of change, and whitespace markers and hunk headers are disabled.
+func DiffCSV(old, new []byte, opts ...Option) (string, error) {
+	cfg := cellConfig(opts)
+
+	hunks, err := csvdiff.Hunks(old, new, csvdiff.Options{Key: cfg.csvKey, Comma: cfg.csvComma}, cfg.contextLines)
+	if err != nil || hunks == nil {
+		return "", err
+	}
+	return renderHunks(hunks, cfg, resolveStyles(cfg)), nil
+}
+
+// cellConfig applies opts for a diff whose unit of change is a cell or
+// field, such as DiffCSV, which has no words or whitespace to mark and
+// no hunk headers.
+func cellConfig(opts []Option) config {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.layout == LayoutWordDiff {
+		cfg.layout = LayoutInline
+	}
+	cfg.whitespace = WhitespaceHidden
+	cfg.hunkHeaders = false
+	return cfg
+}
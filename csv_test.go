@@ -0,0 +1,60 @@
+package godelta
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiffCSVEqual(t *testing.T) {
+	old := "id,name\n1,a\n2,b\n"
+	new := "id,name\n2,b\n1,a\n"
+	out, err := DiffCSV([]byte(old), []byte(new), WithCSVKey("id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "" {
+		t.Errorf("reordered rows matched by key should have no diff, got:\n%s", out)
+	}
+}
+
+func TestDiffCSVDelimiter(t *testing.T) {
+	old := "id;name\n1;a,b\n"
+	new := "id;name\n1;a,c\n"
+	out, err := DiffCSV([]byte(old), []byte(new), WithColor(false), WithCSVDelimiter(';'))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "id | name") || !strings.Contains(out, "1  | a,c") {
+		t.Errorf("expected ';'-separated columns, got:\n%s", out)
+	}
+}
+
+func TestDiffCSVHeaderAboveEachHunk(t *testing.T) {
+	var old, new strings.Builder
+	old.WriteString("id,name\n")
+	new.WriteString("id,name\n")
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&old, "%d,a\n", i)
+		fmt.Fprintf(&new, "%d,a\n", i)
+	}
+	old.WriteString("20,x\n")
+	new.WriteString("20,y\n")
+	out, err := DiffCSV([]byte(strings.Replace(old.String(), "0,a", "0,b", 1)), []byte(new.String()),
+		WithColor(false), WithContextLines(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(out, "id | name"); n != 2 {
+		t.Errorf("expected the header above both hunks, got %d in:\n%s", n, out)
+	}
+}
+
+func TestDiffCSVErrors(t *testing.T) {
+	if _, err := DiffCSV([]byte("a\n\"x\n"), []byte("a\n")); err == nil || !strings.Contains(err.Error(), "old table") {
+		t.Errorf("expected an old table error, got %v", err)
+	}
+	if _, err := DiffCSV([]byte("a\n1\n"), []byte("a\n2\n"), WithCSVKey("id")); err == nil {
+		t.Error("expected an error for a missing key column")
+	}
+}
@@ -6,13 +6,21 @@
 //	output := gd.Diff(old, new)
 package godelta
 
+import (
+	"bytes"
+
+	"github.com/amterp/go-delta/internal/hexdump"
+)
+
 // Diff computes and renders a colored diff between two strings.
 // Returns an empty string if inputs are identical.
 func Diff(old, new string) string {
 	return DiffWith(old, new)
 }
 
-// DiffWith computes a diff using the provided options.
+// DiffWith computes a diff using the provided options. If either input
+// looks binary, the result only reports their sizes and hashes. Invalid
+// UTF-8 in text is shown as escaped \xNN bytes.
 func DiffWith(old, new string, opts ...Option) string {
 	if old == new {
 		return ""
@@ -23,8 +31,36 @@ func DiffWith(old, new string, opts ...Option) string {
 		opt(&cfg)
 	}
 
-	useColor := resolveColor(cfg.colorMode)
-	styles := buildStyles(useColor)
-
+	styles := resolveStyles(cfg)
+	if isBinary(old) || isBinary(new) {
+		return binarySummary(old, new, styles)
+	}
 	return runPipeline(old, new, cfg, styles)
 }
+
+// DiffBytes renders a hexdump-style diff of two byte slices: each row
+// shows an offset, up to 16 bytes in hex, and the same bytes as ASCII.
+// Bytes are diffed individually, so an insertion or deletion doesn't
+// throw the rows after it out of alignment, and changed bytes are
+// emphasized in both columns. Gutters show byte offsets in hex instead
+// of line numbers. LayoutWordDiff renders as LayoutInline, and hunk
+// headers are not shown. Returns an empty string if the inputs are
+// identical.
+func DiffBytes(old, new []byte, opts ...Option) string {
+	if bytes.Equal(old, new) {
+		return ""
+	}
+
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.layout == LayoutWordDiff {
+		cfg.layout = LayoutInline
+	}
+	cfg.whitespace = WhitespaceHidden
+	cfg.hunkHeaders = false
+
+	hunks := hexdump.Hunks(old, new, cfg.contextLines)
+	return renderHunks(hunks, cfg, resolveStyles(cfg))
+}
@@ -3,6 +3,8 @@ package godelta
 import (
 	"strings"
 	"testing"
+
+	"github.com/amterp/go-delta/internal/render"
 )
 
 func TestDiffIdenticalReturnsEmpty(t *testing.T) {
@@ -19,6 +21,35 @@ func TestDiffBothEmptyReturnsEmpty(t *testing.T) {
 	}
 }
 
+func TestDiffBOMOnly(t *testing.T) {
+	result := DiffWith("a\nb", "\uFEFFa\nb", WithColor(false))
+	if result != "~~~ UTF-8 byte order mark added ~~~\n" {
+		t.Errorf("expected only the BOM note, got %q", result)
+	}
+}
+
+func TestDiffBOMOnBothSidesIgnored(t *testing.T) {
+	result := DiffWith("\uFEFFa", "\uFEFFa\n", WithColor(false))
+	if strings.Contains(result, "\uFEFF") || strings.Contains(result, "byte order mark") {
+		t.Errorf("BOM on both sides should be stripped silently, got %q", result)
+	}
+}
+
+func TestDiffBytesIdenticalReturnsEmpty(t *testing.T) {
+	if result := DiffBytes([]byte{1, 2, 3}, []byte{1, 2, 3}); result != "" {
+		t.Errorf("identical bytes should return empty, got %q", result)
+	}
+}
+
+func TestDiffBytesWordDiffFallsBackToInline(t *testing.T) {
+	old, new := []byte("abc\x00"), []byte("abd\x00")
+	got := DiffBytes(old, new, WithColor(false), WithLayout(LayoutWordDiff))
+	want := DiffBytes(old, new, WithColor(false))
+	if got != want {
+		t.Errorf("word diff layout:\n%s\ninline:\n%s", got, want)
+	}
+}
+
 func TestDiffSimpleChange(t *testing.T) {
 	result := DiffWith("a\nb\nc", "a\nB\nc", WithColor(false))
 	if result == "" {
@@ -223,3 +254,35 @@ func TestDiffUnicode(t *testing.T) {
 		t.Fatal("unicode diff should produce output")
 	}
 }
+
+func TestDiffEscapesShownVisibly(t *testing.T) {
+	for _, layout := range []Layout{LayoutInline, LayoutSideBySide} {
+		out := DiffWith("a\x1b[2Jb", "a\x1b[2Jc", WithColor(false), WithLayout(layout), WithWidth(80))
+		if strings.Contains(out, "\x1b") {
+			t.Errorf("layout %d: escape reached the output: %q", layout, out)
+		}
+		if !strings.Contains(out, "a␛[2Jb") || !strings.Contains(out, "a␛[2Jc") {
+			t.Errorf("layout %d: expected the escapes shown as ␛, got:\n%s", layout, out)
+		}
+	}
+
+	// Hunk headers quote a line of the content too.
+	old := "func a\x1b[2J() {\n1\n2\n3\nx\n}\n"
+	new := "func a\x1b[2J() {\n1\n2\n3\ny\n}\n"
+	out := DiffWith(old, new, WithColor(false), WithContextLines(1), WithHunkHeaders(LangGo))
+	if !strings.HasPrefix(out, "@@ -4,3 +4,3 @@ func a␛[2J() {\n") {
+		t.Errorf("expected the escape shown in the hunk header, got %q", out)
+	}
+}
+
+func TestRenderWhitespace(t *testing.T) {
+	for mode, want := range map[WhitespaceMode]render.WhitespaceMode{
+		WhitespaceHidden:       render.WhitespaceNone,
+		WhitespaceChangedLines: render.WhitespaceChanged,
+		WhitespaceEmphasized:   render.WhitespaceEmphasized,
+	} {
+		if got := renderWhitespace(mode); got != want {
+			t.Errorf("renderWhitespace(%d) = %d, want %d", mode, got, want)
+		}
+	}
+}
@@ -6,6 +6,7 @@ require (
 	github.com/amterp/color v1.20.1
 	github.com/mattn/go-runewidth v0.0.19
 	golang.org/x/term v0.39.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,87 @@
+// Package godeltatest reports test failures as go-delta diffs.
+//
+// Usage:
+//
+//	import "github.com/amterp/go-delta/godeltatest"
+//
+//	func TestRender(t *testing.T) {
+//		godeltatest.Equal(t, want, render())
+//		godeltatest.EqualValues(t, wantUser, gotUser)
+//	}
+//
+// Diffs are colored when the test binary runs with -v and its output is
+// a terminal. Otherwise, as in CI logs, they are plain text with
+// changes marked as [-removed-] and {+added+}.
+package godeltatest
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	gd "github.com/amterp/go-delta"
+	"golang.org/x/term"
+)
+
+// Options returns the options failure diffs are rendered with: color
+// when running verbosely (go test -v) in a terminal with NO_COLOR
+// unset, otherwise no color and emphasis markers. Options passed to
+// the functions in this package are applied after these.
+func Options() []gd.Option {
+	_, noColor := os.LookupEnv("NO_COLOR")
+	if testing.Verbose() && !noColor && term.IsTerminal(int(os.Stdout.Fd())) {
+		return []gd.Option{gd.WithColor(true)}
+	}
+	return []gd.Option{gd.WithColor(false), gd.WithEmphasis(gd.EmphasisMarkers)}
+}
+
+// Equal reports a test error with a line diff if want and got differ,
+// and returns whether they are equal.
+func Equal(t testing.TB, want, got string, opts ...gd.Option) bool {
+	t.Helper()
+	if want == got {
+		return true
+	}
+	t.Errorf("strings differ (-want +got):\n%s", gd.DiffWith(want, got, append(Options(), opts...)...))
+	return false
+}
+
+// EqualValues reports a test error with a diff of the printed values
+// (see godelta.DiffValues) if want and got are not reflect.DeepEqual,
+// and returns whether they are equal.
+func EqualValues(t testing.TB, want, got any, opts ...gd.Option) bool {
+	t.Helper()
+	if reflect.DeepEqual(want, got) {
+		return true
+	}
+	diff := gd.DiffValues(want, got, append(Options(), opts...)...)
+	if diff == "" {
+		t.Errorf("values differ (-want +got) but print the same; the difference may be in unexported fields (see godelta.WithUnexportedFields) or in values such as NaN or funcs")
+		return false
+	}
+	t.Errorf("values differ (-want +got):\n%s", diff)
+	return false
+}
+
+// Message returns a diff of want and got as a failure message, for
+// assertion libraries that take one, such as testify:
+//
+//	assert.Equal(t, want, got, godeltatest.Message(want, got))
+//
+// Strings are diffed line by line and other values as printed by
+// godelta.DiffValues. Returns an empty string if they print the same.
+func Message(want, got any, opts ...gd.Option) string {
+	opts = append(Options(), opts...)
+	var diff string
+	w, wantString := want.(string)
+	g, gotString := got.(string)
+	if wantString && gotString {
+		diff = gd.DiffWith(w, g, opts...)
+	} else {
+		diff = gd.DiffValues(want, got, opts...)
+	}
+	if diff == "" {
+		return ""
+	}
+	return "Diff (-want +got):\n" + diff
+}
@@ -0,0 +1,87 @@
+package godeltatest
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	gd "github.com/amterp/go-delta"
+	"golang.org/x/term"
+)
+
+// recorder is a testing.TB that records errors instead of failing.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// plain renders without color whatever the environment.
+var plain = []gd.Option{gd.WithColor(false), gd.WithEmphasis(gd.EmphasisMarkers)}
+
+func TestEqual(t *testing.T) {
+	r := &recorder{}
+	if !Equal(r, "a\nb\n", "a\nb\n", plain...) || len(r.errors) != 0 {
+		t.Fatalf("equal strings should pass, got %q", r.errors)
+	}
+	if Equal(r, "a\nold line\n", "a\nnew line\n", plain...) {
+		t.Fatal("different strings should fail")
+	}
+	if len(r.errors) != 1 || !strings.HasPrefix(r.errors[0], "strings differ (-want +got):\n") ||
+		!strings.Contains(r.errors[0], "[-old-]") || !strings.Contains(r.errors[0], "{+new+}") {
+		t.Errorf("expected a marked diff, got %q", r.errors)
+	}
+}
+
+type account struct {
+	Name    string
+	Balance int
+	note    string
+}
+
+func TestEqualValues(t *testing.T) {
+	r := &recorder{}
+	if !EqualValues(r, account{Name: "a"}, account{Name: "a"}, plain...) {
+		t.Fatalf("equal values should pass, got %q", r.errors)
+	}
+	EqualValues(r, account{Name: "a", Balance: 1}, account{Name: "a", Balance: 2}, plain...)
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "Balance: [-1-],") {
+		t.Errorf("expected a diff of the balance, got %q", r.errors)
+	}
+
+	r = &recorder{}
+	EqualValues(r, account{note: "x"}, account{note: "y"}, plain...)
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "unexported") {
+		t.Errorf("expected a hint about unexported fields, got %q", r.errors)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	if m := Message("same", "same", plain...); m != "" {
+		t.Errorf("expected no message for equal strings, got %q", m)
+	}
+	m := Message("one two\n", "one three\n", plain...)
+	if !strings.HasPrefix(m, "Diff (-want +got):\n") || !strings.Contains(m, "{+three+}") {
+		t.Errorf("expected a line diff, got %q", m)
+	}
+	m = Message([]int{1, 2}, []int{1, 3}, plain...)
+	if !strings.Contains(m, "[1]") || !strings.Contains(m, "{+3+}") {
+		t.Errorf("expected a value diff with paths, got %q", m)
+	}
+}
+
+func TestOptionsWithoutTerminal(t *testing.T) {
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		t.Skip("stdout is a terminal")
+	}
+	out := gd.DiffWith("x a\n", "x b\n", Options()...)
+	if strings.Contains(out, "\x1b[") || !strings.Contains(out, "[-a-]") {
+		t.Errorf("expected plain output with markers, got %q", out)
+	}
+}
@@ -0,0 +1,138 @@
+package align
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/amterp/go-delta/internal/diff"
+)
+
+// Tolerance is how far apart two numbers can be and still compare
+// equal: within Abs of each other, or within Rel times the larger of
+// their magnitudes.
+type Tolerance struct {
+	Abs, Rel float64
+}
+
+// Equal reports whether two tokens are equal: the same text, or
+// numbers within the tolerance.
+func (t Tolerance) Equal(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if !isNumber(a) || !isNumber(b) {
+		return false
+	}
+	x, errX := strconv.ParseFloat(a, 64)
+	y, errY := strconv.ParseFloat(b, 64)
+	if errX != nil || errY != nil {
+		return false // out of range
+	}
+	d := math.Abs(x - y)
+	return d <= t.Abs || d <= t.Rel*max(math.Abs(x), math.Abs(y))
+}
+
+// LineKey returns line with its numbers replaced by a placeholder, so
+// lines equal within the tolerance have the same key.
+func (t Tolerance) LineKey(line string) string {
+	var b strings.Builder
+	for _, tok := range TokenizeNumeric(line) {
+		if isNumber(tok.Text) {
+			b.WriteString("\x00")
+		} else {
+			b.WriteString(tok.Text)
+		}
+	}
+	return b.String()
+}
+
+// LinesEqual reports whether two lines are the same except for numbers
+// within the tolerance.
+func (t Tolerance) LinesEqual(a, b string) bool {
+	at, bt := TokenizeNumeric(a), TokenizeNumeric(b)
+	if len(at) != len(bt) {
+		return false
+	}
+	for i := range at {
+		if !t.Equal(at[i].Text, bt[i].Text) {
+			return false
+		}
+	}
+	return true
+}
+
+// TokenizeNumeric is like Tokenize, but numbers such as 42, -0.5 and
+// 6.02e23 are single tokens. A sign belongs to a number unless it
+// follows a word, number or closing bracket, where it is an operator.
+// Digits within a word, such as in x86 or 1st, or after a dot, as in
+// v1.2, are not numbers.
+func TokenizeNumeric(line string) []Token {
+	var tokens []Token
+	for i := 0; i < len(line); {
+		end := i
+		if i == 0 || !isWordChar(rune(line[i-1])) && line[i-1] != '.' {
+			end += numberLen(line[i:], i == 0 || !strings.ContainsRune(")]}", rune(line[i-1])))
+		}
+		if end == i {
+			end = tokenEnd(line, i)
+		}
+		tokens = append(tokens, Token{Text: line[i:end], Start: i, End: end})
+		i = end
+	}
+	return tokens
+}
+
+// isNumber reports whether a token is a number.
+func isNumber(s string) bool {
+	return s != "" && numberLen(s, true) == len(s)
+}
+
+// numberLen returns the length of the decimal number at the start of
+// s, optionally signed, or 0 if there is none or it runs into a word.
+func numberLen(s string, signed bool) int {
+	i := 0
+	if signed && i < len(s) && (s[i] == '-' || s[i] == '+') {
+		i++
+	}
+	digits := func() int {
+		start := i
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		return i - start
+	}
+	n := digits()
+	if i+1 < len(s) && s[i] == '.' && s[i+1] >= '0' && s[i+1] <= '9' {
+		i++
+		n += digits()
+	}
+	if n == 0 {
+		return 0
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		mark := i
+		i++
+		if i < len(s) && (s[i] == '-' || s[i] == '+') {
+			i++
+		}
+		if digits() == 0 {
+			i = mark
+		}
+	}
+	if i < len(s) && (isWordChar(rune(s[i])) || s[i] >= 0x80) {
+		return 0 // part of a word, such as 1st or 0x1f
+	}
+	return i
+}
+
+// AnnotateHunksTolerance is like AnnotateHunks, but numbers are single
+// tokens and compare equal within tol, so they are only emphasized
+// when they differ by more.
+func AnnotateHunksTolerance(hunks []diff.Hunk, tol Tolerance) []AnnotatedHunk {
+	result := make([]AnnotatedHunk, len(hunks))
+	for i, h := range hunks {
+		result[i] = annotateHunkWith(h, TokenizeNumeric, tol.Equal)
+	}
+	return result
+}
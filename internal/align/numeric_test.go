@@ -0,0 +1,98 @@
+package align
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/amterp/go-delta/internal/diff"
+)
+
+func texts(tokens []Token) []string {
+	out := make([]string, len(tokens))
+	for i, t := range tokens {
+		out[i] = t.Text
+	}
+	return out
+}
+
+func TestTokenizeNumeric(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"loss=0.123456", []string{"loss", "=", "0.123456"}},
+		{"x -1.5e-3, +2E10", []string{"x", " ", "-1.5e-3", ",", " ", "+2E10"}},
+		{"a-1 [-1]", []string{"a", "-", "1", " ", "[", "-1", "]"}},
+		{"(a)-1", []string{"(", "a", ")", "-", "1"}},
+		{"x86 1st 0x1f", []string{"x86", " ", "1st", " ", "0x1f"}},
+		{"v1.2 .5", []string{"v1", ".", "2", " ", ".5"}},
+		{"2e", []string{"2e"}},
+		{"3.", []string{"3", "."}},
+	}
+	for _, tt := range tests {
+		got := TokenizeNumeric(tt.line)
+		if !reflect.DeepEqual(texts(got), tt.want) {
+			t.Errorf("TokenizeNumeric(%q) = %q, want %q", tt.line, texts(got), tt.want)
+		}
+		if strings.Join(texts(got), "") != tt.line {
+			t.Errorf("TokenizeNumeric(%q) is lossy", tt.line)
+		}
+	}
+}
+
+func TestToleranceEqual(t *testing.T) {
+	tol := Tolerance{Abs: 1e-6, Rel: 1e-3}
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"0.1234561", "0.1234569", true},
+		{"0.12345", "0.12400", false},
+		{"1000", "1000.9", true}, // relative
+		{"1000", "1002", false},
+		{"1e3", "1000", true},
+		{"abc", "abd", false},
+		{"abc", "abc", true},
+		{"1", "one", false},
+	}
+	for _, tt := range tests {
+		if got := tol.Equal(tt.a, tt.b); got != tt.want {
+			t.Errorf("Equal(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestToleranceLines(t *testing.T) {
+	tol := Tolerance{Abs: 1e-4}
+	a, b := "acc: 0.91234, loss: 1.5e-2", "acc: 0.91236, loss: 1.50001e-2"
+	if tol.LineKey(a) != tol.LineKey(b) || !tol.LinesEqual(a, b) {
+		t.Errorf("expected %q and %q equal", a, b)
+	}
+	if tol.LinesEqual(a, "acc: 0.95, loss: 1.5e-2") {
+		t.Error("expected lines with a distant number to differ")
+	}
+	if tol.LineKey("a: 1") == tol.LineKey("b: 1") {
+		t.Error("expected keys to keep non-numeric text")
+	}
+}
+
+func TestAnnotateHunksTolerance(t *testing.T) {
+	h := diff.Hunk{Lines: []diff.Line{
+		{Kind: diff.OpDelete, Content: "step 10 loss 0.5000001 acc 0.91"},
+		{Kind: diff.OpInsert, Content: "step 10 loss 0.5000002 acc 0.93"},
+	}}
+	ah := AnnotateHunksTolerance([]diff.Hunk{h}, Tolerance{Abs: 1e-6})[0]
+	if len(ah.Pairs) != 1 {
+		t.Fatalf("expected a pair, got %d", len(ah.Pairs))
+	}
+	var changed []string
+	for _, tok := range ah.Pairs[0].Alignment.New {
+		if tok.Op != AlignMatch {
+			changed = append(changed, tok.Token.Text)
+		}
+	}
+	if !reflect.DeepEqual(changed, []string{"0.93"}) {
+		t.Errorf("changed tokens = %q, want only the accuracy", changed)
+	}
+}
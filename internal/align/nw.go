@@ -27,6 +27,15 @@ type Alignment struct {
 // a normalized distance suitable for deciding whether two lines are
 // similar enough to pair.
 func Align(oldTokens, newTokens []Token) Alignment {
+	return alignWith(oldTokens, newTokens, exactMatch)
+}
+
+func exactMatch(a, b string) bool {
+	return a == b
+}
+
+// alignWith is Align with tokens matching when equal reports true.
+func alignWith(oldTokens, newTokens []Token, equal func(a, b string) bool) Alignment {
 	n := len(oldTokens)
 	m := len(newTokens)
 
@@ -69,7 +78,7 @@ func Align(oldTokens, newTokens []Token) Alignment {
 	for i := 1; i <= n; i++ {
 		for j := 1; j <= m; j++ {
 			cost := 1 // mismatch
-			if oldTokens[i-1].Text == newTokens[j-1].Text {
+			if equal(oldTokens[i-1].Text, newTokens[j-1].Text) {
 				cost = 0 // match
 			}
 			del := dp[i-1][j] + 1
@@ -98,7 +107,7 @@ func Align(oldTokens, newTokens []Token) Alignment {
 			newAligned = append(newAligned, AlignedToken{Op: AlignInsert, Token: newTokens[j-1]})
 			insertions++
 			j--
-		} else if i > 0 && j > 0 && oldTokens[i-1].Text == newTokens[j-1].Text &&
+		} else if i > 0 && j > 0 && equal(oldTokens[i-1].Text, newTokens[j-1].Text) &&
 			dp[i][j] == dp[i-1][j-1] {
 			// match
 			oldAligned = append(oldAligned, AlignedToken{Op: AlignMatch, Token: oldTokens[i-1]})
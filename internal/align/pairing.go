@@ -1,6 +1,11 @@
 package align
 
-import "github.com/amterp/go-delta/internal/diff"
+import (
+	"slices"
+	"sort"
+
+	"github.com/amterp/go-delta/internal/diff"
+)
 
 // DistanceThreshold controls the maximum normalized edit distance for
 // two lines to be considered a pair. Must be in [0.0, 1.0]. Lines at
@@ -45,6 +50,12 @@ func AnnotateHunks(hunks []diff.Hunk) []AnnotatedHunk {
 }
 
 func annotateHunk(h diff.Hunk) AnnotatedHunk {
+	return annotateHunkWith(h, Tokenize, exactMatch)
+}
+
+// annotateHunkWith pairs lines as annotateHunk does, splitting them
+// with tokenize and matching tokens with equal.
+func annotateHunkWith(h diff.Hunk, tokenize func(string) []Token, equal func(a, b string) bool) AnnotatedHunk {
 	ah := AnnotatedHunk{Hunk: h}
 
 	// Track which added lines have been paired
@@ -55,7 +66,7 @@ func annotateHunk(h diff.Hunk) AnnotatedHunk {
 			continue
 		}
 
-		oldTokens := Tokenize(line.Content)
+		oldTokens := tokenize(line.Content)
 		if len(oldTokens) > maxAlignTokens {
 			continue // too many tokens, skip alignment
 		}
@@ -72,11 +83,11 @@ func annotateHunk(h diff.Hunk) AnnotatedHunk {
 				continue
 			}
 
-			newTokens := Tokenize(h.Lines[j].Content)
+			newTokens := tokenize(h.Lines[j].Content)
 			if len(newTokens) > maxAlignTokens {
 				continue // too many tokens, skip alignment
 			}
-			alignment := Align(oldTokens, newTokens)
+			alignment := alignWith(oldTokens, newTokens, equal)
 
 			if alignment.Distance < DistanceThreshold {
 				ah.Pairs = append(ah.Pairs, LinePair{
@@ -92,3 +103,198 @@ func annotateHunk(h diff.Hunk) AnnotatedHunk {
 
 	return ah
 }
+
+// closestCandidates, maxTokenLines and maxCandidateCells bound the work
+// of pairing a hunk's lines by similarity, so it grows linearly with
+// the hunk rather than comparing every removed line with every added
+// one. Each removed line is compared with up to closestCandidates added
+// lines sharing the most tokens with it, ignoring tokens on more than
+// maxTokenLines added lines, and up to closestCandidates added lines
+// nearest its position, stopping once about maxCandidateCells NW matrix
+// cells have been aligned for it.
+const (
+	closestCandidates = 8
+	maxTokenLines     = 32
+	maxCandidateCells = 1 << 16
+)
+
+// AnnotateHunksClosest is like AnnotateHunks, but pairs each removed
+// line with the most similar added line rather than the first similar
+// enough one, most similar pairs first. It suits hunks whose lines
+// aren't in their original order, where the nearest added line is no
+// more likely to correspond than any other. Token matching uses equal,
+// or exact comparison if nil, and lines are split with tokenize. Each
+// removed line is only compared with a bounded number of likely added
+// lines (see closestCandidates).
+func AnnotateHunksClosest(hunks []diff.Hunk, tokenize func(string) []Token, equal func(a, b string) bool) []AnnotatedHunk {
+	if equal == nil {
+		equal = exactMatch
+	}
+	result := make([]AnnotatedHunk, len(hunks))
+	for i, h := range hunks {
+		result[i] = annotateHunkClosest(h, tokenize, equal)
+	}
+	return result
+}
+
+func annotateHunkClosest(h diff.Hunk, tokenize func(string) []Token, equal func(a, b string) bool) AnnotatedHunk {
+	ah := AnnotatedHunk{Hunk: h}
+
+	tokens := make([][]Token, len(h.Lines))
+	var dels, inss []int
+	for i, line := range h.Lines {
+		if line.Kind == diff.OpEqual {
+			continue
+		}
+		if tokens[i] = tokenize(line.Content); len(tokens[i]) > maxAlignTokens {
+			continue // too many tokens, skip alignment
+		}
+		if line.Kind == diff.OpDelete {
+			dels = append(dels, i)
+		} else {
+			inss = append(inss, i)
+		}
+	}
+	if len(dels) == 0 || len(inss) == 0 {
+		return ah
+	}
+
+	// The added lines each token is on, by position among inss.
+	lines := make(map[string][]int)
+	for k, j := range inss {
+		for _, t := range distinctTokens(tokens[j]) {
+			lines[t] = append(lines[t], k)
+		}
+	}
+
+	type candidate struct {
+		oldIdx, newIdx int
+		distance       float64
+	}
+	var near []candidate
+	for r, i := range dels {
+		cells := 0
+		for _, k := range closestCandidatesOf(distinctTokens(tokens[i]), lines, r*len(inss)/len(dels), len(inss)) {
+			j := inss[k]
+			// Only lines of similar enough lengths can be close: at most
+			// the shorter line's tokens match, so the distance is at
+			// least the difference in lengths over their sum.
+			n, m := len(tokens[i]), len(tokens[j])
+			if n+m > 0 && float64(max(n, m)-min(n, m))/float64(n+m) >= DistanceThreshold {
+				continue
+			}
+			if cells > 0 && cells+n*m > maxCandidateCells {
+				break
+			}
+			cells += n * m
+			// Keep only the distance while comparing, and align the
+			// chosen pairs again afterwards.
+			if d := alignWith(tokens[i], tokens[j], equal).Distance; d < DistanceThreshold {
+				near = append(near, candidate{oldIdx: i, newIdx: j, distance: d})
+			}
+		}
+	}
+
+	sort.SliceStable(near, func(a, b int) bool { return near[a].distance < near[b].distance })
+	paired := make(map[int]bool)
+	for _, c := range near {
+		if paired[c.oldIdx] || paired[c.newIdx] {
+			continue
+		}
+		paired[c.oldIdx], paired[c.newIdx] = true, true
+		ah.Pairs = append(ah.Pairs, LinePair{
+			OldIdx:    c.oldIdx,
+			NewIdx:    c.newIdx,
+			Alignment: alignWith(tokens[c.oldIdx], tokens[c.newIdx], equal),
+		})
+	}
+	sort.Slice(ah.Pairs, func(a, b int) bool { return ah.Pairs[a].OldIdx < ah.Pairs[b].OldIdx })
+	return ah
+}
+
+// closestCandidatesOf returns, by position among n added lines, the
+// added lines to compare a removed line with: those sharing the most of
+// its distinct tokens, most first, then those nearest pos. lines holds
+// the added lines each token is on; tokens on too many of them say
+// little and are ignored.
+func closestCandidatesOf(tokens []string, lines map[string][]int, pos, n int) []int {
+	shared := make(map[int]int)
+	for _, t := range tokens {
+		if on := lines[t]; len(on) <= maxTokenLines {
+			for _, k := range on {
+				shared[k]++
+			}
+		}
+	}
+	byShared := make([]int, 0, len(shared))
+	for k := range shared {
+		byShared = append(byShared, k)
+	}
+	sort.Slice(byShared, func(a, b int) bool {
+		ka, kb := byShared[a], byShared[b]
+		if shared[ka] != shared[kb] {
+			return shared[ka] > shared[kb]
+		}
+		return ka < kb
+	})
+	out := slices.Clip(byShared[:min(len(byShared), closestCandidates)])
+
+	seen := make(map[int]bool, len(out))
+	for _, k := range out {
+		seen[k] = true
+	}
+	// Walk outward from pos: pos, pos+1, pos-1, pos+2, ...
+	added := 0
+	for d := 0; added < closestCandidates && (pos-d >= 0 || pos+d < n); d++ {
+		for _, k := range []int{pos + d, pos - d} {
+			if k < 0 || k >= n || seen[k] || added == closestCandidates {
+				continue
+			}
+			seen[k] = true
+			out = append(out, k)
+			added++
+		}
+	}
+	return out
+}
+
+// distinctTokens returns the texts of tokens, each once.
+func distinctTokens(tokens []Token) []string {
+	seen := make(map[string]bool, len(tokens))
+	var out []string
+	for _, t := range tokens {
+		if !seen[t.Text] {
+			seen[t.Text] = true
+			out = append(out, t.Text)
+		}
+	}
+	return out
+}
+
+// AnnotatePairs is like AnnotateHunks, but pairs lines as given instead
+// of by similarity, for diffs that know which lines correspond. pairs
+// index into the flat line sequence the hunks were computed from, in
+// order; pairs outside every hunk are ignored.
+func AnnotatePairs(hunks []diff.Hunk, pairs []diff.Pair) []AnnotatedHunk {
+	annotated := make([]AnnotatedHunk, len(hunks))
+	start := 0 // index of the hunk's first line in the flat sequence
+	p := 0
+	for i, h := range hunks {
+		start += h.Skipped
+		end := start + len(h.Lines)
+		annotated[i] = AnnotatedHunk{Hunk: h}
+		for ; p < len(pairs) && pairs[p].New < end; p++ {
+			if pairs[p].Old < start {
+				continue
+			}
+			oldIdx, newIdx := pairs[p].Old-start, pairs[p].New-start
+			annotated[i].Pairs = append(annotated[i].Pairs, LinePair{
+				OldIdx:    oldIdx,
+				NewIdx:    newIdx,
+				Alignment: Align(Tokenize(h.Lines[oldIdx].Content), Tokenize(h.Lines[newIdx].Content)),
+			})
+		}
+		start = end
+	}
+	return annotated
+}
@@ -1,6 +1,8 @@
 package align
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/amterp/go-delta/internal/diff"
@@ -118,3 +120,97 @@ func TestAnnotateHunksMultipleHunks(t *testing.T) {
 		t.Errorf("hunk 1: expected 1 pair, got %d", len(annotated[1].Pairs))
 	}
 }
+
+func TestAnnotatePairs(t *testing.T) {
+	lines := []diff.Line{
+		{Kind: diff.OpDelete, Content: `"state": "IL",`},
+		{Kind: diff.OpDelete, Content: `"street": "Main"`},
+		{Kind: diff.OpInsert, Content: `"street": "Oak",`},
+		{Kind: diff.OpEqual, Content: "a"},
+		{Kind: diff.OpEqual, Content: "b"},
+		{Kind: diff.OpEqual, Content: "c"},
+		{Kind: diff.OpDelete, Content: "x"},
+		{Kind: diff.OpInsert, Content: "y"},
+	}
+	hunks := diff.ComputeHunks(lines, 0)
+	annotated := AnnotatePairs(hunks, []diff.Pair{{Old: 1, New: 2}, {Old: 6, New: 7}})
+	if len(annotated) != 2 {
+		t.Fatalf("expected 2 hunks, got %d", len(annotated))
+	}
+	// Similarity alone would pair "state" with "street".
+	if p := annotated[0].Pairs; len(p) != 1 || p[0].OldIdx != 1 || p[0].NewIdx != 2 {
+		t.Errorf("first hunk pairs = %+v, want (1,2)", p)
+	}
+	if p := annotated[1].Pairs; len(p) != 1 || p[0].OldIdx != 0 || p[0].NewIdx != 1 {
+		t.Errorf("second hunk pairs = %+v, want (0,1)", p)
+	}
+}
+
+func TestAnnotateHunksClosestPrefersMostSimilar(t *testing.T) {
+	// Greedy pairing would pair "foo bar" with "foo baz"; the closest
+	// match for it is "foo bar!".
+	h := diff.Hunk{
+		Lines: []diff.Line{
+			{Kind: diff.OpDelete, Content: "foo bar"},
+			{Kind: diff.OpDelete, Content: "foo qux"},
+			{Kind: diff.OpInsert, Content: "foo baz"},
+			{Kind: diff.OpInsert, Content: "foo bar!"},
+		},
+	}
+	pairs := AnnotateHunksClosest([]diff.Hunk{h}, Tokenize, nil)[0].Pairs
+	if len(pairs) != 2 {
+		t.Fatalf("expected 2 pairs, got %d", len(pairs))
+	}
+	if pairs[0].OldIdx != 0 || pairs[0].NewIdx != 3 {
+		t.Errorf("pair 0: expected (0,3), got (%d,%d)", pairs[0].OldIdx, pairs[0].NewIdx)
+	}
+	if pairs[1].OldIdx != 1 || pairs[1].NewIdx != 2 {
+		t.Errorf("pair 1: expected (1,2), got (%d,%d)", pairs[1].OldIdx, pairs[1].NewIdx)
+	}
+}
+
+func TestAnnotateHunksClosestBounded(t *testing.T) {
+	// hunk returns n removed and n added lines of words tokens each,
+	// every removed line close to every added one.
+	hunk := func(n, words int) diff.Hunk {
+		var h diff.Hunk
+		line := strings.Repeat("word ", words)
+		for i := 0; i < n; i++ {
+			h.Lines = append(h.Lines, diff.Line{Kind: diff.OpDelete, Content: fmt.Sprintf("%sold%d", line, i)})
+		}
+		for i := 0; i < n; i++ {
+			h.Lines = append(h.Lines, diff.Line{Kind: diff.OpInsert, Content: fmt.Sprintf("%snew%d", line, i)})
+		}
+		return h
+	}
+
+	// Large hunks, in lines or in tokens per line, are still paired,
+	// comparing each removed line with a bounded number of added lines.
+	for _, h := range []diff.Hunk{hunk(50, 5), hunk(1100, 1), hunk(100, 100)} {
+		if pairs := AnnotateHunksClosest([]diff.Hunk{h}, Tokenize, nil)[0].Pairs; len(pairs) != len(h.Lines)/2 {
+			t.Errorf("expected every line of a hunk of %d lines paired, got %d pairs", len(h.Lines), len(pairs))
+		}
+	}
+}
+
+func TestAnnotateHunksClosestShuffled(t *testing.T) {
+	// Like a go.sum file in a different order: each removed line's
+	// counterpart is far from it, but shares rare tokens with it.
+	const n = 2000
+	var h diff.Hunk
+	for i := 0; i < n; i++ {
+		h.Lines = append(h.Lines, diff.Line{Kind: diff.OpDelete, Content: fmt.Sprintf("example.com/mod%d v1.0.%d h1:sum%d=", i, i%7, i)})
+	}
+	for i := n - 1; i >= 0; i-- {
+		h.Lines = append(h.Lines, diff.Line{Kind: diff.OpInsert, Content: fmt.Sprintf("example.com/mod%d v1.0.%d h1:sum%dx=", i, i%7, i)})
+	}
+	pairs := AnnotateHunksClosest([]diff.Hunk{h}, Tokenize, nil)[0].Pairs
+	if len(pairs) != n {
+		t.Fatalf("expected %d pairs, got %d", n, len(pairs))
+	}
+	for _, p := range pairs {
+		if want := 2*n - 1 - p.OldIdx; p.NewIdx != want {
+			t.Fatalf("line %d paired with %d, want %d", p.OldIdx, p.NewIdx, want)
+		}
+	}
+}
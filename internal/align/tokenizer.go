@@ -19,71 +19,43 @@ type Token struct {
 //   - Each whitespace character is its own token (for precise NW alignment)
 //
 // The tokenization is lossless: joining all token texts reproduces the
-// original line exactly.
+// original line exactly, even if it isn't valid UTF-8. Each invalid
+// byte is a token of its own, and offsets always count original bytes.
 func Tokenize(line string) []Token {
 	if line == "" {
 		return nil
 	}
 
 	var tokens []Token
-	runes := []rune(line)
-	i := 0
-
-	for i < len(runes) {
-		start := byteOffset(runes, i)
-		r := runes[i]
+	for i := 0; i < len(line); {
+		end := tokenEnd(line, i)
+		tokens = append(tokens, Token{Text: line[i:end], Start: i, End: end})
+		i = end
+	}
+	return tokens
+}
 
-		switch {
-		case isWordChar(r):
-			// consume word run
-			j := i + 1
-			for j < len(runes) && isWordChar(runes[j]) {
-				j++
+// tokenEnd returns the end of the Tokenize token starting at line[i].
+func tokenEnd(line string, i int) int {
+	r, size := utf8.DecodeRuneInString(line[i:])
+	i += size
+
+	if isWordChar(r) {
+		// consume word run
+		for i < len(line) {
+			r, size := utf8.DecodeRuneInString(line[i:])
+			if !isWordChar(r) {
+				break
 			}
-			end := byteOffset(runes, j)
-			tokens = append(tokens, Token{
-				Text:  line[start:end],
-				Start: start,
-				End:   end,
-			})
-			i = j
-
-		case unicode.IsSpace(r):
-			// individual whitespace characters so NW can align
-			// runs that differ by only a few characters
-			end := byteOffset(runes, i+1)
-			tokens = append(tokens, Token{
-				Text:  line[start:end],
-				Start: start,
-				End:   end,
-			})
-			i++
-
-		default:
-			// single punctuation/operator character
-			end := byteOffset(runes, i+1)
-			tokens = append(tokens, Token{
-				Text:  line[start:end],
-				Start: start,
-				End:   end,
-			})
-			i++
+			i += size
 		}
 	}
-
-	return tokens
+	// Otherwise the token is a single whitespace character (so NW
+	// can align runs that differ by only a few characters), a single
+	// punctuation/operator character, or a single invalid byte.
+	return i
 }
 
 func isWordChar(r rune) bool {
 	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
 }
-
-// byteOffset returns the byte offset of the i-th rune in a rune slice,
-// relative to the original string.
-func byteOffset(runes []rune, i int) int {
-	offset := 0
-	for j := 0; j < i; j++ {
-		offset += utf8.RuneLen(runes[j])
-	}
-	return offset
-}
@@ -56,6 +56,8 @@ func TestTokenizeLossless(t *testing.T) {
 		"héllo wörld",
 		"a+b*c/d",
 		"",
+		"ab\xffcd \xc3",
+		"\xe2\x82",
 	}
 	for _, input := range inputs {
 		tokens := Tokenize(input)
@@ -84,6 +86,20 @@ func TestTokenizePositions(t *testing.T) {
 	}
 }
 
+func TestTokenizeInvalidUTF8Positions(t *testing.T) {
+	line := "ab\xffc\u00e9"
+	tokens := Tokenize(line)
+	assertTokenTexts(t, tokens, []string{"ab", "\xff", "c\u00e9"})
+	for _, tok := range tokens {
+		if line[tok.Start:tok.End] != tok.Text {
+			t.Errorf("token %q: offsets [%d,%d) select %q", tok.Text, tok.Start, tok.End, line[tok.Start:tok.End])
+		}
+	}
+	if last := tokens[len(tokens)-1]; last.End != len(line) {
+		t.Errorf("last token ends at %d, want %d", last.End, len(line))
+	}
+}
+
 func assertTokenTexts(t *testing.T, tokens []Token, expected []string) {
 	t.Helper()
 	if len(tokens) != len(expected) {
@@ -0,0 +1,359 @@
+// Package csvdiff diffs delimited tables (CSV, TSV) row by row and cell
+// by cell, laying them out as aligned columns.
+package csvdiff
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/amterp/go-delta/internal/align"
+	"github.com/amterp/go-delta/internal/diff"
+	"github.com/amterp/go-delta/internal/structdiff"
+	"github.com/mattn/go-runewidth"
+)
+
+// separator is drawn between columns.
+const separator = " | "
+
+// Options controls how tables are compared.
+type Options struct {
+	// Key is the header of the column identifying rows. Rows with the
+	// same key are compared wherever they are. Empty compares rows in
+	// order.
+	Key string
+	// Comma is the field delimiter, or 0 to detect ',' or '\t' from the
+	// first line.
+	Comma rune
+}
+
+// table is a parsed file: its header, rows, and the line each row
+// starts on.
+type table struct {
+	header []string
+	rows   [][]string
+	lines  []int // 1-based line of each row; the header is on line 1
+}
+
+// parse reads a delimited table whose first record is its header.
+// Short rows are padded; columns beyond the header get empty names.
+func parse(data []byte, comma rune) (table, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	var t table
+	for first := true; ; first = false {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return table{}, err
+		}
+		if first {
+			t.header = record
+			continue
+		}
+		line, _ := r.FieldPos(0)
+		t.rows = append(t.rows, record)
+		t.lines = append(t.lines, line)
+	}
+
+	width := len(t.header)
+	for _, row := range t.rows {
+		width = max(width, len(row))
+	}
+	for len(t.header) < width {
+		t.header = append(t.header, "")
+	}
+	for i, row := range t.rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		t.rows[i] = row
+	}
+	return t, nil
+}
+
+// DetectComma returns '\t' if the first line of data has more tabs than
+// commas, otherwise ','.
+func DetectComma(data []byte) rune {
+	first, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(first, []byte("\t")) > bytes.Count(first, []byte(",")) {
+		return '\t'
+	}
+	return ','
+}
+
+// column is a column of the merged table and its index in each input,
+// -1 where it is missing.
+type column struct {
+	name     string
+	old, new int
+	width    int
+}
+
+// Hunks diffs two tables and returns the changed rows with
+// contextRows unchanged rows around them. Each hunk starts with the
+// header row, which is shown as a hunk of its own if it is the only
+// change. Columns are matched by
+// header name and laid out in the new table's order; removed columns
+// follow the column before them in the old table. Changed cells are
+// emphasized. Gutters show each row's line number in its file.
+func Hunks(old, new []byte, opts Options, contextRows int) ([]align.AnnotatedHunk, error) {
+	comma := opts.Comma
+	if comma == 0 {
+		comma = DetectComma(new)
+		if len(bytes.TrimSpace(new)) == 0 {
+			comma = DetectComma(old)
+		}
+	}
+	oldTable, err := parse(old, comma)
+	if err != nil {
+		return nil, fmt.Errorf("old table: %w", err)
+	}
+	newTable, err := parse(new, comma)
+	if err != nil {
+		return nil, fmt.Errorf("new table: %w", err)
+	}
+
+	cols := mergeColumns(oldTable.header, newTable.header)
+	for i := range cols {
+		c := &cols[i]
+		c.width = cellWidth(c.name)
+		for _, t := range []struct {
+			table
+			idx int
+		}{{oldTable, c.old}, {newTable, c.new}} {
+			if t.idx < 0 {
+				continue
+			}
+			for _, row := range t.rows {
+				c.width = max(c.width, cellWidth(row[t.idx]))
+			}
+		}
+	}
+
+	matches, err := matchRows(oldTable, newTable, cols, opts.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := builder{cols: cols}
+	header.row(oldTable.header, newTable.header, 1, 1)
+	b := builder{cols: cols}
+	for _, m := range matches {
+		var o, n []string
+		var ol, nl int
+		if m.Old >= 0 {
+			o, ol = oldTable.rows[m.Old], oldTable.lines[m.Old]
+		}
+		if m.New >= 0 {
+			n, nl = newTable.rows[m.New], newTable.lines[m.New]
+		}
+		b.row(o, n, ol, nl)
+	}
+
+	hunks := diff.ComputeHunks(b.Lines, contextRows)
+	headerChanged := len(header.Lines) > 0 && header.Lines[0].Kind != diff.OpEqual
+	if len(hunks) == 0 && !headerChanged {
+		return nil, nil
+	}
+
+	head := header.Annotate([]diff.Hunk{{OldStart: 1, NewStart: 1, Lines: header.Lines}})[0]
+	if len(hunks) == 0 {
+		return []align.AnnotatedHunk{head}, nil
+	}
+	annotated := b.Annotate(hunks)
+	for i, h := range annotated {
+		// Start each hunk with the header, so its columns are named
+		// wherever it is.
+		h.OldStart++ // after the header
+		h.NewStart++
+		h.Lines = append(slices.Clip(head.Lines), h.Lines...)
+		pairs := slices.Clone(head.Pairs)
+		for _, p := range h.Pairs {
+			p.OldIdx += len(head.Lines)
+			p.NewIdx += len(head.Lines)
+			pairs = append(pairs, p)
+		}
+		h.Pairs = pairs
+		annotated[i] = h
+	}
+	return annotated, nil
+}
+
+// mergeColumns matches the columns of two headers by name, repeated
+// names in order, in the new header's order with removed columns after
+// the column before them in the old header.
+func mergeColumns(old, new []string) []column {
+	var cols []column
+	for _, m := range structdiff.MatchByID(occurrences(old), occurrences(new)) {
+		c := column{old: m.Old, new: m.New}
+		if m.New >= 0 {
+			c.name = new[m.New]
+		} else {
+			c.name = old[m.Old]
+		}
+		cols = append(cols, c)
+	}
+	return cols
+}
+
+// occurrences makes names unique by numbering their repetitions, so the
+// second "x" matches the other side's second "x". The result never
+// contains "".
+func occurrences(names []string) []string {
+	seen := map[string]int{}
+	out := make([]string, len(names))
+	for i, n := range names {
+		seen[n]++
+		out[i] = fmt.Sprintf("%d\x00%s", seen[n], n)
+	}
+	return out
+}
+
+// matchRows matches the rows of two tables: by key column if key is
+// set, otherwise in order by the cells of the columns they share.
+func matchRows(old, new table, cols []column, key string) ([]structdiff.Match, error) {
+	if key != "" {
+		k := -1
+		for i, c := range cols {
+			if c.name == key && c.old >= 0 && c.new >= 0 {
+				k = i
+				break
+			}
+		}
+		if k < 0 {
+			return nil, fmt.Errorf("key column %q is not in both headers", key)
+		}
+		keys := func(t table, idx int) []string {
+			ks := make([]string, len(t.rows))
+			for i, row := range t.rows {
+				ks[i] = row[idx]
+			}
+			return occurrences(ks)
+		}
+		return structdiff.MatchByID(keys(old, cols[k].old), keys(new, cols[k].new)), nil
+	}
+
+	ids := func(t table, side func(column) int) []string {
+		out := make([]string, len(t.rows))
+		for i, row := range t.rows {
+			var b strings.Builder
+			for _, c := range cols {
+				if c.old >= 0 && c.new >= 0 {
+					b.WriteString(row[side(c)] + "\x00")
+				}
+			}
+			out[i] = b.String()
+		}
+		return out
+	}
+	oldIDs := ids(old, func(c column) int { return c.old })
+	newIDs := ids(new, func(c column) int { return c.new })
+
+	// Rows removed and added next to each other are compared cell by
+	// cell when they share a cell, otherwise shown as unrelated.
+	var out []structdiff.Match
+	structdiff.WalkMatches(structdiff.ScriptMatches(diff.EditScript(oldIDs, newIDs)),
+		func(i, j int) bool { return shareCell(old.rows[i], new.rows[j], cols) },
+		func(i, j int) { out = append(out, structdiff.Match{Old: i, New: j}) },
+		func(i int) { out = append(out, structdiff.Match{Old: i, New: -1}) },
+		func(j int) { out = append(out, structdiff.Match{Old: -1, New: j}) })
+	return out, nil
+}
+
+// shareCell reports whether two rows have the same non-empty cell in a
+// column they share.
+func shareCell(old, new []string, cols []column) bool {
+	for _, c := range cols {
+		if c.old >= 0 && c.new >= 0 && old[c.old] != "" && old[c.old] == new[c.new] {
+			return true
+		}
+	}
+	return false
+}
+
+// builder lays out rows as diff lines.
+type builder struct {
+	structdiff.Builder
+	cols []column
+}
+
+// row adds a row that is old in the old table and new in the new one,
+// either nil if missing, on the given lines of each file.
+func (b *builder) row(old, new []string, oldLine, newLine int) {
+	oldLabel, newLabel := fmt.Sprint(oldLine), fmt.Sprint(newLine)
+	oldCells := make([]cell, len(b.cols))
+	newCells := make([]cell, len(b.cols))
+	changed := old == nil || new == nil
+	for i, c := range b.cols {
+		var o, n string
+		if old != nil && c.old >= 0 {
+			o = old[c.old]
+		}
+		if new != nil && c.new >= 0 {
+			n = new[c.new]
+		}
+		oldCells[i].text, newCells[i].text = o, n
+		if old != nil && new != nil && o != n {
+			changed = true
+			if c.old >= 0 {
+				oldCells[i].op = align.AlignDelete
+			}
+			if c.new >= 0 {
+				newCells[i].op = align.AlignInsert
+			}
+		}
+	}
+
+	oldTokens, newTokens := b.tokens(oldCells), b.tokens(newCells)
+	switch {
+	case !changed:
+		b.Lines = append(b.Lines, diff.Line{Kind: diff.OpEqual, Content: structdiff.Text(newTokens), OldLabel: oldLabel, NewLabel: newLabel})
+	case old != nil && new != nil:
+		b.AddPair(oldTokens, newTokens, oldLabel, newLabel)
+	case old != nil:
+		b.Lines = append(b.Lines, diff.Line{Kind: diff.OpDelete, Content: structdiff.Text(oldTokens), OldLabel: oldLabel})
+	default:
+		b.Lines = append(b.Lines, diff.Line{Kind: diff.OpInsert, Content: structdiff.Text(newTokens), NewLabel: newLabel})
+	}
+}
+
+// cell is one cell of a row and how it changed.
+type cell struct {
+	text string
+	op   align.AlignOp
+}
+
+// tokens formats cells as aligned columns, one token per cell and one
+// per padding and separator, so changed cells are emphasized whole.
+func (b *builder) tokens(cells []cell) []align.AlignedToken {
+	var tokens []align.AlignedToken
+	for i, c := range cells {
+		if i > 0 {
+			tokens = structdiff.AppendToken(tokens, separator, align.AlignMatch)
+		}
+		t := cellText(c.text)
+		tokens = structdiff.AppendToken(tokens, t, c.op)
+		if i < len(cells)-1 {
+			tokens = structdiff.AppendToken(tokens, strings.Repeat(" ", b.cols[i].width-runewidth.StringWidth(t)), align.AlignMatch)
+		}
+	}
+	return tokens
+}
+
+// cellText makes a cell fit on one line: newlines are shown as "↵" and
+// tabs as "⇥", so columns stay aligned.
+func cellText(s string) string {
+	return strings.NewReplacer("\r\n", "↵", "\n", "↵", "\t", "⇥").Replace(s)
+}
+
+func cellWidth(s string) int {
+	return runewidth.StringWidth(cellText(s))
+}
@@ -0,0 +1,190 @@
+package csvdiff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/amterp/go-delta/internal/align"
+	"github.com/amterp/go-delta/internal/diff"
+)
+
+// render shows hunks as "-"/"+"/" " prefixed lines, one hunk per group.
+func render(hunks []align.AnnotatedHunk) []string {
+	var out []string
+	for _, h := range hunks {
+		for _, l := range h.Lines {
+			prefix := map[diff.OpKind]string{diff.OpEqual: " ", diff.OpDelete: "-", diff.OpInsert: "+"}[l.Kind]
+			out = append(out, prefix+strings.TrimRight(l.Content, " "))
+		}
+	}
+	return out
+}
+
+func TestHunksIdentical(t *testing.T) {
+	data := []byte("id,name\n1,a\n")
+	hunks, err := Hunks(data, data, Options{}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hunks != nil {
+		t.Errorf("expected no hunks, got %v", render(hunks))
+	}
+}
+
+func TestHunksKeyedRows(t *testing.T) {
+	old := "id,name\n1,Alice\n2,Bob\n3,Carol\n"
+	new := "id,name\n3,Carol\n2,Bob\n1,Alicia\n"
+	hunks, err := Hunks([]byte(old), []byte(new), Options{Key: "id"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		" id | name",
+		"-1  | Alice",
+		"+1  | Alicia",
+	}
+	if got := render(hunks); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	// Gutters show each row's line in its file.
+	l := hunks[0].Lines
+	if l[1].OldLabel != "2" || l[2].NewLabel != "4" {
+		t.Errorf("labels = %q, %q; want 2, 4", l[1].OldLabel, l[2].NewLabel)
+	}
+}
+
+func TestHunksUnkeyedRows(t *testing.T) {
+	old := "id,name\n1,Alice\n2,Bob\n3,Carol\n"
+	new := "id,name\n1,Alice\n2,Robert\n4,Dan\n"
+	hunks, err := Hunks([]byte(old), []byte(new), Options{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		" id | name",
+		"-2  | Bob",
+		"+2  | Robert",
+		"-3  | Carol",
+		"+4  | Dan",
+	}
+	if got := render(hunks); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if n := len(hunks[0].Pairs); n != 1 {
+		t.Errorf("expected only the rows sharing a cell to pair, got %d pairs", n)
+	}
+}
+
+func TestHunksColumns(t *testing.T) {
+	old := "id,city,age\n1,Paris,30\n"
+	new := "id,email,age\n1,a@x,30\n"
+	hunks, err := Hunks([]byte(old), []byte(new), Options{Key: "id"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"-id | city  |       | age",
+		"+id |       | email | age",
+		"-1  | Paris |       | 30",
+		"+1  |       | a@x   | 30",
+	}
+	if got := render(hunks); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHunksChangedCells(t *testing.T) {
+	old := "a,b,c\nx,y,z\n"
+	new := "a,b,c\nx,Y,z\n"
+	hunks, err := Hunks([]byte(old), []byte(new), Options{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hunks) != 1 || len(hunks[0].Pairs) != 1 {
+		t.Fatalf("expected one hunk with one paired row, got %v", render(hunks))
+	}
+	a := hunks[0].Pairs[0].Alignment
+	var changed []string
+	for _, tok := range a.Old {
+		if tok.Op == align.AlignDelete {
+			changed = append(changed, "-"+tok.Token.Text)
+		}
+	}
+	for _, tok := range a.New {
+		if tok.Op == align.AlignInsert {
+			changed = append(changed, "+"+tok.Token.Text)
+		}
+	}
+	if want := []string{"-y", "+Y"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("changed tokens = %q, want %q", changed, want)
+	}
+}
+
+func TestHunksHeaderPinned(t *testing.T) {
+	var old, new strings.Builder
+	old.WriteString("n\n")
+	new.WriteString("n\n")
+	for i := 0; i < 20; i++ {
+		old.WriteString("row\n")
+		new.WriteString("row\n")
+		if i == 10 {
+			new.WriteString("middle\n")
+		}
+	}
+	new.WriteString("last\n")
+	hunks, err := Hunks([]byte(old.String()), []byte(new.String()), Options{}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hunks) != 2 {
+		t.Fatalf("expected two hunks, got %v", render(hunks))
+	}
+	for i, h := range hunks {
+		if l := h.Lines[0]; l.Kind != diff.OpEqual || l.Content != "n" || l.OldLabel != "1" {
+			t.Errorf("hunk %d should start with the header, got %v", i, render(hunks[i:i+1]))
+		}
+	}
+	if h := hunks[1]; h.OldStart != 21 || h.NewStart != 22 || h.Lines[1].Content != "row" {
+		t.Errorf("second hunk starts = %d, %d; want 21, 22", h.OldStart, h.NewStart)
+	}
+}
+
+func TestHunksHeaderOnly(t *testing.T) {
+	hunks, err := Hunks([]byte("a,b\n"), []byte("a,c\n"), Options{}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"-a | b |", "+a |   | c"}
+	if got := render(hunks); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHunksTSV(t *testing.T) {
+	old := "id\tnote\n1\ta, b\n"
+	new := "id\tnote\n1\ta, c\n"
+	hunks, err := Hunks([]byte(old), []byte(new), Options{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{" id | note", "-1  | a, b", "+1  | a, c"}
+	if got := render(hunks); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHunksErrors(t *testing.T) {
+	if _, err := Hunks([]byte("a\n\"x\n"), []byte("a\n"), Options{}, 3); err == nil || !strings.HasPrefix(err.Error(), "old table") {
+		t.Errorf("expected an old table error, got %v", err)
+	}
+	if _, err := Hunks([]byte("a\n1\n"), []byte("b\n1\n"), Options{Key: "a"}, 3); err == nil || !strings.Contains(err.Error(), `"a"`) {
+		t.Errorf("expected a key column error, got %v", err)
+	}
+}
+
+func TestCellText(t *testing.T) {
+	if got := cellText("a\nb\tc"); got != "a↵b⇥c" {
+		t.Errorf("cellText = %q", got)
+	}
+}
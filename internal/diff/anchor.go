@@ -0,0 +1,118 @@
+package diff
+
+import (
+	"sort"
+	"strings"
+)
+
+// DiffAnchored is like Diff, but keeps anchor lines unchanged where it
+// can, like git diff --anchored. A line is an anchor if it starts with
+// one of anchors and appears exactly once in each input. Anchor lines
+// are matched as equal and the text between them is diffed separately,
+// so when a block moves, the anchored lines stay put and the block
+// around them shows as moved. Anchors that appear in a different order
+// in the two inputs can't all be kept; the longest run in the same
+// order is.
+func DiffAnchored(old, new string, anchors []string) []Line {
+	if old == new {
+		return nil
+	}
+	if len(anchors) == 0 {
+		return Diff(old, new)
+	}
+
+	if old == "" || new == "" || hasFinalNewline(old) == hasFinalNewline(new) {
+		old = withFinalNewline(old)
+		new = withFinalNewline(new)
+	}
+	oldLines := splitLines(old)
+	newLines := splitLines(new)
+
+	var lines []Line
+	i, j := 0, 0
+	for _, p := range anchorPairs(oldLines, newLines, anchors) {
+		lines = append(lines, diffLines(oldLines[i:p.old], newLines[j:p.new])...)
+		lines = append(lines, makeLine(OpEqual, oldLines[p.old]))
+		i, j = p.old+1, p.new+1
+	}
+	return append(lines, diffLines(oldLines[i:], newLines[j:])...)
+}
+
+// anchorPair is the position of an anchor line in each input.
+type anchorPair struct {
+	old, new int
+}
+
+// anchorPairs returns the anchor lines to match, in order on both sides.
+func anchorPairs(old, new []string, anchors []string) []anchorPair {
+	isAnchor := func(line string) bool {
+		for _, a := range anchors {
+			if strings.HasPrefix(line, a) {
+				return true
+			}
+		}
+		return false
+	}
+	// Count each anchor line's occurrences and remember where it was
+	// last seen; only lines seen once on each side are used.
+	type seen struct {
+		oldCount, newCount int
+		oldPos, newPos     int
+	}
+	counts := map[string]*seen{}
+	for i, l := range old {
+		if !isAnchor(l) {
+			continue
+		}
+		s := counts[l]
+		if s == nil {
+			s = &seen{}
+			counts[l] = s
+		}
+		s.oldCount++
+		s.oldPos = i
+	}
+	for j, l := range new {
+		if s := counts[l]; s != nil {
+			s.newCount++
+			s.newPos = j
+		}
+	}
+	var pairs []anchorPair
+	for _, s := range counts {
+		if s.oldCount == 1 && s.newCount == 1 {
+			pairs = append(pairs, anchorPair{s.oldPos, s.newPos})
+		}
+	}
+	sort.Slice(pairs, func(a, b int) bool { return pairs[a].old < pairs[b].old })
+	return increasingPairs(pairs)
+}
+
+// increasingPairs returns the longest subsequence of pairs, which are
+// sorted by old position, that is also increasing in new position.
+func increasingPairs(pairs []anchorPair) []anchorPair {
+	// tails[k] is the index of the pair ending the best subsequence of
+	// length k+1 found so far; prev links each pair to the one before it.
+	var tails []int
+	prev := make([]int, len(pairs))
+	for i, p := range pairs {
+		k := sort.Search(len(tails), func(k int) bool { return pairs[tails[k]].new >= p.new })
+		prev[i] = -1
+		if k > 0 {
+			prev[i] = tails[k-1]
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+	if len(tails) == 0 {
+		return nil
+	}
+	out := make([]anchorPair, len(tails))
+	for k, i := len(tails)-1, tails[len(tails)-1]; k >= 0; k, i = k-1, prev[i] {
+		out[k] = pairs[i]
+	}
+	return out
+}
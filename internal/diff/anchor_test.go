@@ -0,0 +1,75 @@
+package diff
+
+import "testing"
+
+func TestDiffAnchoredMovedBlock(t *testing.T) {
+	old := "a\nb\nc\nanchor\n"
+	new := "anchor\na\nb\nc\n"
+
+	// Without anchors the shorter edit moves the anchor line.
+	plain := changed(Diff(old, new))
+	if len(plain) != 2 || plain[0] != "-anchor" && plain[0] != "+anchor" {
+		t.Fatalf("unanchored diff = %q, expected the anchor line to move", plain)
+	}
+
+	got := changed(DiffAnchored(old, new, []string{"anch"}))
+	want := []string{"-a", "-b", "-c", "+a", "+b", "+c"}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %q, want %q", got, want)
+			break
+		}
+	}
+}
+
+func TestDiffAnchoredIgnoresRepeatedLines(t *testing.T) {
+	old := "x\na\nx\n"
+	new := "a\nx\nx\n"
+	got := DiffAnchored(old, new, []string{"x"})
+	want := Diff(old, new)
+	if len(got) != len(want) {
+		t.Fatalf("repeated anchors should be ignored: got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDiffAnchoredCrossingAnchors(t *testing.T) {
+	// Anchors that swap places can't both be kept.
+	old := "p\nq\nr\n"
+	new := "r\nq\np\n"
+	lines := DiffAnchored(old, new, []string{"p", "q", "r"})
+	equal := 0
+	for _, l := range lines {
+		if l.Kind == OpEqual {
+			equal++
+		}
+	}
+	if equal != 1 {
+		t.Errorf("expected exactly one kept anchor, got %d in %v", equal, lines)
+	}
+	if sideText(lines, OpInsert) != old || sideText(lines, OpDelete) != new {
+		t.Errorf("diff doesn't reproduce the inputs: %v", lines)
+	}
+}
+
+func TestIncreasingPairs(t *testing.T) {
+	pairs := []anchorPair{{0, 3}, {1, 0}, {2, 1}, {3, 4}, {4, 2}}
+	got := increasingPairs(pairs)
+	want := []anchorPair{{1, 0}, {2, 1}, {4, 2}}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+			break
+		}
+	}
+}
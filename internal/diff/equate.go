@@ -0,0 +1,74 @@
+package diff
+
+// Equate marks removed and added lines that are equivalent as
+// unchanged, such as lines whose numbers differ only by rounding.
+// Within each run of changed lines, removed and added lines are matched
+// in order by key, which must be the same for equivalent lines, and
+// matched lines for which equal reports true become a single unchanged
+// line with the added line's text. The remaining lines between them
+// stay changed, removed lines first.
+func Equate(lines []Line, key func(string) string, equal func(a, b string) bool) []Line {
+	var out []Line
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == OpEqual {
+			out = append(out, lines[i])
+			i++
+			continue
+		}
+		var dels, ins []Line
+		for ; i < len(lines) && lines[i].Kind != OpEqual; i++ {
+			if lines[i].Kind == OpDelete {
+				dels = append(dels, lines[i])
+			} else {
+				ins = append(ins, lines[i])
+			}
+		}
+		out = append(out, equateRun(dels, ins, key, equal)...)
+	}
+	return out
+}
+
+// equateRun equates the removed and added lines of one run of changes.
+func equateRun(dels, ins []Line, key func(string) string, equal func(a, b string) bool) []Line {
+	if len(dels) == 0 || len(ins) == 0 {
+		return append(dels, ins...)
+	}
+	delKeys, insKeys := make([]string, len(dels)), make([]string, len(ins))
+	for k, l := range dels {
+		delKeys[k] = key(l.Content)
+	}
+	for k, l := range ins {
+		insKeys[k] = key(l.Content)
+	}
+
+	var out, pendingIns []Line
+	flush := func() {
+		out = append(out, pendingIns...)
+		pendingIns = pendingIns[:0]
+	}
+	i, j := 0, 0
+	for _, op := range EditScript(delKeys, insKeys) {
+		switch op {
+		case OpEqual:
+			d, n := dels[i], ins[j]
+			if d.NoNewline == n.NoNewline && equal(d.Content, n.Content) {
+				flush()
+				n.Kind = OpEqual
+				out = append(out, n)
+			} else {
+				out = append(out, d)
+				pendingIns = append(pendingIns, n)
+			}
+			i++
+			j++
+		case OpDelete:
+			out = append(out, dels[i])
+			i++
+		case OpInsert:
+			pendingIns = append(pendingIns, ins[j])
+			j++
+		}
+	}
+	flush()
+	return out
+}
@@ -0,0 +1,47 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+// lower is a key making lines that differ only in case equivalent.
+func lower(s string) string { return strings.ToLower(s) }
+
+func equalFold(a, b string) bool { return strings.EqualFold(a, b) && !strings.Contains(a, "!") }
+
+func TestEquate(t *testing.T) {
+	tests := []struct {
+		name       string
+		old, new   string
+		wantKinds  string // one of " -+" per line
+		wantOutput string // lines' content, joined
+	}{
+		{"all equivalent", "a\nB\nc\n", "a\nb\nC\n", "   ", "a b C"},
+		{"real change kept", "a\nb\n", "a\nx\n", " -+", "a b x"},
+		{"mixed", "a\nb\nc\nd\n", "A\nx\nC\nd\n", " -+  ", "A b x C d"},
+		{"key match but unequal", "a!\n", "A!\n", "-+", "a! A!"},
+		{"insertion", "a\nc\n", "A\nb\nc\n", " + ", "A b c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := Equate(Diff(tt.old, tt.new), lower, equalFold)
+			var kinds strings.Builder
+			var content []string
+			for _, l := range lines {
+				kinds.WriteByte(" -+"[l.Kind])
+				content = append(content, l.Content)
+			}
+			if kinds.String() != tt.wantKinds || strings.Join(content, " ") != tt.wantOutput {
+				t.Errorf("got %q %q, want %q %q", kinds.String(), strings.Join(content, " "), tt.wantKinds, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestEquateNoNewline(t *testing.T) {
+	lines := Equate(Diff("a\n", "A"), lower, equalFold)
+	if len(lines) != 2 {
+		t.Errorf("lines differing in their final newline should stay changed, got %+v", lines)
+	}
+}
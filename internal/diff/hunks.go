@@ -1,5 +1,7 @@
 package diff
 
+import "sort"
+
 // ComputeHunks groups a flat sequence of diff Lines into Hunks, each
 // containing a contiguous region of changes with surrounding context.
 // contextLines controls how many equal lines appear around each change.
@@ -9,6 +11,38 @@ func ComputeHunks(lines []Line, contextLines int) []Hunk {
 		return nil
 	}
 
+	windows := contextWindows(lines, contextLines, nil)
+	if len(windows) == 0 {
+		return nil
+	}
+	return buildHunks(lines, windows)
+}
+
+// BlockFinder returns the block of lines [start, end) in text that
+// encloses line i, such as the function it is in, or ok=false if i is
+// not inside a block.
+type BlockFinder func(text []string, i int) (start, end int, ok bool)
+
+// ComputeFunctionHunks is like ComputeHunks, but also widens the context
+// around each change to the whole enclosing block, as found by find, like
+// git diff --function-context. Blocks are found in the old text for
+// deleted lines and in the new text for inserted lines.
+func ComputeFunctionHunks(lines []Line, contextLines int, find BlockFinder) []Hunk {
+	windows := contextWindows(lines, contextLines, blockWidener(lines, find))
+	if len(windows) == 0 {
+		return nil
+	}
+	return buildHunks(lines, windows)
+}
+
+// window is a range of diff lines [start, end) shown in one hunk.
+type window struct {
+	start, end int
+}
+
+// contextWindows gives each change line a context window, widened by
+// widen if it isn't nil, and merges overlapping or adjacent windows.
+func contextWindows(lines []Line, contextLines int, widen func(idx int, w window) window) []window {
 	// Find indices of all change lines (deletes/inserts).
 	var changeIdxs []int
 	for i, l := range lines {
@@ -21,12 +55,7 @@ func ComputeHunks(lines []Line, contextLines int) []Hunk {
 	}
 
 	// Build ranges: each change gets a context window [start, end).
-	// We then merge overlapping/adjacent windows into hunks.
-	type window struct {
-		start, end int
-	}
-
-	var windows []window
+	var changeWindows []window
 	for _, idx := range changeIdxs {
 		start := idx - contextLines
 		if start < 0 {
@@ -36,18 +65,72 @@ func ComputeHunks(lines []Line, contextLines int) []Hunk {
 		if end > len(lines) {
 			end = len(lines)
 		}
+		w := window{start, end}
+		if widen != nil {
+			w = widen(idx, w)
+		}
+		changeWindows = append(changeWindows, w)
+	}
+	// Widened windows can start before an earlier change's window.
+	sort.SliceStable(changeWindows, func(a, b int) bool {
+		return changeWindows[a].start < changeWindows[b].start
+	})
 
-		if len(windows) > 0 && start <= windows[len(windows)-1].end {
+	// Merge overlapping/adjacent windows into hunks.
+	var windows []window
+	for _, w := range changeWindows {
+		if len(windows) > 0 && w.start <= windows[len(windows)-1].end {
 			// merge with previous window
-			if end > windows[len(windows)-1].end {
-				windows[len(windows)-1].end = end
+			if w.end > windows[len(windows)-1].end {
+				windows[len(windows)-1].end = w.end
 			}
 		} else {
-			windows = append(windows, window{start, end})
+			windows = append(windows, w)
 		}
 	}
+	return windows
+}
+
+// blockWidener returns a function widening a change's window to cover
+// the block enclosing the change on its side of the diff.
+func blockWidener(lines []Line, find BlockFinder) func(idx int, w window) window {
+	// Each side's text, and the diff line index of each of its lines.
+	var oldText, newText []string
+	var oldIdx, newIdx []int
+	// The position of each diff line within its side's text.
+	sidePos := make([]int, len(lines))
+	for i, l := range lines {
+		if l.Kind != OpInsert {
+			sidePos[i] = len(oldText)
+			oldText = append(oldText, l.Content)
+			oldIdx = append(oldIdx, i)
+		}
+		if l.Kind != OpDelete {
+			if l.Kind == OpInsert {
+				sidePos[i] = len(newText)
+			}
+			newText = append(newText, l.Content)
+			newIdx = append(newIdx, i)
+		}
+	}
+
+	return func(idx int, w window) window {
+		text, diffIdx := oldText, oldIdx
+		if lines[idx].Kind == OpInsert {
+			text, diffIdx = newText, newIdx
+		}
+		start, end, ok := find(text, sidePos[idx])
+		if !ok || start >= end {
+			return w
+		}
+		w.start = min(w.start, diffIdx[start])
+		w.end = max(w.end, diffIdx[end-1]+1)
+		return w
+	}
+}
 
-	// Convert windows to hunks with correct line numbers.
+// buildHunks converts windows to hunks with correct line numbers.
+func buildHunks(lines []Line, windows []window) []Hunk {
 	hunks := make([]Hunk, len(windows))
 	// We need to track the old/new line numbers as we walk through
 	// all diff lines from the start to compute accurate starts.
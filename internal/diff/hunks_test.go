@@ -204,3 +204,59 @@ func TestComputeHunksAllDeleted(t *testing.T) {
 		t.Errorf("expected start (1,1), got (%d,%d)", hunks[0].OldStart, hunks[0].NewStart)
 	}
 }
+
+func TestComputeFunctionHunksWidensToBlock(t *testing.T) {
+	old := "a\nf {\nb\nc\nd\ne\n}\ng"
+	new := "a\nf {\nb\nc\nd\nE\n}\ng"
+	lines := Diff(old, new)
+	// Lines 1-6 form a block, in both texts.
+	find := func(text []string, i int) (int, int, bool) {
+		if i >= 1 && i < 7 {
+			return 1, 7, true
+		}
+		return 0, 0, false
+	}
+	hunks := ComputeFunctionHunks(lines, 0, find)
+	if len(hunks) != 1 {
+		t.Fatalf("expected 1 hunk, got %d", len(hunks))
+	}
+	h := hunks[0]
+	if h.OldStart != 2 || h.NewStart != 2 || h.Skipped != 1 {
+		t.Errorf("wrong start: old=%d new=%d skipped=%d", h.OldStart, h.NewStart, h.Skipped)
+	}
+	// f { b c d -e +E }
+	if len(h.Lines) != 7 {
+		t.Errorf("expected 7 lines, got %d: %v", len(h.Lines), h.Lines)
+	}
+}
+
+func TestComputeFunctionHunksMergesWidenedWindows(t *testing.T) {
+	old := "x\ny\nf {\nb\nc\n}"
+	new := "X\ny\nf {\nb\nC\n}"
+	lines := Diff(old, new)
+	// Only the second change is in a block; widening it makes it
+	// reach back over the first change's window.
+	find := func(text []string, i int) (int, int, bool) {
+		if i >= 2 {
+			return 0, 6, true
+		}
+		return 0, 0, false
+	}
+	hunks := ComputeFunctionHunks(lines, 0, find)
+	if len(hunks) != 1 {
+		t.Fatalf("expected 1 merged hunk, got %d: %v", len(hunks), hunks)
+	}
+	if hunks[0].Skipped != 0 || len(hunks[0].Lines) != len(lines) {
+		t.Errorf("expected the hunk to cover all lines, got %v", hunks[0])
+	}
+}
+
+func TestComputeFunctionHunksNoBlock(t *testing.T) {
+	lines := Diff("a\nb\nc\nd", "a\nb\nc\nD")
+	none := func([]string, int) (int, int, bool) { return 0, 0, false }
+	got := ComputeFunctionHunks(lines, 1, none)
+	want := ComputeHunks(lines, 1)
+	if len(got) != len(want) || len(got[0].Lines) != len(want[0].Lines) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
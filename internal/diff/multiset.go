@@ -0,0 +1,56 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DiffUnordered compares old and new as multisets of lines, ignoring
+// their order: a line is removed or added only if it appears more often
+// on one side than the other. The result is the removed lines followed
+// by the added lines, each sorted and listed once. Each is labeled with
+// the line number where it first appears among its extra copies, and
+// with their count if there are several, as in "12 ×3". Final newlines
+// are ignored.
+func DiffUnordered(old, new string) []Line {
+	oldLines, newLines := occurrenceLines(old), occurrenceLines(new)
+	var removed, added []Line
+	for content, at := range oldLines {
+		if extra := at[min(len(newLines[content]), len(at)):]; len(extra) > 0 {
+			removed = append(removed, Line{Kind: OpDelete, Content: content, OldLabel: countLabel(extra)})
+		}
+	}
+	for content, at := range newLines {
+		if extra := at[min(len(oldLines[content]), len(at)):]; len(extra) > 0 {
+			added = append(added, Line{Kind: OpInsert, Content: content, NewLabel: countLabel(extra)})
+		}
+	}
+	byContent := func(lines []Line) {
+		sort.Slice(lines, func(i, j int) bool { return lines[i].Content < lines[j].Content })
+	}
+	byContent(removed)
+	byContent(added)
+	return append(removed, added...)
+}
+
+// occurrenceLines maps each line of s to the 1-based numbers of the
+// lines it appears on.
+func occurrenceLines(s string) map[string][]int {
+	m := map[string][]int{}
+	for i, raw := range splitLines(s) {
+		content := strings.TrimSuffix(raw, "\n")
+		m[content] = append(m[content], i+1)
+	}
+	return m
+}
+
+// countLabel labels extra copies of a line by the first one's line
+// number and, if there are several, their count.
+func countLabel(at []int) string {
+	if len(at) == 1 {
+		return strconv.Itoa(at[0])
+	}
+	return fmt.Sprintf("%d ×%d", at[0], len(at))
+}
@@ -0,0 +1,34 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffUnordered(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []Line
+	}{
+		{"reordered", "b\na\nc\n", "c\nb\na", nil},
+		{"added and removed", "b\nz\na\n", "a\ny\nb\nx\n", []Line{
+			{Kind: OpDelete, Content: "z", OldLabel: "2"},
+			{Kind: OpInsert, Content: "x", NewLabel: "4"},
+			{Kind: OpInsert, Content: "y", NewLabel: "2"},
+		}},
+		{"duplicates", "a\nb\na\na\n", "b\na\n", []Line{
+			{Kind: OpDelete, Content: "a", OldLabel: "3 ×2"},
+		}},
+		{"empty side", "", "a\na\n", []Line{
+			{Kind: OpInsert, Content: "a", NewLabel: "1 ×2"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffUnordered(tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
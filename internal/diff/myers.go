@@ -10,18 +10,41 @@ import "strings"
 // Diff computes a line-level diff between two strings.
 // It splits both inputs on newlines and returns a sequence of Lines
 // classifying each line as equal, deleted, or inserted.
+//
+// A final newline terminates the last line rather than starting an
+// empty one. When exactly one side lacks it, that side's last line is
+// marked NoNewline and compares unequal to the same text with a
+// newline, as in git. When both sides agree, the flag is never set.
 func Diff(old, new string) []Line {
 	if old == new {
 		return nil
 	}
 
+	if old == "" || new == "" || hasFinalNewline(old) == hasFinalNewline(new) {
+		old = withFinalNewline(old)
+		new = withFinalNewline(new)
+	}
 	oldLines := splitLines(old)
 	newLines := splitLines(new)
 
 	return diffLines(oldLines, newLines)
 }
 
-// splitLines splits s into lines. An empty string returns nil (zero
+func hasFinalNewline(s string) bool {
+	return strings.HasSuffix(s, "\n")
+}
+
+// withFinalNewline terminates a non-empty s with a newline if it isn't
+// already.
+func withFinalNewline(s string) string {
+	if s == "" || hasFinalNewline(s) {
+		return s
+	}
+	return s + "\n"
+}
+
+// splitLines splits s into lines, each keeping its terminating newline
+// (the last line may have none). An empty string returns nil (zero
 // lines), not a single empty-string element. This is correct because
 // the caller (Diff) short-circuits the equal case before we get here,
 // so "" only appears when the other side is non-empty, and nil lets
@@ -30,28 +53,74 @@ func splitLines(s string) []string {
 	if s == "" {
 		return nil
 	}
-	return strings.Split(s, "\n")
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
 }
 
-// diffLines runs the Myers algorithm on two slices of strings and
-// returns the result as a flat sequence of Lines.
+// makeLine builds a Line from a line as returned by splitLines,
+// stripping its newline.
+func makeLine(kind OpKind, raw string) Line {
+	content, ok := strings.CutSuffix(raw, "\n")
+	return Line{Kind: kind, Content: content, NoNewline: !ok}
+}
+
+// diffLines runs the Myers algorithm on two slices of lines (as
+// returned by splitLines) and returns the result as a flat sequence of
+// Lines.
 func diffLines(old, new []string) []Line {
+	ops := EditScript(old, new)
+	lines := make([]Line, len(ops))
+	i, j := 0, 0
+	for k, op := range ops {
+		switch op {
+		case OpEqual:
+			lines[k] = makeLine(OpEqual, old[i])
+			i++
+			j++
+		case OpDelete:
+			lines[k] = makeLine(OpDelete, old[i])
+			i++
+		case OpInsert:
+			lines[k] = makeLine(OpInsert, new[j])
+			j++
+		}
+	}
+	return lines
+}
+
+// EditScript runs the Myers algorithm on two sequences and returns the
+// shortest edit script turning old into new: one op per element, where
+// OpEqual consumes an element from both sides, OpDelete one from old and
+// OpInsert one from new.
+func EditScript[T comparable](old, new []T) []OpKind {
+	ops, _ := BoundedEditScript(old, new, len(old)+len(new))
+	return ops
+}
+
+// BoundedEditScript is like EditScript but gives up, returning false,
+// if the script would need more than maxEdits deletions and insertions.
+// The algorithm's memory grows with the number of edits times the input
+// length, so callers diffing large inputs use this to fall back to a
+// coarser diff.
+func BoundedEditScript[T comparable](old, new []T, maxEdits int) ([]OpKind, bool) {
 	n := len(old)
 	m := len(new)
 
-	if n == 0 {
-		lines := make([]Line, m)
-		for i, l := range new {
-			lines[i] = Line{Kind: OpInsert, Content: l}
+	if n == 0 || m == 0 {
+		if n+m > maxEdits {
+			return nil, false
 		}
-		return lines
-	}
-	if m == 0 {
-		lines := make([]Line, n)
-		for i, l := range old {
-			lines[i] = Line{Kind: OpDelete, Content: l}
+		ops := make([]OpKind, 0, n+m)
+		for range old {
+			ops = append(ops, OpDelete)
+		}
+		for range new {
+			ops = append(ops, OpInsert)
 		}
-		return lines
+		return ops, true
 	}
 
 	// Myers shortest-edit-script algorithm.
@@ -61,10 +130,10 @@ func diffLines(old, new []string) []Line {
 	// diagonal k. We use offset max so that v[k+max] maps to diagonal k.
 	v := make([]int, 2*max+1)
 	// trace stores a copy of v at each step d, used for backtracking.
-	trace := make([][]int, 0, max)
+	trace := make([][]int, 0, min(max, maxEdits)+1)
 
 	var found bool
-	for d := 0; d <= max; d++ {
+	for d := 0; d <= min(max, maxEdits); d++ {
 		snapshot := make([]int, len(v))
 		copy(snapshot, v)
 		trace = append(trace, snapshot)
@@ -95,18 +164,19 @@ func diffLines(old, new []string) []Line {
 			break
 		}
 	}
+	if !found {
+		return nil, false
+	}
 
-	return backtrack(trace, old, new, max)
+	return backtrack(trace, n, m, max), true
 }
 
 // backtrack reconstructs the edit sequence from the trace.
-func backtrack(trace [][]int, old, new []string, max int) []Line {
-	n := len(old)
-	m := len(new)
+func backtrack(trace [][]int, n, m, max int) []OpKind {
 	x, y := n, m
 
 	// edits collected in reverse
-	var edits []Line
+	var edits []OpKind
 
 	for d := len(trace) - 1; d >= 0; d-- {
 		v := trace[d]
@@ -126,18 +196,18 @@ func backtrack(trace [][]int, old, new []string, max int) []Line {
 		for x > prevX && y > prevY {
 			x--
 			y--
-			edits = append(edits, Line{Kind: OpEqual, Content: old[x]})
+			edits = append(edits, OpEqual)
 		}
 
 		if d > 0 {
 			if x == prevX {
 				// vertical move: insertion
 				y--
-				edits = append(edits, Line{Kind: OpInsert, Content: new[y]})
+				edits = append(edits, OpInsert)
 			} else {
 				// horizontal move: deletion
 				x--
-				edits = append(edits, Line{Kind: OpDelete, Content: old[x]})
+				edits = append(edits, OpDelete)
 			}
 		}
 	}
@@ -85,14 +85,37 @@ func TestDiffDeletionInMiddle(t *testing.T) {
 }
 
 func TestDiffTrailingNewlineMismatch(t *testing.T) {
-	// "hello\n" splits to ["hello", ""], "hello" splits to ["hello"]
-	old := "hello\n"
-	new := "hello"
-	result := Diff(old, new)
-	// The trailing newline creates an extra empty-string line
+	// The last line differs only in its newline, like in git.
+	result := Diff("hello\nworld\n", "hello\nworld")
 	expected := []Line{
 		{Kind: OpEqual, Content: "hello"},
-		{Kind: OpDelete, Content: ""},
+		{Kind: OpDelete, Content: "world"},
+		{Kind: OpInsert, Content: "world", NoNewline: true},
+	}
+	assertLines(t, expected, result)
+}
+
+func TestDiffTrailingNewlineAgreement(t *testing.T) {
+	// Neither side ends in a newline: no phantom lines, no flags.
+	result := Diff("a\nb", "a\nc")
+	expected := []Line{
+		{Kind: OpEqual, Content: "a"},
+		{Kind: OpDelete, Content: "b"},
+		{Kind: OpInsert, Content: "c"},
+	}
+	assertLines(t, expected, result)
+
+	// Both do: the final newline doesn't produce an empty line.
+	result = Diff("a\nb\n", "a\nc\n")
+	assertLines(t, expected, result)
+}
+
+func TestDiffTrailingBlankLine(t *testing.T) {
+	// An extra newline is a real empty line.
+	result := Diff("a\n", "a\n\n")
+	expected := []Line{
+		{Kind: OpEqual, Content: "a"},
+		{Kind: OpInsert, Content: ""},
 	}
 	assertLines(t, expected, result)
 }
@@ -134,6 +157,34 @@ func TestDiffAllRemoved(t *testing.T) {
 	assertLines(t, expected, result)
 }
 
+func TestEditScriptBytes(t *testing.T) {
+	ops := EditScript([]byte("abcd"), []byte("abXcd"))
+	expected := []OpKind{OpEqual, OpEqual, OpInsert, OpEqual, OpEqual}
+	if len(ops) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, ops)
+	}
+	for i := range expected {
+		if ops[i] != expected[i] {
+			t.Errorf("op %d: expected %v, got %v", i, expected[i], ops[i])
+		}
+	}
+}
+
+func TestBoundedEditScriptGivesUp(t *testing.T) {
+	old := []byte("abcdef")
+	new := []byte("uvwxyz")
+	if _, ok := BoundedEditScript(old, new, 11); ok {
+		t.Error("expected to give up with 12 edits needed and a budget of 11")
+	}
+	ops, ok := BoundedEditScript(old, new, 12)
+	if !ok || len(ops) != 12 {
+		t.Errorf("expected 12 ops within budget, got %v (ok=%v)", ops, ok)
+	}
+	if _, ok := BoundedEditScript(nil, new, 5); ok {
+		t.Error("expected pure insert of 6 to exceed a budget of 5")
+	}
+}
+
 func assertLines(t *testing.T, expected, got []Line) {
 	t.Helper()
 	if len(expected) != len(got) {
@@ -0,0 +1,255 @@
+package diff
+
+// The indent heuristic below follows git's (xdiff/xdiffi.c), which was
+// tuned against a corpus of human-rated diffs.
+
+// Split scoring weights, from git.
+const (
+	startOfFilePenalty              = 1
+	endOfFilePenalty                = 21
+	totalBlankWeight                = -30
+	postBlankWeight                 = 6
+	relativeIndentPenalty           = -4
+	relativeIndentWithBlankPenalty  = 10
+	relativeOutdentPenalty          = 24
+	relativeOutdentWithBlankPenalty = 17
+	relativeDedentPenalty           = 23
+	relativeDedentWithBlankPenalty  = 17
+	indentWeight                    = 60
+
+	maxIndent  = 200 // indentation beyond this counts as this
+	maxBlanks  = 20  // blank lines beyond this aren't counted
+	maxSliding = 100 // runs slide at most this far
+)
+
+// IndentHeuristic slides ambiguous runs of inserted or deleted lines to
+// where they read best. A run can slide when the line just outside it
+// equals the line at its other end, which happens often in code: adding
+// a function after another can be shown as "}" plus the new body plus
+// "func old() {" instead of the whole new function. Among the positions
+// a run can take, the one chosen has the best git indent heuristic
+// score, which favors runs bounded by blank lines and starting at low
+// indentation. Only runs between unchanged lines are moved; each side's
+// text is unchanged.
+func IndentHeuristic(lines []Line) []Line {
+	out := make([]Line, len(lines))
+	copy(out, lines)
+
+	// Each side's text in order. Sliding never changes these, only
+	// which of their lines are marked as changed.
+	var oldText, newText []string
+	oldPos := make([]int, len(out)) // position in oldText of each non-insert line
+	newPos := make([]int, len(out)) // position in newText of each non-delete line
+	for i, l := range out {
+		if l.Kind != OpInsert {
+			oldPos[i] = len(oldText)
+			oldText = append(oldText, l.Content)
+		}
+		if l.Kind != OpDelete {
+			newPos[i] = len(newText)
+			newText = append(newText, l.Content)
+		}
+	}
+
+	for i := 0; i < len(out); {
+		kind := out[i].Kind
+		if kind == OpEqual {
+			i++
+			continue
+		}
+		end := i
+		for end < len(out) && out[end].Kind == kind {
+			end++
+		}
+		text, pos := newText, newPos
+		if kind == OpDelete {
+			text, pos = oldText, oldPos
+		}
+		i = slideRun(out, i, end, text, pos)
+	}
+	return out
+}
+
+// slideRun slides the run of changed lines at out[start:end] to its best
+// position and returns the index just past the region it could occupy.
+// text is the run's side of the diff and pos maps lines of out to it.
+func slideRun(out []Line, start, end int, text []string, pos []int) int {
+	n := end - start
+	canSlide := func(i int) bool {
+		return out[i].Kind == OpEqual && !out[i].NoNewline
+	}
+	for i := start; i < end; i++ {
+		if out[i].NoNewline {
+			return end
+		}
+	}
+
+	// Find how far the run can slide up and down: past an unchanged
+	// line that equals the run's line at the other end.
+	lo, hi := start, end
+	for lo > 0 && start-(lo-1) <= maxSliding && canSlide(lo-1) && out[lo-1].Content == text[pos[start]+n-1-(start-lo)] {
+		lo--
+	}
+	for hi < len(out) && hi-end < maxSliding && canSlide(hi) && out[hi].Content == text[pos[start]+(hi-end)] {
+		hi++
+	}
+	if lo == start && hi == end {
+		return end
+	}
+
+	// Score each position by its top and bottom splits, keeping the
+	// lowest; ties go to the lower position, as in git.
+	base := pos[start] - (start - lo) // text index of out[lo]
+	best := -1
+	var bestScore splitScore
+	for s := lo; s+n <= hi; s++ {
+		top := base + (s - lo)
+		var score splitScore
+		score.add(measureSplit(text, top))
+		score.add(measureSplit(text, top+n))
+		if best < 0 || score.cmp(bestScore) <= 0 {
+			best, bestScore = s, score
+		}
+	}
+
+	kind := out[start].Kind
+	for k := lo; k < hi; k++ {
+		out[k].Content = text[base+(k-lo)]
+		out[k].Kind = OpEqual
+		if k >= best && k < best+n {
+			out[k].Kind = kind
+		}
+	}
+	return hi
+}
+
+// splitMeasurement describes the lines around a split point in a text.
+type splitMeasurement struct {
+	endOfFile  bool
+	indent     int // indentation of the line after the split, -1 if blank
+	preBlank   int // blank lines directly before the split
+	preIndent  int // indentation of the non-blank line before those, -1 if none
+	postBlank  int // blank lines after the line after the split
+	postIndent int // indentation of the non-blank line after those, -1 if none
+}
+
+// measureSplit measures the split before text[split].
+func measureSplit(text []string, split int) splitMeasurement {
+	var m splitMeasurement
+	if split >= len(text) {
+		m.endOfFile = true
+		m.indent = -1
+	} else {
+		m.indent = lineIndent(text[split])
+	}
+
+	m.preIndent = -1
+	for i := split - 1; i >= 0; i-- {
+		if ind := lineIndent(text[i]); ind != -1 {
+			m.preIndent = ind
+			break
+		}
+		m.preBlank++
+		if m.preBlank == maxBlanks {
+			m.preIndent = 0
+			break
+		}
+	}
+
+	m.postIndent = -1
+	for i := split + 1; i < len(text); i++ {
+		if ind := lineIndent(text[i]); ind != -1 {
+			m.postIndent = ind
+			break
+		}
+		m.postBlank++
+		if m.postBlank == maxBlanks {
+			m.postIndent = 0
+			break
+		}
+	}
+	return m
+}
+
+// lineIndent returns the indentation of line, with tabs advancing to the
+// next multiple of 8, or -1 if the line is blank.
+func lineIndent(line string) int {
+	w := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ' ':
+			w++
+		case '\t':
+			w += 8 - w%8
+		case '\r', '\f', '\v':
+			// whitespace that doesn't indent
+		default:
+			return w
+		}
+		if w >= maxIndent {
+			return maxIndent
+		}
+	}
+	return -1
+}
+
+// splitScore accumulates how bad a run position's splits are.
+type splitScore struct {
+	effectiveIndent int
+	penalty         int
+}
+
+// add scores one split.
+func (s *splitScore) add(m splitMeasurement) {
+	if m.preIndent == -1 && m.preBlank == 0 {
+		s.penalty += startOfFilePenalty
+	}
+	if m.endOfFile {
+		s.penalty += endOfFilePenalty
+	}
+
+	postBlank := 0
+	if m.indent == -1 {
+		postBlank = 1 + m.postBlank
+	}
+	totalBlank := m.preBlank + postBlank
+	s.penalty += totalBlankWeight * totalBlank
+	s.penalty += postBlankWeight * postBlank
+
+	indent := m.indent
+	if indent == -1 {
+		indent = m.postIndent
+	}
+	anyBlanks := totalBlank != 0
+	s.effectiveIndent += indent
+
+	switch {
+	case indent == -1, m.preIndent == -1, indent == m.preIndent:
+		// no adjustment
+	case indent > m.preIndent:
+		s.penalty += pick(anyBlanks, relativeIndentWithBlankPenalty, relativeIndentPenalty)
+	case m.postIndent != -1 && m.postIndent > indent:
+		s.penalty += pick(anyBlanks, relativeOutdentWithBlankPenalty, relativeOutdentPenalty)
+	default:
+		s.penalty += pick(anyBlanks, relativeDedentWithBlankPenalty, relativeDedentPenalty)
+	}
+}
+
+// cmp compares two scores: negative if s is better than o.
+func (s splitScore) cmp(o splitScore) int {
+	cmpIndents := 0
+	switch {
+	case s.effectiveIndent > o.effectiveIndent:
+		cmpIndents = 1
+	case s.effectiveIndent < o.effectiveIndent:
+		cmpIndents = -1
+	}
+	return indentWeight*cmpIndents + (s.penalty - o.penalty)
+}
+
+func pick(cond bool, a, b int) int {
+	if cond {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,122 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+// changed returns the kind and content of each changed line.
+func changed(lines []Line) []string {
+	var out []string
+	for _, l := range lines {
+		switch l.Kind {
+		case OpDelete:
+			out = append(out, "-"+l.Content)
+		case OpInsert:
+			out = append(out, "+"+l.Content)
+		}
+	}
+	return out
+}
+
+func sideText(lines []Line, skip OpKind) string {
+	var b strings.Builder
+	for _, l := range lines {
+		if l.Kind != skip {
+			b.WriteString(l.Content + "\n")
+		}
+	}
+	return b.String()
+}
+
+func TestIndentHeuristicNewFunction(t *testing.T) {
+	// Without sliding, the insert reads "}", "", "func b() {", "	y()"
+	// rather than the whole new function.
+	lines := []Line{
+		{Kind: OpEqual, Content: "func a() {"},
+		{Kind: OpEqual, Content: "	x()"},
+		{Kind: OpEqual, Content: "}"},
+		{Kind: OpInsert, Content: ""},
+		{Kind: OpInsert, Content: "func b() {"},
+		{Kind: OpInsert, Content: "	y()"},
+		{Kind: OpInsert, Content: "}"},
+		{Kind: OpEqual, Content: ""},
+		{Kind: OpEqual, Content: "func c() {"},
+		{Kind: OpEqual, Content: "}"},
+	}
+	got := IndentHeuristic(lines)
+	want := []string{"+func b() {", "+	y()", "+}", "+"}
+	if strings.Join(changed(got), "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", changed(got), want)
+	}
+	if sideText(got, OpDelete) != sideText(lines, OpDelete) {
+		t.Error("new text changed")
+	}
+	if sideText(got, OpInsert) != sideText(lines, OpInsert) {
+		t.Error("old text changed")
+	}
+}
+
+func TestIndentHeuristicDelete(t *testing.T) {
+	lines := []Line{
+		{Kind: OpEqual, Content: "a"},
+		{Kind: OpEqual, Content: ""},
+		{Kind: OpDelete, Content: "b"},
+		{Kind: OpDelete, Content: ""},
+		{Kind: OpEqual, Content: "c"},
+	}
+	got := IndentHeuristic(lines)
+	want := []string{"-b", "-"}
+	if strings.Join(changed(got), "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", changed(got), want)
+	}
+	if got[1].Kind != OpEqual || got[1].Content != "" {
+		t.Errorf("blank line before the run should stay unchanged, got %+v", got[1])
+	}
+}
+
+func TestIndentHeuristicUnambiguous(t *testing.T) {
+	lines := []Line{
+		{Kind: OpEqual, Content: "a"},
+		{Kind: OpDelete, Content: "b"},
+		{Kind: OpInsert, Content: "B"},
+		{Kind: OpEqual, Content: "c"},
+	}
+	got := IndentHeuristic(lines)
+	for i := range lines {
+		if got[i] != lines[i] {
+			t.Errorf("line %d: got %+v, want %+v", i, got[i], lines[i])
+		}
+	}
+}
+
+func TestIndentHeuristicKeepsInput(t *testing.T) {
+	lines := []Line{
+		{Kind: OpEqual, Content: "x"},
+		{Kind: OpInsert, Content: "y"},
+		{Kind: OpInsert, Content: "x"},
+	}
+	IndentHeuristic(lines)
+	if lines[0].Kind != OpEqual || lines[1].Kind != OpInsert {
+		t.Error("input was modified")
+	}
+}
+
+func TestLineIndent(t *testing.T) {
+	tests := []struct {
+		line string
+		want int
+	}{
+		{"x", 0},
+		{"  x", 2},
+		{"\tx", 8},
+		{"  \tx", 8},
+		{"", -1},
+		{" \t ", -1},
+	}
+	for _, tt := range tests {
+		if got := lineIndent(tt.line); got != tt.want {
+			t.Errorf("lineIndent(%q) = %d, want %d", tt.line, got, tt.want)
+		}
+	}
+}
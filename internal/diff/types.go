@@ -11,8 +11,21 @@ const (
 
 // Line represents a single line in a diff result.
 type Line struct {
-	Kind    OpKind
-	Content string // the line text (without trailing newline)
+	Kind      OpKind
+	Content   string // the line text (without trailing newline)
+	NoNewline bool   // last line of a text that doesn't end in a newline
+
+	// OldLabel and NewLabel, when set, are shown in the gutter in place
+	// of the old and new line numbers (e.g. byte offsets).
+	OldLabel string
+	NewLabel string
+}
+
+// Pair is a removed and an added line known to correspond, such as the
+// old and new value of the same field in a structural diff, as indices
+// into a flat sequence of Lines.
+type Pair struct {
+	Old, New int
 }
 
 // Hunk is a contiguous group of diff lines with surrounding context.
@@ -21,4 +34,5 @@ type Hunk struct {
 	NewStart int    // 1-based line number in the new text
 	Lines    []Line // the lines in this hunk (context + changes)
 	Skipped  int    // number of lines skipped before this hunk
+	Context  string // enclosing function or section, for hunk headers
 }
@@ -0,0 +1,290 @@
+// Package hexdump lays out a byte-level diff as hexdump rows: an offset,
+// sixteen bytes in hex, and the same bytes as ASCII.
+package hexdump
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/amterp/go-delta/internal/align"
+	"github.com/amterp/go-delta/internal/diff"
+	"github.com/amterp/go-delta/internal/structdiff"
+)
+
+// RowBytes is the maximum number of bytes shown on a row.
+const RowBytes = 16
+
+// hexWidth is the width of a full row's hex column: two digits per
+// byte, separated by spaces, with an extra space after the eighth byte.
+const hexWidth = RowBytes * 3
+
+// traceBudget bounds the memory used by the byte-level Myers diff, in
+// trace entries (see diff.BoundedEditScript).
+const traceBudget = 8 << 20
+
+// cell is one byte on a row, with how it changed.
+type cell struct {
+	b  byte
+	op align.AlignOp
+}
+
+// row is one hexdump row on each side. Either side may be empty when
+// the row only holds insertions or deletions.
+type row struct {
+	oldOffset, newOffset int
+	old, new             []cell
+}
+
+func (r row) changed() bool {
+	for _, c := range r.old {
+		if c.op != align.AlignMatch {
+			return true
+		}
+	}
+	return len(r.old) != len(r.new)
+}
+
+// Hunks diffs old and new byte by byte and returns the result as
+// annotated hunks of hexdump rows, ready for rendering. Rows are cut so
+// that an insertion or deletion doesn't shift the rows after it out of
+// alignment: once the change ends, both sides continue with identical
+// rows, labeled with their own offsets. Changed bytes are emphasized in
+// both the hex and ASCII columns.
+func Hunks(old, new []byte, contextRows int) []align.AnnotatedHunk {
+	rows := layoutRows(old, new, byteOps(old, new))
+
+	var b structdiff.Builder
+	for _, r := range rows {
+		oldLabel := fmt.Sprintf("%08x", r.oldOffset)
+		newLabel := fmt.Sprintf("%08x", r.newOffset)
+		oldTokens := rowTokens(r.old)
+		newTokens := rowTokens(r.new)
+
+		switch {
+		case !r.changed():
+			b.Lines = append(b.Lines, diff.Line{
+				Kind:     diff.OpEqual,
+				Content:  structdiff.Text(oldTokens),
+				OldLabel: oldLabel,
+				NewLabel: newLabel,
+			})
+		case len(r.old) > 0 && len(r.new) > 0:
+			b.AddPair(oldTokens, newTokens, oldLabel, newLabel)
+		case len(r.old) > 0:
+			b.Emit(diff.OpDelete, structdiff.Printed{Text: structdiff.Text(oldTokens), Path: oldLabel})
+		default:
+			b.Emit(diff.OpInsert, structdiff.Printed{Text: structdiff.Text(newTokens), Path: newLabel})
+		}
+	}
+	return b.Annotate(diff.ComputeHunks(b.Lines, contextRows))
+}
+
+// byteOps returns a byte-level edit script from old to new. Inputs too
+// large or too different for an exact diff within traceBudget fall back
+// to diffing fixed rows, as xxd output would be, and then diffing bytes
+// within each pair of changed rows.
+func byteOps(old, new []byte) []diff.OpKind {
+	if d, ok := maxEdits(len(old), len(new)); ok {
+		if ops, ok := diff.BoundedEditScript(old, new, d); ok {
+			return ops
+		}
+	}
+
+	oldRows, newRows := fixedRows(old), fixedRows(new)
+	var rowOps []diff.OpKind
+	d, ok := maxEdits(len(oldRows), len(newRows))
+	if ok {
+		rowOps, ok = diff.BoundedEditScript(oldRows, newRows, d)
+	}
+	if !ok {
+		// Too different even by rows: compare row by row.
+		rowOps = replaceOps(len(oldRows), len(newRows))
+	}
+
+	var ops []diff.OpKind
+	i, j := 0, 0
+	for k := 0; k < len(rowOps); {
+		if rowOps[k] == diff.OpEqual {
+			for range oldRows[i] {
+				ops = append(ops, diff.OpEqual)
+			}
+			i++
+			j++
+			k++
+			continue
+		}
+		// Pair up the deleted and inserted rows of this change in order.
+		var dels, ins []string
+		for ; k < len(rowOps) && rowOps[k] != diff.OpEqual; k++ {
+			if rowOps[k] == diff.OpDelete {
+				dels = append(dels, oldRows[i])
+				i++
+			} else {
+				ins = append(ins, newRows[j])
+				j++
+			}
+		}
+		for n := 0; n < max(len(dels), len(ins)); n++ {
+			var o, w string
+			if n < len(dels) {
+				o = dels[n]
+			}
+			if n < len(ins) {
+				w = ins[n]
+			}
+			// Rows changed in more than half their bytes are replaced
+			// whole; diffing them would show little and cost a trace.
+			var cellOps []diff.OpKind
+			ok := minEdits(o, w) <= RowBytes/2
+			if ok {
+				cellOps, ok = diff.BoundedEditScript([]byte(o), []byte(w), RowBytes/2)
+			}
+			if !ok {
+				cellOps = replaceOps(len(o), len(w))
+			}
+			ops = append(ops, cellOps...)
+		}
+	}
+	return ops
+}
+
+// maxEdits returns the edit budget for diffing sequences of n and m
+// elements within traceBudget, or false if the sequences are too long
+// for even a single edit to fit. The Myers trace keeps 2(n+m)+1 ints
+// for each edit, plus one set for the start.
+func maxEdits(n, m int) (int, bool) {
+	d := traceBudget/(2*(n+m)+1) - 1
+	return d, d >= 1
+}
+
+// minEdits returns a lower bound on the edits between a and b: the
+// bytes they don't share, in any order.
+func minEdits(a, b string) int {
+	var count [256]int
+	for i := 0; i < len(a); i++ {
+		count[a[i]]++
+	}
+	shared := 0
+	for i := 0; i < len(b); i++ {
+		if count[b[i]] > 0 {
+			count[b[i]]--
+			shared++
+		}
+	}
+	return len(a) + len(b) - 2*shared
+}
+
+// replaceOps returns an edit script deleting n elements and inserting m.
+func replaceOps(n, m int) []diff.OpKind {
+	ops := make([]diff.OpKind, 0, n+m)
+	for range n {
+		ops = append(ops, diff.OpDelete)
+	}
+	for range m {
+		ops = append(ops, diff.OpInsert)
+	}
+	return ops
+}
+
+// fixedRows splits data into RowBytes-sized strings.
+func fixedRows(data []byte) []string {
+	var rows []string
+	for len(data) > 0 {
+		n := min(len(data), RowBytes)
+		rows = append(rows, string(data[:n]))
+		data = data[n:]
+	}
+	return rows
+}
+
+// layoutRows distributes an edit script over rows. A row ends when
+// either side holds RowBytes bytes, or when the old side reaches a
+// multiple of RowBytes, so unchanged stretches line up with the old
+// input's natural rows.
+func layoutRows(old, new []byte, ops []diff.OpKind) []row {
+	var rows []row
+	cur := row{}
+	i, j := 0, 0
+
+	flush := func() {
+		if len(cur.old) > 0 || len(cur.new) > 0 {
+			rows = append(rows, cur)
+		}
+		cur = row{oldOffset: i, newOffset: j}
+	}
+
+	for _, op := range ops {
+		usesOld := op != diff.OpInsert
+		usesNew := op != diff.OpDelete
+		if (usesOld && len(cur.old) == RowBytes) || (usesNew && len(cur.new) == RowBytes) {
+			flush()
+		}
+		switch op {
+		case diff.OpEqual:
+			cur.old = append(cur.old, cell{old[i], align.AlignMatch})
+			cur.new = append(cur.new, cell{new[j], align.AlignMatch})
+			i++
+			j++
+		case diff.OpDelete:
+			cur.old = append(cur.old, cell{old[i], align.AlignDelete})
+			i++
+		case diff.OpInsert:
+			cur.new = append(cur.new, cell{new[j], align.AlignInsert})
+			j++
+		}
+		if usesOld && i%RowBytes == 0 {
+			flush()
+		}
+	}
+	flush()
+	return rows
+}
+
+// rowTokens formats one side of a row as aligned tokens: the hex
+// column padded to full width, then the ASCII column between bars.
+// Each byte's hex digits and ASCII character carry its op. A space
+// between two bytes changed the same way takes their op too, so runs
+// of changed bytes are emphasized as one block.
+func rowTokens(cells []cell) []align.AlignedToken {
+	if len(cells) == 0 {
+		return nil
+	}
+
+	var tokens []align.AlignedToken
+	add := func(text string, op align.AlignOp) {
+		tokens = structdiff.AppendToken(tokens, text, op)
+	}
+
+	width := 0
+	for k, c := range cells {
+		if k > 0 {
+			sep := " "
+			if k == RowBytes/2 {
+				sep = "  "
+			}
+			op := align.AlignMatch
+			if prev := cells[k-1].op; prev != align.AlignMatch && prev == c.op {
+				op = c.op
+			}
+			add(sep, op)
+			width += len(sep)
+		}
+		add(fmt.Sprintf("%02x", c.b), c.op)
+		width += 2
+	}
+	add(strings.Repeat(" ", hexWidth-width)+"  |", align.AlignMatch)
+	for _, c := range cells {
+		add(string(printable(c.b)), c.op)
+	}
+	add("|", align.AlignMatch)
+	return tokens
+}
+
+// printable returns b as an ASCII character, or '.' if it isn't a
+// printable one.
+func printable(b byte) byte {
+	if b < 0x20 || b > 0x7e {
+		return '.'
+	}
+	return b
+}
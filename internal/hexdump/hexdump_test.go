@@ -0,0 +1,164 @@
+package hexdump
+
+import (
+	"bytes"
+	"math/rand"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/amterp/go-delta/internal/align"
+	"github.com/amterp/go-delta/internal/diff"
+	"github.com/amterp/go-delta/internal/structdiff"
+)
+
+func TestRowTokensFullRow(t *testing.T) {
+	cells := make([]cell, RowBytes)
+	for i := range cells {
+		cells[i] = cell{b: byte('a' + i), op: align.AlignMatch}
+	}
+	got := structdiff.Text(rowTokens(cells))
+	want := "61 62 63 64 65 66 67 68  69 6a 6b 6c 6d 6e 6f 70  |abcdefghijklmnop|"
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestRowTokensPadsShortRow(t *testing.T) {
+	full := structdiff.Text(rowTokens(make([]cell, RowBytes)))
+	short := structdiff.Text(rowTokens([]cell{{b: 0}, {b: 0x41}}))
+	if strings.Index(short, "|") != strings.Index(full, "|") {
+		t.Errorf("ASCII column not aligned:\n%q\n%q", short, full)
+	}
+	if !strings.HasSuffix(short, "|.A|") {
+		t.Errorf("got %q", short)
+	}
+}
+
+func TestRowTokensGroupsChangedRun(t *testing.T) {
+	cells := []cell{
+		{b: 1, op: align.AlignMatch},
+		{b: 2, op: align.AlignDelete},
+		{b: 3, op: align.AlignDelete},
+		{b: 4, op: align.AlignMatch},
+	}
+	var emph []string
+	for _, tok := range rowTokens(cells) {
+		if tok.Op == align.AlignDelete {
+			emph = append(emph, tok.Token.Text)
+		}
+	}
+	// The space between two deleted bytes is emphasized with them.
+	if got := strings.Join(emph, ""); got != "02 03.." {
+		t.Errorf("emphasized text = %q", got)
+	}
+}
+
+func TestRowTokensOffsets(t *testing.T) {
+	tokens := rowTokens([]cell{{b: 'x'}, {b: 'y'}})
+	text := structdiff.Text(tokens)
+	for _, tok := range tokens {
+		if text[tok.Token.Start:tok.Token.End] != tok.Token.Text {
+			t.Errorf("token %q has wrong offsets [%d,%d)", tok.Token.Text, tok.Token.Start, tok.Token.End)
+		}
+	}
+}
+
+func TestLayoutRowsRealignsAfterInsertion(t *testing.T) {
+	old := bytes.Repeat([]byte("0123456789abcdef"), 4)
+	new := append([]byte("XYZ"), old...)
+	rows := layoutRows(old, new, diff.EditScript(old, new))
+
+	var unchanged int
+	for _, r := range rows {
+		if !r.changed() {
+			unchanged++
+			if r.newOffset != r.oldOffset+3 {
+				t.Errorf("unchanged row at old %#x has new offset %#x", r.oldOffset, r.newOffset)
+			}
+		}
+	}
+	if unchanged != 4 {
+		t.Errorf("expected all 4 data rows unchanged, got %d of %d rows", unchanged, len(rows))
+	}
+}
+
+func TestLayoutRowsLimits(t *testing.T) {
+	old := bytes.Repeat([]byte{1}, 40)
+	new := bytes.Repeat([]byte{2}, 50)
+	for _, r := range layoutRows(old, new, diff.EditScript(old, new)) {
+		if len(r.old) > RowBytes || len(r.new) > RowBytes {
+			t.Errorf("row at %#x/%#x too long: %d/%d bytes", r.oldOffset, r.newOffset, len(r.old), len(r.new))
+		}
+	}
+}
+
+// checkOps verifies that ops is a valid edit script from old to new.
+func checkOps(t *testing.T, old, new []byte, ops []diff.OpKind) {
+	t.Helper()
+	i, j := 0, 0
+	for _, op := range ops {
+		switch op {
+		case diff.OpEqual:
+			if old[i] != new[j] {
+				t.Fatalf("equal op on differing bytes at %d/%d", i, j)
+			}
+			i++
+			j++
+		case diff.OpDelete:
+			i++
+		case diff.OpInsert:
+			j++
+		}
+	}
+	if i != len(old) || j != len(new) {
+		t.Fatalf("script consumed %d/%d bytes, want %d/%d", i, j, len(old), len(new))
+	}
+}
+
+func TestByteOpsLargeInputsFallBack(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	old := make([]byte, 200_000)
+	new := make([]byte, 210_000)
+	rng.Read(old)
+	rng.Read(new)
+	checkOps(t, old, new, byteOps(old, new))
+}
+
+func TestByteOpsBoundedAllocation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("diffs megabytes of random bytes")
+	}
+	rng := rand.New(rand.NewSource(2))
+	old := make([]byte, 1<<20)
+	new := make([]byte, 1<<20)
+	rng.Read(old)
+	rng.Read(new)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	ops := byteOps(old, new)
+	runtime.ReadMemStats(&after)
+	checkOps(t, old, new, ops)
+	// The traces are capped at traceBudget ints; everything else is
+	// linear in the input.
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 256<<20 {
+		t.Errorf("allocated %d MiB diffing two 1 MiB inputs", alloc>>20)
+	}
+}
+
+func TestHunksPairsChangedRows(t *testing.T) {
+	old := []byte("hello, world! this is row two...")
+	new := []byte("hello, World! this is row two...")
+	hunks := Hunks(old, new, 0)
+	if len(hunks) != 1 || len(hunks[0].Pairs) != 1 {
+		t.Fatalf("expected one hunk with one pair, got %+v", hunks)
+	}
+	h := hunks[0]
+	if l := h.Lines[0]; l.Kind != diff.OpDelete || l.OldLabel != "00000000" {
+		t.Errorf("unexpected first line %+v", l)
+	}
+	if l := h.Lines[1]; l.Kind != diff.OpInsert || l.NewLabel != "00000000" {
+		t.Errorf("unexpected second line %+v", l)
+	}
+}
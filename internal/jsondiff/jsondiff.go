@@ -0,0 +1,425 @@
+// Package jsondiff compares JSON documents structurally and lays out
+// the result as a line diff of their canonical pretty-printed forms.
+package jsondiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/amterp/go-delta/internal/diff"
+	"github.com/amterp/go-delta/internal/structdiff"
+)
+
+// indent is the canonical indentation per nesting level.
+const indent = "  "
+
+// Options controls how documents are compared.
+type Options struct {
+	// ArrayKeys are object fields identifying array elements: objects
+	// in arrays are matched by the first of these fields they have,
+	// instead of by position and content.
+	ArrayKeys []string
+	// IgnoreArrayOrder compares arrays as unordered collections.
+	IgnoreArrayOrder bool
+}
+
+// Parse decodes a single JSON document, keeping numbers as written.
+func Parse(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after top-level value")
+	}
+	return v, nil
+}
+
+// Diff compares two decoded documents, as returned by Parse, and
+// returns their canonical forms as a line diff: objects print with
+// sorted keys, so key order never shows as a change. Changed lines are
+// labeled with the JSON path of the value they belong to, such as
+// "$.address.zip", in OldLabel or NewLabel. Changed scalars and lines
+// differing only in a trailing comma are returned as pairs. Returns nil
+// if the documents are equal.
+func Diff(old, new any, opts Options) ([]diff.Line, []diff.Pair) {
+	if Equal(old, new) {
+		return nil, nil
+	}
+	d := differ{opts: opts}
+	d.value(old, new, node{oldPath: "$", newPath: "$"})
+	return d.Lines, d.Pairs
+}
+
+// Equal reports whether two decoded values are equal, comparing numbers
+// by value, so 1 and 1.0 are equal.
+func Equal(a, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, av := range a {
+			bv, ok := b[k]
+			if !ok || !Equal(av, bv) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !Equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		return a == b || CanonicalNumber(a) == CanonicalNumber(b)
+	default:
+		return a == b
+	}
+}
+
+// CanonicalNumber returns a JSON number's exact value in a canonical
+// form, its significant digits and decimal exponent, so numbers can be
+// compared without rounding: 1, 1.0 and 10e-1 are all 1e0, while
+// integers too large for a float64 to tell apart, such as 64-bit IDs,
+// stay distinct. A number whose exponent doesn't fit an int64 is
+// returned as is.
+func CanonicalNumber(n json.Number) json.Number {
+	s := string(n)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	exp := int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(s[i+1:], 10, 64)
+		if err != nil {
+			return n
+		}
+		s, exp = s[:i], e
+	}
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		exp -= int64(len(s) - i - 1)
+		s = s[:i] + s[i+1:]
+	}
+	s = strings.TrimLeft(s, "0")
+	if s == "" {
+		return "0" // -0 equals 0
+	}
+	trimmed := strings.TrimRight(s, "0")
+	exp += int64(len(s) - len(trimmed))
+	return json.Number(fmt.Sprintf("%s%se%d", sign, trimmed, exp))
+}
+
+// node is where a value is printed: its indentation, the key text
+// before it, whether a comma follows it on each side, and its path on
+// each side.
+type node struct {
+	depth              int
+	prefix             string
+	oldComma, newComma bool
+	oldPath, newPath   string
+}
+
+type differ struct {
+	structdiff.Builder
+	opts Options
+}
+
+// value diffs old and new at n.
+func (d *differ) value(old, new any, n node) {
+	if Equal(old, new) {
+		// Equal values print the same except possibly for a trailing
+		// comma, or a number written differently, on the last line.
+		ps := render(new, n.depth, n.prefix, n.newComma, n.newPath)
+		if n.oldComma == n.newComma {
+			d.EmitAll(diff.OpEqual, ps)
+			return
+		}
+		last := len(ps) - 1
+		d.EmitAll(diff.OpEqual, ps[:last])
+		d.EmitPair(render(new, n.depth, n.prefix, n.oldComma, n.oldPath)[last], ps[last])
+		return
+	}
+
+	switch o := old.(type) {
+	case map[string]any:
+		if nw, ok := new.(map[string]any); ok && len(o) > 0 && len(nw) > 0 {
+			d.object(o, nw, n)
+			return
+		}
+	case []any:
+		if nw, ok := new.([]any); ok && len(o) > 0 && len(nw) > 0 {
+			d.array(o, nw, n)
+			return
+		}
+	}
+	oldLines := render(old, n.depth, n.prefix, n.oldComma, n.oldPath)
+	newLines := render(new, n.depth, n.prefix, n.newComma, n.newPath)
+	if len(oldLines) == 1 && len(newLines) == 1 {
+		d.EmitPair(oldLines[0], newLines[0])
+		return
+	}
+	d.EmitAll(diff.OpDelete, oldLines)
+	d.EmitAll(diff.OpInsert, newLines)
+}
+
+// object diffs two non-empty objects key by key.
+func (d *differ) object(old, new map[string]any, n node) {
+	d.open(n, "{")
+	oldKeys, newKeys := sortedKeys(old), sortedKeys(new)
+	keys := sortedKeys(merge(old, new))
+	for _, k := range keys {
+		child := node{
+			depth:    n.depth + 1,
+			prefix:   quote(k) + ": ",
+			oldComma: k != oldKeys[len(oldKeys)-1],
+			newComma: k != newKeys[len(newKeys)-1],
+			oldPath:  keyPath(n.oldPath, k),
+			newPath:  keyPath(n.newPath, k),
+		}
+		ov, inOld := old[k]
+		nv, inNew := new[k]
+		switch {
+		case inOld && inNew:
+			d.value(ov, nv, child)
+		case inOld:
+			d.EmitAll(diff.OpDelete, render(ov, child.depth, child.prefix, child.oldComma, child.oldPath))
+		default:
+			d.EmitAll(diff.OpInsert, render(nv, child.depth, child.prefix, child.newComma, child.newPath))
+		}
+	}
+	d.close(n, "}")
+}
+
+// array diffs two non-empty arrays, matching their elements by content,
+// or by key (see Options.ArrayKeys), and diffing matched elements.
+// Elements are matched in order, except in arrays with keyed elements,
+// which are matched wherever they are and laid out in the new order.
+func (d *differ) array(old, new []any, n node) {
+	d.open(n, "[")
+	oldOrder, newOrder := d.order(old), d.order(new)
+	oldIDs, newIDs := make([]string, len(old)), make([]string, len(new))
+	for k, i := range oldOrder {
+		oldIDs[k] = d.identity(old[i])
+	}
+	for k, j := range newOrder {
+		newIDs[k] = d.identity(new[j])
+	}
+
+	child := func(i, j int) node {
+		c := node{depth: n.depth + 1}
+		if i >= 0 {
+			c.oldComma = i != oldOrder[len(oldOrder)-1]
+			c.oldPath = fmt.Sprintf("%s[%d]", n.oldPath, i)
+		}
+		if j >= 0 {
+			c.newComma = j != newOrder[len(newOrder)-1]
+			c.newPath = fmt.Sprintf("%s[%d]", n.newPath, j)
+		}
+		return c
+	}
+	var matches []structdiff.Match
+	if keyed(oldIDs) || keyed(newIDs) {
+		// Keyed elements are the same record wherever they moved to.
+		matches = structdiff.MatchByID(oldIDs, newIDs)
+	} else {
+		matches = structdiff.ScriptMatches(diff.EditScript(oldIDs, newIDs))
+	}
+	// Elements removed and added next to each other are diffed
+	// pairwise when they are containers of the same kind, so changes
+	// inside them show in place. Keyed elements with different keys
+	// are different records and are never paired.
+	structdiff.WalkMatches(matches,
+		func(i, j int) bool { return d.pairable(old[oldOrder[i]], new[newOrder[j]]) },
+		func(i, j int) {
+			i, j = oldOrder[i], newOrder[j]
+			d.value(old[i], new[j], child(i, j))
+		},
+		func(i int) {
+			c := child(oldOrder[i], -1)
+			d.EmitAll(diff.OpDelete, render(old[oldOrder[i]], c.depth, "", c.oldComma, c.oldPath))
+		},
+		func(j int) {
+			c := child(-1, newOrder[j])
+			d.EmitAll(diff.OpInsert, render(new[newOrder[j]], c.depth, "", c.newComma, c.newPath))
+		})
+	d.close(n, "]")
+}
+
+// keyed reports whether any of ids identifies an element by key.
+func keyed(ids []string) bool {
+	for _, id := range ids {
+		if strings.HasPrefix(id, "key:") {
+			return true
+		}
+	}
+	return false
+}
+
+// open emits the opening line of a container diffed entry by entry.
+func (d *differ) open(n node, bracket string) {
+	d.Emit(diff.OpEqual, structdiff.Printed{Text: strings.Repeat(indent, n.depth) + n.prefix + bracket})
+}
+
+// close emits the closing line of a container diffed entry by entry.
+func (d *differ) close(n node, bracket string) {
+	line := strings.Repeat(indent, n.depth) + bracket
+	if n.oldComma == n.newComma {
+		d.Emit(diff.OpEqual, structdiff.Printed{Text: line + commaIf(n.oldComma)})
+		return
+	}
+	d.EmitPair(structdiff.Printed{Text: line + commaIf(n.oldComma), Path: n.oldPath}, structdiff.Printed{Text: line + commaIf(n.newComma), Path: n.newPath})
+}
+
+// order returns the indices of elems in the order they are compared:
+// as written, or sorted by identity with IgnoreArrayOrder.
+func (d *differ) order(elems []any) []int {
+	idx := make([]int, len(elems))
+	for i := range idx {
+		idx[i] = i
+	}
+	if d.opts.IgnoreArrayOrder {
+		ids := make([]string, len(elems))
+		for i, e := range elems {
+			ids[i] = d.identity(e)
+		}
+		sort.SliceStable(idx, func(a, b int) bool { return ids[idx[a]] < ids[idx[b]] })
+	}
+	return idx
+}
+
+// identity returns what an array element is matched by: its key field
+// if it is an object with one of the ArrayKeys, otherwise its content.
+func (d *differ) identity(v any) string {
+	if obj, ok := v.(map[string]any); ok {
+		for _, k := range d.opts.ArrayKeys {
+			if kv, ok := obj[k]; ok {
+				return "key:" + k + "=" + compact(kv)
+			}
+		}
+	}
+	return "value:" + compact(v)
+}
+
+// pairable reports whether removed and added elements should be diffed
+// as one changed element.
+func (d *differ) pairable(old, new any) bool {
+	if strings.HasPrefix(d.identity(old), "key:") || strings.HasPrefix(d.identity(new), "key:") {
+		return false
+	}
+	switch old.(type) {
+	case map[string]any:
+		_, ok := new.(map[string]any)
+		return ok
+	case []any:
+		_, ok := new.([]any)
+		return ok
+	}
+	return false
+}
+
+// render prints v in canonical form at depth, after prefix, with a
+// trailing comma if comma is set. Lines are labeled with the path of
+// the value they print, starting from path.
+func render(v any, depth int, prefix string, comma bool, path string) []structdiff.Printed {
+	pad := strings.Repeat(indent, depth)
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			return []structdiff.Printed{{Text: pad + prefix + "{}" + commaIf(comma), Path: path}}
+		}
+		out := []structdiff.Printed{{Text: pad + prefix + "{", Path: path}}
+		keys := sortedKeys(v)
+		for i, k := range keys {
+			out = append(out, render(v[k], depth+1, quote(k)+": ", i < len(keys)-1, keyPath(path, k))...)
+		}
+		return append(out, structdiff.Printed{Text: pad + "}" + commaIf(comma), Path: path})
+	case []any:
+		if len(v) == 0 {
+			return []structdiff.Printed{{Text: pad + prefix + "[]" + commaIf(comma), Path: path}}
+		}
+		out := []structdiff.Printed{{Text: pad + prefix + "[", Path: path}}
+		for i, e := range v {
+			out = append(out, render(e, depth+1, "", i < len(v)-1, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return append(out, structdiff.Printed{Text: pad + "]" + commaIf(comma), Path: path})
+	default:
+		return []structdiff.Printed{{Text: pad + prefix + compact(v) + commaIf(comma), Path: path}}
+	}
+}
+
+// compact returns v as compact JSON, with object keys sorted.
+func compact(v any) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v) // decoded values always encode
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// quote returns s as a JSON string.
+func quote(s string) string {
+	return compact(s)
+}
+
+func commaIf(on bool) string {
+	if on {
+		return ","
+	}
+	return ""
+}
+
+// identifier matches keys that can be written as .key in a JSON path.
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// keyPath returns the path of the member k of the object at path.
+func keyPath(path, k string) string {
+	if identifier.MatchString(k) {
+		return path + "." + k
+	}
+	return path + "[" + quote(k) + "]"
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// merge returns a map with the keys of both a and b.
+func merge(a, b map[string]any) map[string]any {
+	m := make(map[string]any, len(a)+len(b))
+	for k, v := range a {
+		m[k] = v
+	}
+	for k, v := range b {
+		m[k] = v
+	}
+	return m
+}
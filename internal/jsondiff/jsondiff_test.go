@@ -0,0 +1,244 @@
+package jsondiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/amterp/go-delta/internal/diff"
+)
+
+func mustParse(t *testing.T, s string) any {
+	t.Helper()
+	v, err := Parse([]byte(s))
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", s, err)
+	}
+	return v
+}
+
+// format renders lines as "<op> <content>  # <label>" rows.
+func format(lines []diff.Line, _ []diff.Pair) string {
+	var b strings.Builder
+	for _, l := range lines {
+		switch l.Kind {
+		case diff.OpEqual:
+			b.WriteString("  " + l.Content + "\n")
+		case diff.OpDelete:
+			b.WriteString("- " + l.Content + "  # " + l.OldLabel + "\n")
+		case diff.OpInsert:
+			b.WriteString("+ " + l.Content + "  # " + l.NewLabel + "\n")
+		}
+	}
+	return b.String()
+}
+
+func TestParse(t *testing.T) {
+	v := mustParse(t, `{"a": 1.50}`)
+	if compact(v) != `{"a":1.50}` {
+		t.Errorf("numbers should keep their text, got %s", compact(v))
+	}
+	if _, err := Parse([]byte(`{} {}`)); err == nil {
+		t.Error("expected an error for trailing data")
+	}
+	if _, err := Parse([]byte(`{`)); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{`{"a":1,"b":2}`, `{"b":2,"a":1}`, true},
+		{`1`, `1.0`, true},
+		{`100`, `1e2`, true},
+		{`0.5`, `5E-1`, true},
+		{`-0`, `0.0`, true},
+		{`1.5`, `-1.5`, false},
+		{`9007199254740993`, `9007199254740992`, false},
+		{`18446744073709551615`, `18446744073709551614`, false},
+		{`9007199254740993`, `9007199254740993.0`, true},
+		{`[1,2]`, `[2,1]`, false},
+		{`"1"`, `1`, false},
+		{`null`, `false`, false},
+	}
+	for _, tt := range tests {
+		if got := Equal(mustParse(t, tt.a), mustParse(t, tt.b)); got != tt.want {
+			t.Errorf("Equal(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDiffKeyOrderIgnored(t *testing.T) {
+	lines, pairs := Diff(mustParse(t, `{"a":1,"b":2}`), mustParse(t, `{"b":2,"a":1}`), Options{})
+	if lines != nil {
+		t.Errorf("expected no diff, got:\n%s", format(lines, pairs))
+	}
+}
+
+func TestDiffNestedChange(t *testing.T) {
+	old := `{"name":"Alice","address":{"city":"X","state":"IL"}}`
+	new := `{"name":"Alice","address":{"city":"X","zip":"62704"}}`
+	got := format(Diff(mustParse(t, old), mustParse(t, new), Options{}))
+	want := `  {
+    "address": {
+      "city": "X",
+-     "state": "IL"  # $.address.state
++     "zip": "62704"  # $.address.zip
+    },
+    "name": "Alice"
+  }
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffTrailingComma(t *testing.T) {
+	got := format(Diff(mustParse(t, `{"a":1}`), mustParse(t, `{"a":1,"b":2}`), Options{}))
+	want := `  {
+-   "a": 1  # $.a
++   "a": 1,  # $.a
++   "b": 2  # $.b
+  }
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffArrayInsert(t *testing.T) {
+	got := format(Diff(mustParse(t, `["a","c"]`), mustParse(t, `["a","b","c"]`), Options{}))
+	want := `  [
+    "a",
++   "b",  # $[1]
+    "c"
+  ]
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffArrayPairsChangedObjects(t *testing.T) {
+	got := format(Diff(mustParse(t, `[{"id":1,"v":"a"}]`), mustParse(t, `[{"id":1,"v":"b"}]`), Options{}))
+	want := `  [
+    {
+      "id": 1,
+-     "v": "a"  # $[0].v
++     "v": "b"  # $[0].v
+    }
+  ]
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffPairs(t *testing.T) {
+	old := `{"list":[1,{"a":1}],"state":"IL","street":"Main"}`
+	new := `{"list":[1],"street":"Oak","zip":"1"}`
+	lines, pairs := Diff(mustParse(t, old), mustParse(t, new), Options{})
+	var got []string
+	for _, p := range pairs {
+		got = append(got, lines[p.Old].Content+" => "+lines[p.New].Content)
+	}
+	want := []string{
+		`    1, =>     1`,
+		`  "street": "Main" =>   "street": "Oak",`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got pairs:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, p := range pairs {
+		if lines[p.Old].Kind != diff.OpDelete || lines[p.New].Kind != diff.OpInsert {
+			t.Errorf("pair %v isn't a removed and an added line", p)
+		}
+	}
+}
+
+func TestDiffArrayKeys(t *testing.T) {
+	old := `[{"id":1,"v":"a"},{"id":2,"v":"b"}]`
+	new := `[{"id":2,"v":"B"},{"id":3,"v":"c"}]`
+	got := format(Diff(mustParse(t, old), mustParse(t, new), Options{ArrayKeys: []string{"id"}}))
+	want := `  [
+-   {  # $[0]
+-     "id": 1,  # $[0].id
+-     "v": "a"  # $[0].v
+-   },  # $[0]
+    {
+      "id": 2,
+-     "v": "b"  # $[1].v
++     "v": "B"  # $[0].v
+-   }  # $[1]
++   },  # $[0]
++   {  # $[1]
++     "id": 3,  # $[1].id
++     "v": "c"  # $[1].v
++   }  # $[1]
+  ]
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffArrayKeysMoved(t *testing.T) {
+	// A keyed element that moved is still diffed field by field.
+	old := `[{"id":1,"a":1},{"id":2}]`
+	new := `[{"id":2},{"id":1,"a":3}]`
+	got := format(Diff(mustParse(t, old), mustParse(t, new), Options{ArrayKeys: []string{"id"}}))
+	want := `  [
+    {
+      "id": 2
+-   }  # $[1]
++   },  # $[0]
+    {
+-     "a": 1,  # $[0].a
++     "a": 3,  # $[1].a
+      "id": 1
+-   },  # $[0]
++   }  # $[1]
+  ]
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffIgnoreArrayOrder(t *testing.T) {
+	old, new := mustParse(t, `[3,1,2]`), mustParse(t, `[1,2,3]`)
+	lines, _ := Diff(old, new, Options{IgnoreArrayOrder: true})
+	for _, l := range lines {
+		if l.Kind != diff.OpEqual {
+			t.Fatalf("expected no changes, got %+v", l)
+		}
+	}
+	if lines, _ := Diff(old, new, Options{}); len(lines) == 0 {
+		t.Error("order should matter by default")
+	}
+}
+
+func TestDiffTypeChange(t *testing.T) {
+	got := format(Diff(mustParse(t, `{"a":[1]}`), mustParse(t, `{"a":"x"}`), Options{}))
+	want := `  {
+-   "a": [  # $.a
+-     1  # $.a[0]
+-   ]  # $.a
++   "a": "x"  # $.a
+  }
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestKeyPath(t *testing.T) {
+	if got := keyPath("$", "zip"); got != "$.zip" {
+		t.Errorf("got %s", got)
+	}
+	if got := keyPath("$", "a b"); got != `$["a b"]` {
+		t.Errorf("got %s", got)
+	}
+}
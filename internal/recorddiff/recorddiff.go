@@ -0,0 +1,358 @@
+// Package recorddiff diffs streams of line-delimited records, such as
+// JSON Lines or logfmt logs, matching records by a key field and
+// comparing them field by field.
+package recorddiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/amterp/go-delta/internal/align"
+	"github.com/amterp/go-delta/internal/diff"
+	"github.com/amterp/go-delta/internal/jsondiff"
+	"github.com/amterp/go-delta/internal/structdiff"
+)
+
+// DefaultKey is the field that identifies records if none is set.
+const DefaultKey = "id"
+
+// Options controls how records are compared.
+type Options struct {
+	// Key is the field identifying a record. Records with the same
+	// value are compared wherever they are in the stream, repeated
+	// values in order. Records without it are matched by position among
+	// such records. Empty means DefaultKey.
+	Key string
+}
+
+// field is a top-level field of a record.
+type field struct {
+	name  string
+	key   string // the name as written, with its separator: `"id":` or `id=`
+	text  string // the value as displayed
+	value string // the value as compared
+	plain string // the value as shown in the gutter
+}
+
+// record is a parsed line.
+type record struct {
+	json   bool
+	fields []field
+	line   int // 1-based
+}
+
+// get returns the first field named name.
+func (r record) get(name string) (field, bool) {
+	for _, f := range r.fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// parse reads one record per non-blank line. A line starting with '{'
+// is a JSON object; any other line is logfmt, as in
+// `level=info msg="hello world" id=42`.
+func parse(data []byte) ([]record, error) {
+	var records []record
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		r := record{line: i + 1, json: line[0] == '{'}
+		var err error
+		if r.json {
+			r.fields, err = parseJSON(line)
+		} else {
+			r.fields, err = parseLogfmt(line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// parseJSON reads the fields of a JSON object in the order written.
+// Values are compared by their canonical encoding, so nested objects
+// with their keys in a different order are equal, as are numbers with
+// the same value, such as 1 and 1.0.
+func parseJSON(line string) ([]field, error) {
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	var fields []field
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		name := t.(string) // object keys are always strings
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, raw); err != nil {
+			return nil, err
+		}
+		var v any
+		d := json.NewDecoder(bytes.NewReader(raw))
+		d.UseNumber()
+		if err := d.Decode(&v); err != nil {
+			return nil, err
+		}
+		f := field{name: name, key: encode(name) + ":", text: compact.String(), value: encode(canonical(v))}
+		f.plain = encode(v)
+		if s, ok := v.(string); ok {
+			f.plain = s
+		}
+		fields = append(fields, f)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after JSON object")
+	}
+	return fields, nil
+}
+
+// canonical returns v with its numbers in canonical form (see
+// jsondiff.CanonicalNumber).
+func canonical(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = canonical(e)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = canonical(e)
+		}
+		return out
+	case json.Number:
+		return jsondiff.CanonicalNumber(v)
+	}
+	return v
+}
+
+// encode returns the compact JSON encoding of v, with map keys sorted
+// and without escaping HTML characters.
+func encode(v any) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v) // v was decoded from JSON, so it encodes
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// parseLogfmt reads space-separated key=value pairs. Values may be
+// double-quoted with Go escapes; a key without "=" has an empty value.
+func parseLogfmt(line string) ([]field, error) {
+	var fields []field
+	for i := 0; i < len(line); {
+		if line[i] == ' ' || line[i] == '\t' {
+			i++
+			continue
+		}
+		start := i
+		for i < len(line) && line[i] != '=' && line[i] != ' ' && line[i] != '\t' && line[i] != '"' {
+			i++
+		}
+		name := line[start:i]
+		if name == "" {
+			return nil, fmt.Errorf("expected a key at column %d", i+1)
+		}
+		if i == len(line) || line[i] != '=' {
+			if i < len(line) && line[i] == '"' {
+				return nil, fmt.Errorf("unexpected '\"' at column %d", i+1)
+			}
+			fields = append(fields, field{name: name, key: name})
+			continue
+		}
+		i++ // '='
+		valStart := i
+		var value string
+		if i < len(line) && line[i] == '"' {
+			end := quotedEnd(line, i)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quoted value at column %d", i+1)
+			}
+			s, err := strconv.Unquote(line[i:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted value at column %d", i+1)
+			}
+			value, i = s, end
+		} else {
+			for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+				i++
+			}
+			value = line[valStart:i]
+		}
+		fields = append(fields, field{name: name, key: name + "=", text: line[valStart:i], value: value, plain: value})
+	}
+	return fields, nil
+}
+
+// quotedEnd returns the index after the closing quote of the string
+// starting at line[i], or -1 if it isn't closed.
+func quotedEnd(line string, i int) int {
+	for j := i + 1; j < len(line); j++ {
+		switch line[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		}
+	}
+	return -1
+}
+
+// Hunks diffs two record streams and returns the added, removed and
+// modified records with contextRecords unchanged records around them.
+// Records are shown compacted and in the new stream's order; removed
+// records follow the record before them in the old stream. In a
+// modified record, changed values and added or removed fields are
+// emphasized whole. Gutters show each record's key, or its line number
+// if it has none.
+func Hunks(old, new []byte, opts Options, contextRecords int) ([]align.AnnotatedHunk, error) {
+	key := opts.Key
+	if key == "" {
+		key = DefaultKey
+	}
+	oldRecords, err := parse(old)
+	if err != nil {
+		return nil, fmt.Errorf("old records: %w", err)
+	}
+	newRecords, err := parse(new)
+	if err != nil {
+		return nil, fmt.Errorf("new records: %w", err)
+	}
+
+	var b structdiff.Builder
+	for _, m := range structdiff.MatchByID(ids(oldRecords, key), ids(newRecords, key)) {
+		var o, n *record
+		if m.Old >= 0 {
+			o = &oldRecords[m.Old]
+		}
+		if m.New >= 0 {
+			n = &newRecords[m.New]
+		}
+		add(&b, o, n, key)
+	}
+
+	hunks := diff.ComputeHunks(b.Lines, contextRecords)
+	if len(hunks) == 0 {
+		return nil, nil
+	}
+	return b.Annotate(hunks), nil
+}
+
+// ids returns each record's ID: "=" and its key value, or its position
+// among records without the key.
+func ids(records []record, key string) []string {
+	out := make([]string, len(records))
+	unkeyed := 0
+	for i, r := range records {
+		if f, ok := r.get(key); ok {
+			out[i] = "=" + f.value
+			continue
+		}
+		out[i] = fmt.Sprintf("#%d", unkeyed)
+		unkeyed++
+	}
+	return out
+}
+
+// add adds a record that is old in the old stream and new in the new
+// one, either nil if missing.
+func add(b *structdiff.Builder, old, new *record, key string) {
+	switch {
+	case old != nil && new != nil && equal(*old, *new):
+		b.Lines = append(b.Lines, diff.Line{Kind: diff.OpEqual, Content: structdiff.Text(tokens(*new, nil, 0)),
+			OldLabel: label(*old, key), NewLabel: label(*new, key)})
+	case old != nil && new != nil:
+		b.AddPair(tokens(*old, new, align.AlignDelete), tokens(*new, old, align.AlignInsert), label(*old, key), label(*new, key))
+	case old != nil:
+		b.Lines = append(b.Lines, diff.Line{Kind: diff.OpDelete, Content: structdiff.Text(tokens(*old, nil, 0)), OldLabel: label(*old, key)})
+	default:
+		b.Lines = append(b.Lines, diff.Line{Kind: diff.OpInsert, Content: structdiff.Text(tokens(*new, nil, 0)), NewLabel: label(*new, key)})
+	}
+}
+
+// equal reports whether two records have the same fields with the same
+// values, in any order.
+func equal(a, b record) bool {
+	if len(a.fields) != len(b.fields) {
+		return false
+	}
+	for _, f := range a.fields {
+		g, ok := b.get(f.name)
+		if !ok || f.value != g.value {
+			return false
+		}
+	}
+	return true
+}
+
+// label returns the gutter label of a record: its key value, quoted if
+// it has control characters, or its line number.
+func label(r record, key string) string {
+	f, ok := r.get(key)
+	if !ok {
+		return strconv.Itoa(r.line)
+	}
+	if strings.ContainsFunc(f.plain, unicode.IsControl) {
+		return strconv.Quote(f.plain)
+	}
+	return f.plain
+}
+
+// tokens formats r as a line, one token per field key, value and
+// separator. If other is set, values differing from other's are marked
+// with op, as are whole fields other lacks.
+func tokens(r record, other *record, op align.AlignOp) []align.AlignedToken {
+	var out []align.AlignedToken
+	add := func(text string, op align.AlignOp) {
+		out = structdiff.AppendToken(out, text, op)
+	}
+	sep := " "
+	if r.json {
+		add("{", align.AlignMatch)
+		sep = ","
+	}
+	for i, f := range r.fields {
+		if i > 0 {
+			add(sep, align.AlignMatch)
+		}
+		keyOp, valueOp := align.AlignMatch, align.AlignMatch
+		if other != nil {
+			if g, ok := other.get(f.name); !ok {
+				keyOp, valueOp = op, op
+			} else if f.value != g.value {
+				valueOp = op
+			}
+		}
+		add(f.key, keyOp)
+		add(f.text, valueOp)
+	}
+	if r.json {
+		add("}", align.AlignMatch)
+	}
+	return out
+}
@@ -0,0 +1,184 @@
+package recorddiff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/amterp/go-delta/internal/align"
+	"github.com/amterp/go-delta/internal/diff"
+)
+
+// render shows hunks as "-"/"+"/" " prefixed lines.
+func render(hunks []align.AnnotatedHunk) []string {
+	var out []string
+	for _, h := range hunks {
+		for _, l := range h.Lines {
+			prefix := map[diff.OpKind]string{diff.OpEqual: " ", diff.OpDelete: "-", diff.OpInsert: "+"}[l.Kind]
+			out = append(out, prefix+l.Content)
+		}
+	}
+	return out
+}
+
+// emphasized returns the emphasized tokens of each pair.
+func emphasized(hunks []align.AnnotatedHunk) (old, new []string) {
+	for _, h := range hunks {
+		for _, p := range h.Pairs {
+			for _, t := range p.Alignment.Old {
+				if t.Op != align.AlignMatch {
+					old = append(old, t.Token.Text)
+				}
+			}
+			for _, t := range p.Alignment.New {
+				if t.Op != align.AlignMatch {
+					new = append(new, t.Token.Text)
+				}
+			}
+		}
+	}
+	return old, new
+}
+
+func TestHunksIdentical(t *testing.T) {
+	old := `{"id":1,"a":{"x":1,"y":2}}` + "\n" + `{"id":2}` + "\n"
+	new := `{"id":2}` + "\n\n" + `{ "a": {"y":2, "x":1}, "id": 1 }` + "\n"
+	hunks, err := Hunks([]byte(old), []byte(new), Options{}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hunks != nil {
+		t.Errorf("reordered records and fields should have no diff, got %q", render(hunks))
+	}
+}
+
+func TestHunksJSONLines(t *testing.T) {
+	old := `{"id":1,"status":"ok","ms":12}
+{"id":2,"status":"ok","ms":30}
+{"id":3,"status":"ok","ms":7}
+`
+	new := `{"id":3,"status":"ok","ms":7}
+{"id":1,"status":"error","ms":12,"error":"timeout"}
+{"id":4,"status":"ok","ms":9}
+`
+	hunks, err := Hunks([]byte(old), []byte(new), Options{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`-{"id":1,"status":"ok","ms":12}`,
+		`+{"id":1,"status":"error","ms":12,"error":"timeout"}`,
+		`-{"id":2,"status":"ok","ms":30}`,
+		`+{"id":4,"status":"ok","ms":9}`,
+	}
+	if got := render(hunks); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	oldEmph, newEmph := emphasized(hunks)
+	if !reflect.DeepEqual(oldEmph, []string{`"ok"`}) || !reflect.DeepEqual(newEmph, []string{`"error"`, `"error":`, `"timeout"`}) {
+		t.Errorf("emphasized %q and %q", oldEmph, newEmph)
+	}
+	l := hunks[0].Lines
+	if l[0].OldLabel != "1" || l[1].NewLabel != "1" || l[2].OldLabel != "2" || l[3].NewLabel != "4" {
+		t.Errorf("labels = %q %q %q %q; want record keys", l[0].OldLabel, l[1].NewLabel, l[2].OldLabel, l[3].NewLabel)
+	}
+}
+
+func TestHunksLogfmt(t *testing.T) {
+	old := `ts=1 req=a level=info msg="request done" status=200` + "\n"
+	new := `ts=2 req=a level=info msg="request done" status=500 retry` + "\n"
+	hunks, err := Hunks([]byte(old), []byte(new), Options{Key: "req"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldEmph, newEmph := emphasized(hunks)
+	if !reflect.DeepEqual(oldEmph, []string{"1", "200"}) || !reflect.DeepEqual(newEmph, []string{"2", "500", "retry"}) {
+		t.Errorf("emphasized %q and %q", oldEmph, newEmph)
+	}
+	if got := hunks[0].Lines[0].OldLabel; got != "a" {
+		t.Errorf("label = %q, want a", got)
+	}
+}
+
+func TestHunksUnkeyed(t *testing.T) {
+	// Records without the key are matched by position among themselves.
+	old := "msg=start\nid=7 n=1\nmsg=stop\n"
+	new := "id=7 n=1\nmsg=start\nmsg=halt\n"
+	hunks, err := Hunks([]byte(old), []byte(new), Options{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"-msg=stop", "+msg=halt"}
+	if got := render(hunks); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	l := hunks[0].Lines
+	if l[0].OldLabel != "3" || l[1].NewLabel != "3" {
+		t.Errorf("labels = %q, %q; want line numbers", l[0].OldLabel, l[1].NewLabel)
+	}
+}
+
+func TestHunksRepeatedKeys(t *testing.T) {
+	old := "id=1 n=a\nid=1 n=b\n"
+	new := "id=1 n=a\nid=1 n=c\n"
+	hunks, err := Hunks([]byte(old), []byte(new), Options{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"-id=1 n=b", "+id=1 n=c"}
+	if got := render(hunks); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHunksKeyTypes(t *testing.T) {
+	// A string ID and a numeric ID are different records, while numbers
+	// with the same value are the same one.
+	old := `{"id":"1","n":"a"}` + "\n" + `{"id":2,"n":"b"}` + "\n"
+	new := `{"id":1,"n":"a"}` + "\n" + `{"id":2.0,"n":"c"}` + "\n"
+	hunks, err := Hunks([]byte(old), []byte(new), Options{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`-{"id":"1","n":"a"}`,
+		`+{"id":1,"n":"a"}`,
+		`-{"id":2,"n":"b"}`,
+		`+{"id":2.0,"n":"c"}`,
+	}
+	if got := render(hunks); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if n := len(hunks[0].Pairs); n != 1 || hunks[0].Pairs[0].OldIdx != 2 {
+		t.Errorf("expected only the records with ID 2 to pair, got %+v", hunks[0].Pairs)
+	}
+}
+
+func TestParseLogfmt(t *testing.T) {
+	fields, err := parseLogfmt(`a=1 b="x \"y\"" c= d`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range fields {
+		got = append(got, f.name+"|"+f.text+"|"+f.value)
+	}
+	want := []string{"a|1|1", `b|"x \"y\""|x "y"`, "c||", "d||"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHunksErrors(t *testing.T) {
+	for _, tc := range []struct{ old, new, want string }{
+		{"id=1\n{\"id\":\n", "", "old records: line 2"},
+		{"", `msg="unterminated`, "new records: line 1"},
+		{"", `{"id":1} x`, "unexpected data"},
+		{"", `=1`, "expected a key"},
+	} {
+		_, err := Hunks([]byte(tc.old), []byte(tc.new), Options{}, 3)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Hunks(%q, %q) error = %v, want %q", tc.old, tc.new, err, tc.want)
+		}
+	}
+}
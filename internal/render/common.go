@@ -1,6 +1,7 @@
 package render
 
 import (
+	"cmp"
 	"fmt"
 	"strings"
 
@@ -14,6 +15,36 @@ func formatLineNum(n int, width int) string {
 	return fmt.Sprintf("%*d", width, n)
 }
 
+// formatLineLabel formats the gutter text for a line: its label if it
+// has one, abbreviated as by abbreviateLabel, otherwise its line number
+// n, right-justified to width.
+func formatLineLabel(label string, n int, width int) string {
+	if label == "" {
+		return formatLineNum(n, width)
+	}
+	label = abbreviateLabel(label)
+	return strings.Repeat(" ", max(width-runewidth.StringWidth(label), 0)) + label
+}
+
+// maxLabelWidth is the widest a line label is drawn in the gutter.
+const maxLabelWidth = 32
+
+// abbreviateLabel shortens a label wider than maxLabelWidth to its end,
+// after "…": for a path such as .spec.template.spec.containers[0].image,
+// the end names the value. Hunk headers show paths in full.
+func abbreviateLabel(label string) string {
+	w := runewidth.StringWidth(label)
+	if w <= maxLabelWidth {
+		return label
+	}
+	return runewidth.TruncateLeft(label, w-maxLabelWidth+1, "…")
+}
+
+// labelWidth returns the width label is drawn at in the gutter.
+func labelWidth(label string) int {
+	return runewidth.StringWidth(abbreviateLabel(label))
+}
+
 // blankLineNum returns a blank string of the given width.
 func blankLineNum(width int) string {
 	return strings.Repeat(" ", width)
@@ -32,34 +63,149 @@ func digitCount(n int) int {
 	return count
 }
 
+// skippedSeparator returns the unstyled hunk separator text for a gap
+// of n unchanged lines.
+func skippedSeparator(n int) string {
+	noun := "lines"
+	if n == 1 {
+		noun = "line"
+	}
+	return fmt.Sprintf("~~~ %d %s skipped ~~~", n, noun)
+}
+
+// hunkHeader returns the unstyled git-style header for a hunk:
+// "@@ -12,7 +12,8 @@" followed by the hunk's context, if any.
+func hunkHeader(h align.AnnotatedHunk) string {
+	var oldCount, newCount int
+	for _, l := range h.Lines {
+		if l.Kind != diff.OpInsert {
+			oldCount++
+		}
+		if l.Kind != diff.OpDelete {
+			newCount++
+		}
+	}
+	header := fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.OldStart, oldCount), hunkRange(h.NewStart, newCount))
+	if h.Context != "" {
+		header += " " + (&lineFormatter{}).format(h.Context, false)
+	}
+	return header
+}
+
+// hunkRange formats one side of a hunk header as git does: "start,count",
+// just "start" when count is 1, and the line before the hunk when it
+// has no lines on this side.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// writeHunkSeparator writes what precedes a hunk's rows in the inline
+// layouts: its header with o.HunkHeaders, otherwise a count of the lines
+// skipped before it, if any.
+func writeHunkSeparator(b *strings.Builder, h align.AnnotatedHunk, s Styles, o Options) {
+	switch {
+	case o.HunkHeaders:
+		b.WriteString(s.Separator(hunkHeader(h)) + "\n")
+	case h.Skipped > 0:
+		b.WriteString(s.Separator(skippedSeparator(h.Skipped)) + "\n\n")
+	}
+}
+
+// noNewlineNote is shown after a line that ends its text without a
+// newline, when the other text does end in one.
+const noNewlineNote = "\\ No newline at end of file"
+
 // --- Gutter formatting ---
 
-// gutterInline formats the dual-number gutter for inline mode.
-// For context lines: "NN MM │ "
-// For delete lines:  "NN    │ "
-// For insert lines:  "   MM │ "
-func gutterInline(kind diff.OpKind, oldNum, newNum, oldWidth, newWidth int, s Styles) string {
+// inlineGutter lays out the dual-number gutter of the inline layouts:
+// "NN MM │ " for unchanged lines, "NN    │ " for removed ones and
+// "   MM │ " for added ones. Line labels, where set, replace the numbers.
+type inlineGutter struct {
+	oldWidth, newWidth int
+	// labelColumn is set when the labels of changed lines, such as the
+	// paths of a structural diff, don't fit the number columns. Each is
+	// then drawn once, across both columns, rather than widening both.
+	labelColumn bool
+}
+
+// newInlineGutter sizes the gutter columns for the line numbers and the
+// labels of unchanged lines, and for the labels of changed lines either
+// in their own column or, if wider, in one column across both.
+func newInlineGutter(hunks []align.AnnotatedHunk) inlineGutter {
+	maxOld, maxNew := maxLineNumbers(hunks)
+	g := inlineGutter{oldWidth: digitCount(maxOld), newWidth: digitCount(maxNew)}
+	changedOld, changedNew := 0, 0
+	for _, h := range hunks {
+		for _, l := range h.Lines {
+			switch l.Kind {
+			case diff.OpEqual:
+				g.oldWidth = max(g.oldWidth, labelWidth(l.OldLabel))
+				g.newWidth = max(g.newWidth, labelWidth(l.NewLabel))
+			case diff.OpDelete:
+				changedOld = max(changedOld, labelWidth(l.OldLabel))
+			case diff.OpInsert:
+				changedNew = max(changedNew, labelWidth(l.NewLabel))
+			}
+		}
+	}
+	if changedOld <= g.oldWidth && changedNew <= g.newWidth {
+		return g
+	}
+	g.labelColumn = true
+	g.oldWidth += max(changedOld, changedNew) - g.width()
+	return g
+}
+
+// width returns the width of both columns and the space between them.
+func (g inlineGutter) width() int {
+	return g.oldWidth + 1 + g.newWidth
+}
+
+// format formats the gutter of a line of the given kind. An unchanged
+// kind with labels that don't fit their columns, as for a word-diff row
+// merging a removed and an added line, shows one label across both.
+func (g inlineGutter) format(kind diff.OpKind, l *diff.Line, oldNum, newNum int, s Styles) string {
 	sep := s.LineNum("│")
 	switch kind {
 	case diff.OpEqual:
+		if labelWidth(l.OldLabel) > g.oldWidth || labelWidth(l.NewLabel) > g.newWidth {
+			return g.label(cmp.Or(l.NewLabel, l.OldLabel), s)
+		}
 		return fmt.Sprintf("%s %s %s ",
-			s.LineNum(formatLineNum(oldNum, oldWidth)),
-			s.LineNum(formatLineNum(newNum, newWidth)),
+			s.LineNum(formatLineLabel(l.OldLabel, oldNum, g.oldWidth)),
+			s.LineNum(formatLineLabel(l.NewLabel, newNum, g.newWidth)),
 			sep)
 	case diff.OpDelete:
+		if g.labelColumn && l.OldLabel != "" {
+			return g.label(l.OldLabel, s)
+		}
 		return fmt.Sprintf("%s %s %s ",
-			s.LineNum(formatLineNum(oldNum, oldWidth)),
-			blankLineNum(newWidth),
+			s.LineNum(formatLineLabel(l.OldLabel, oldNum, g.oldWidth)),
+			blankLineNum(g.newWidth),
 			sep)
 	case diff.OpInsert:
+		if g.labelColumn && l.NewLabel != "" {
+			return g.label(l.NewLabel, s)
+		}
 		return fmt.Sprintf("%s %s %s ",
-			blankLineNum(oldWidth),
-			s.LineNum(formatLineNum(newNum, newWidth)),
+			blankLineNum(g.oldWidth),
+			s.LineNum(formatLineLabel(l.NewLabel, newNum, g.newWidth)),
 			sep)
 	}
 	return ""
 }
 
+// label formats a gutter showing label across both columns.
+func (g inlineGutter) label(label string, s Styles) string {
+	return fmt.Sprintf("%s %s ", s.LineNum(formatLineLabel(label, 0, g.width())), s.LineNum("│"))
+}
+
 // --- Hunk walking ---
 
 // hunkRow represents a single output row produced by walking a hunk.
@@ -133,24 +279,36 @@ func walkHunk(h align.AnnotatedHunk) []hunkRow {
 // Consecutive tokens with the same treatment are grouped to minimize
 // style transitions (and ANSI escape overhead).
 func RenderAnnotatedLine(tokens []align.AlignedToken, baseStyle, emphStyle func(string) string) string {
-	if len(tokens) == 0 {
-		return ""
+	var b strings.Builder
+	for _, seg := range annotatedSegments(tokens, baseStyle, emphStyle) {
+		b.WriteString(seg.style(seg.text))
 	}
+	return b.String()
+}
 
-	var b strings.Builder
+// segment is a run of line content drawn in one style.
+type segment struct {
+	text  string
+	style func(string) string
+	emph  bool // an emphasized (changed) run
+}
+
+// annotatedSegments groups aligned tokens into segments: matched tokens
+// in baseStyle, changed tokens in emphStyle.
+func annotatedSegments(tokens []align.AlignedToken, baseStyle, emphStyle func(string) string) []segment {
+	var segs []segment
 	var buf strings.Builder
 	currentEmph := false
 
 	flush := func() {
-		text := buf.String()
-		if text == "" {
+		if buf.Len() == 0 {
 			return
 		}
+		style := baseStyle
 		if currentEmph {
-			b.WriteString(emphStyle(text))
-		} else {
-			b.WriteString(baseStyle(text))
+			style = emphStyle
 		}
+		segs = append(segs, segment{text: buf.String(), style: style, emph: currentEmph})
 		buf.Reset()
 	}
 
@@ -164,7 +322,17 @@ func RenderAnnotatedLine(tokens []align.AlignedToken, baseStyle, emphStyle func(
 	}
 	flush()
 
-	return b.String()
+	return segs
+}
+
+// styledLine is one side of a hunk row, rendered with its "- ", "+ "
+// or "  " marker. whole is the line as drawn when it fits on one row;
+// prefix and body are the marker and text styled separately, used when
+// the body has to wrap onto continuation rows.
+type styledLine struct {
+	whole  string
+	prefix string
+	body   string
 }
 
 // --- ANSI-aware string measurement ---
@@ -1,7 +1,6 @@
 package render
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/amterp/go-delta/internal/align"
@@ -10,32 +9,23 @@ import (
 
 // RenderInline produces an inline (unified-style) diff string from
 // annotated hunks. Paired lines get within-line emphasis; unpaired
-// lines are rendered entirely in their base color.
-func RenderInline(hunks []align.AnnotatedHunk, s Styles) string {
+// lines are rendered entirely in their base color. With o.Wrap and a
+// known o.Width, long lines continue on extra rows.
+func RenderInline(hunks []align.AnnotatedHunk, s Styles, o Options) string {
 	if len(hunks) == 0 {
 		return ""
 	}
 
-	maxOld, maxNew := maxLineNumbers(hunks)
-	oldWidth := digitCount(maxOld)
-	newWidth := digitCount(maxNew)
-
-	var b strings.Builder
+	g := newInlineGutter(hunks)
+	w := newInlineWriter(g, s, o)
+	r := lineRenderer{s: s, o: o}
 
 	for i, h := range hunks {
 		if i > 0 {
-			b.WriteString("\n")
+			w.b.WriteString("\n")
 		}
 
-		if h.Skipped > 0 {
-			noun := "lines"
-			if h.Skipped == 1 {
-				noun = "line"
-			}
-			sep := fmt.Sprintf("~~~ %d %s skipped ~~~", h.Skipped, noun)
-			b.WriteString(s.Separator(sep))
-			b.WriteString("\n\n")
-		}
+		writeHunkSeparator(&w.b, h, s, o)
 
 		rows := walkHunk(h)
 
@@ -45,39 +35,100 @@ func RenderInline(hunks []align.AnnotatedHunk, s Styles) string {
 		for _, row := range rows {
 			switch {
 			case row.IsContext:
-				gutter := gutterInline(diff.OpEqual, oldNum, newNum, oldWidth, newWidth, s)
-				b.WriteString(gutter + "  " + row.Left.Content + "\n")
+				gutter := g.format(diff.OpEqual, row.Left, oldNum, newNum, s)
+				w.writeRow(gutter, r.context(row.Left.Content), s.LineNum)
 				oldNum++
 				newNum++
 
 			case row.IsPaired:
 				// Build both gutters before incrementing either counter
-				delGutter := gutterInline(diff.OpDelete, oldNum, newNum, oldWidth, newWidth, s)
-				insGutter := gutterInline(diff.OpInsert, oldNum, newNum, oldWidth, newWidth, s)
+				delGutter := g.format(diff.OpDelete, row.Left, oldNum, newNum, s)
+				insGutter := g.format(diff.OpInsert, row.Right, oldNum, newNum, s)
 
-				annotated := RenderAnnotatedLine(row.Pair.Alignment.Old, s.Removed, s.RemovedEmph)
-				b.WriteString(delGutter + s.Removed("- ") + annotated + "\n")
-
-				annotated = RenderAnnotatedLine(row.Pair.Alignment.New, s.Added, s.AddedEmph)
-				b.WriteString(insGutter + s.Added("+ ") + annotated + "\n")
+				w.writeRow(delGutter, r.annotated("- ", row.Pair.Alignment.Old, s.Removed, s.RemovedEmph), s.Removed)
+				w.writeNoNewline(row.Left, s.Separator)
+				w.writeRow(insGutter, r.annotated("+ ", row.Pair.Alignment.New, s.Added, s.AddedEmph), s.Added)
+				w.writeNoNewline(row.Right, s.Separator)
 
 				oldNum++
 				newNum++
 
 			case row.Left != nil:
-				gutter := gutterInline(diff.OpDelete, oldNum, newNum, oldWidth, newWidth, s)
-				b.WriteString(gutter + s.Removed(fmt.Sprintf("- %s", row.Left.Content)) + "\n")
+				gutter := g.format(diff.OpDelete, row.Left, oldNum, newNum, s)
+				w.writeRow(gutter, r.unpaired("- ", row.Left.Content, s.Removed), s.Removed)
+				w.writeNoNewline(row.Left, s.Separator)
 				oldNum++
 
 			case row.Right != nil:
-				gutter := gutterInline(diff.OpInsert, oldNum, newNum, oldWidth, newWidth, s)
-				b.WriteString(gutter + s.Added(fmt.Sprintf("+ %s", row.Right.Content)) + "\n")
+				gutter := g.format(diff.OpInsert, row.Right, oldNum, newNum, s)
+				w.writeRow(gutter, r.unpaired("+ ", row.Right.Content, s.Added), s.Added)
+				w.writeNoNewline(row.Right, s.Separator)
 				newNum++
 			}
 		}
 	}
 
-	return b.String()
+	return w.b.String()
+}
+
+// inlineWriter accumulates inline-style output rows, wrapping line
+// bodies that exceed the available width.
+type inlineWriter struct {
+	b          strings.Builder
+	wrapWidth  int    // max body width; 0 = never wrap
+	contGutter string // gutter for continuation rows
+}
+
+func newInlineWriter(g inlineGutter, s Styles, o Options) *inlineWriter {
+	w := &inlineWriter{
+		contGutter: blankLineNum(g.width()) + " " + s.LineNum("│") + " ",
+	}
+	if o.Wrap && o.Width > 0 {
+		// gutter ("NN MM │ ") plus the 2-column "- " marker
+		w.wrapWidth = o.Width - visibleWidth(w.contGutter) - 2
+		if w.wrapWidth < 1 {
+			w.wrapWidth = 0
+		}
+	}
+	return w
+}
+
+// writeRow writes a gutter and line. If the line's body doesn't fit,
+// it continues on rows with a blank gutter and a "↪" marker drawn in
+// markerStyle, so continuations keep the line's -/+ color.
+func (w *inlineWriter) writeRow(gutter string, line styledLine, markerStyle func(string) string) {
+	if w.wrapWidth == 0 || visibleWidth(line.body) <= w.wrapWidth {
+		w.b.WriteString(gutter + line.whole + "\n")
+		return
+	}
+	chunks := wrapToWidth(line.body, w.wrapWidth)
+	w.b.WriteString(gutter + line.prefix + chunks[0] + "\n")
+	for _, chunk := range chunks[1:] {
+		w.b.WriteString(w.contGutter + markerStyle("↪ ") + chunk + "\n")
+	}
+}
+
+// writeNoNewline writes the "\ No newline at end of file" note, drawn
+// in style, on its own row after line if line is marked NoNewline.
+func (w *inlineWriter) writeNoNewline(line *diff.Line, style func(string) string) {
+	if line.NoNewline {
+		w.b.WriteString(w.contGutter + style(noNewlineNote) + "\n")
+	}
+}
+
+// gutterWidths returns the widths of the old and new gutter columns of
+// the side-by-side layout: wide enough for the highest line number or
+// the widest line label, as abbreviated.
+func gutterWidths(hunks []align.AnnotatedHunk) (oldWidth, newWidth int) {
+	maxOld, maxNew := maxLineNumbers(hunks)
+	oldWidth, newWidth = digitCount(maxOld), digitCount(maxNew)
+	for _, h := range hunks {
+		for _, line := range h.Lines {
+			oldWidth = max(oldWidth, labelWidth(line.OldLabel))
+			newWidth = max(newWidth, labelWidth(line.NewLabel))
+		}
+	}
+	return oldWidth, newWidth
 }
 
 // maxLineNumbers computes the highest old and new line numbers that
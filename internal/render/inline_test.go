@@ -18,11 +18,12 @@ func markerStyles() Styles {
 		LineNum:     func(s string) string { return "[N:" + s + "]" },
 		Separator:   func(s string) string { return "[S:" + s + "]" },
 		Plain:       func(s string) string { return s },
+		Whitespace:  func(s string) string { return "[W:" + s + "]" },
 	}
 }
 
 func TestRenderInlineEmpty(t *testing.T) {
-	result := RenderInline(nil, NoColorStyles())
+	result := RenderInline(nil, NoColorStyles(), Options{})
 	if result != "" {
 		t.Errorf("expected empty, got %q", result)
 	}
@@ -42,7 +43,7 @@ func TestRenderInlineUnpairedLines(t *testing.T) {
 		},
 	}
 	s := markerStyles()
-	result := RenderInline(hunks, s)
+	result := RenderInline(hunks, s, Options{})
 	// Unpaired: entire line styled with base color
 	if !strings.Contains(result, "[R:- hello]") {
 		t.Errorf("expected unpaired removed line, got:\n%s", result)
@@ -73,7 +74,7 @@ func TestRenderInlinePairedLinesHaveEmphasis(t *testing.T) {
 		},
 	}
 	s := markerStyles()
-	result := RenderInline(hunks, s)
+	result := RenderInline(hunks, s, Options{})
 
 	// Paired lines should have emphasis markers on changed tokens
 	if !strings.Contains(result, "[RE:") {
@@ -107,7 +108,7 @@ func TestRenderInlineContextLines(t *testing.T) {
 		},
 	}
 	s := NoColorStyles()
-	result := RenderInline(hunks, s)
+	result := RenderInline(hunks, s, Options{})
 	if !strings.Contains(result, "context") {
 		t.Errorf("expected context line, got:\n%s", result)
 	}
@@ -137,8 +138,141 @@ func TestRenderInlineHunkSeparator(t *testing.T) {
 		},
 	}
 	s := markerStyles()
-	result := RenderInline(hunks, s)
+	result := RenderInline(hunks, s, Options{})
 	if !strings.Contains(result, "5 lines skipped") {
 		t.Errorf("expected '5 lines skipped', got:\n%s", result)
 	}
 }
+
+func TestWithEmphasisMarkers(t *testing.T) {
+	s := WithEmphasisMarkers(markerStyles())
+	if got := s.RemovedEmph("30"); got != "[RE:[-30-]]" {
+		t.Errorf("RemovedEmph = %q", got)
+	}
+	if got := s.AddedEmph("31"); got != "[AE:{+31+}]" {
+		t.Errorf("AddedEmph = %q", got)
+	}
+	if got := s.Removed("x"); got != "[R:x]" {
+		t.Errorf("Removed should be unchanged, got %q", got)
+	}
+}
+
+func TestRenderInlineWrap(t *testing.T) {
+	hunks := []align.AnnotatedHunk{
+		{
+			Hunk: diff.Hunk{
+				OldStart: 1, NewStart: 1,
+				Lines: []diff.Line{
+					{Kind: diff.OpDelete, Content: "alpha beta gamma delta epsilon"},
+				},
+			},
+		},
+	}
+	result := RenderInline(hunks, NoColorStyles(), Options{Width: 30, Wrap: true})
+	want := "1   │ - alpha beta gamma \n" +
+		"    │ ↪ delta epsilon\n"
+	if result != want {
+		t.Errorf("got:\n%s\nwant:\n%s", result, want)
+	}
+}
+
+func TestRenderInlineNoWrapWithoutWidth(t *testing.T) {
+	long := strings.Repeat("x ", 100)
+	hunks := []align.AnnotatedHunk{
+		{
+			Hunk: diff.Hunk{
+				OldStart: 1, NewStart: 1,
+				Lines: []diff.Line{{Kind: diff.OpInsert, Content: long}},
+			},
+		},
+	}
+	result := RenderInline(hunks, NoColorStyles(), Options{Wrap: true})
+	if strings.Count(result, "\n") != 1 {
+		t.Errorf("unknown width should not wrap, got:\n%s", result)
+	}
+}
+
+func TestHunkHeader(t *testing.T) {
+	h := align.AnnotatedHunk{Hunk: diff.Hunk{
+		OldStart: 12,
+		NewStart: 12,
+		Context:  "func f() {",
+		Lines: []diff.Line{
+			{Kind: diff.OpEqual, Content: "a"},
+			{Kind: diff.OpDelete, Content: "b"},
+			{Kind: diff.OpInsert, Content: "B"},
+			{Kind: diff.OpInsert, Content: "C"},
+		},
+	}}
+	if got := hunkHeader(h); got != "@@ -12,2 +12,3 @@ func f() {" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestHunkRange(t *testing.T) {
+	tests := []struct {
+		start, count int
+		want         string
+	}{
+		{5, 3, "5,3"},
+		{5, 1, "5"},
+		{5, 0, "4,0"},
+		{1, 0, "0,0"},
+	}
+	for _, tt := range tests {
+		if got := hunkRange(tt.start, tt.count); got != tt.want {
+			t.Errorf("hunkRange(%d, %d) = %q, want %q", tt.start, tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestRenderInlineHunkHeaders(t *testing.T) {
+	h := align.AnnotatedHunk{Hunk: diff.Hunk{
+		OldStart: 3,
+		NewStart: 3,
+		Skipped:  2,
+		Lines:    []diff.Line{{Kind: diff.OpDelete, Content: "x"}},
+	}}
+	got := RenderInline([]align.AnnotatedHunk{h}, markerStyles(), Options{HunkHeaders: true})
+	want := "[S:@@ -3 +2,0 @@]\n[N:3]   [N:│] [R:- x]\n"
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestRenderInlineLabelColumn(t *testing.T) {
+	// Paths too wide for the number columns are drawn in one column
+	// across both, abbreviated to their end if too long.
+	long := ".spec.template.spec.containers[0].image"
+	h := align.AnnotatedHunk{Hunk: diff.Hunk{
+		OldStart: 9, NewStart: 9,
+		Lines: []diff.Line{
+			{Kind: diff.OpEqual, Content: "a"},
+			{Kind: diff.OpDelete, Content: "b", OldLabel: ".b"},
+			{Kind: diff.OpInsert, Content: "c", NewLabel: long},
+		},
+	}}
+	got := RenderInline([]align.AnnotatedHunk{h}, NoColorStyles(), Options{})
+	want := strings.Repeat(" ", 28) + "9  9 │   a\n" +
+		strings.Repeat(" ", 30) + ".b │ - b\n" +
+		"…mplate.spec.containers[0].image │ + c\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderInlineLabelsInTheirColumns(t *testing.T) {
+	// Labels of unchanged lines, such as table row numbers, keep a
+	// column per side.
+	h := align.AnnotatedHunk{Hunk: diff.Hunk{
+		OldStart: 1, NewStart: 1,
+		Lines: []diff.Line{
+			{Kind: diff.OpEqual, Content: "a", OldLabel: "10", NewLabel: "12"},
+			{Kind: diff.OpDelete, Content: "b", OldLabel: "11"},
+		},
+	}}
+	got := RenderInline([]align.AnnotatedHunk{h}, NoColorStyles(), Options{})
+	if want := "10 12 │   a\n11    │ - b\n"; got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
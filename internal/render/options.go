@@ -0,0 +1,21 @@
+package render
+
+// Options holds layout settings shared by the renderers.
+type Options struct {
+	// Width is the terminal width in columns. 0 means unknown, in
+	// which case nothing is truncated or wrapped.
+	Width int
+	// Wrap continues long lines on extra rows instead of truncating
+	// them.
+	Wrap bool
+	// TabWidth is the distance between tab stops. 0 leaves tabs
+	// unexpanded.
+	TabWidth int
+	// Whitespace selects where spaces, tabs and trailing whitespace are
+	// drawn visibly.
+	Whitespace WhitespaceMode
+	// HunkHeaders starts every hunk with a git-style
+	// "@@ -12,7 +12,8 @@ context" header instead of separating hunks
+	// with a count of skipped lines.
+	HunkHeaders bool
+}
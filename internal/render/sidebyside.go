@@ -1,41 +1,45 @@
 package render
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/amterp/go-delta/internal/align"
 )
 
-// sbsItem is a single output element: either a separator line or a
-// left/right panel pair.
+// sbsItem is a single output element: a separator line, a hunk header
+// or a left/right panel pair.
 type sbsItem struct {
 	separator string // non-empty for hunk separator lines
+	header    string // non-empty for hunk header lines
 	left      string // left panel content (no trailing padding)
 	right     string // right panel content
 }
 
 // buildSBSItems builds all panel content items from annotated hunks.
-// maxPanelWidth controls truncation; 0 means no truncation.
+// maxPanelWidth controls truncation; 0 means no truncation. With o.Wrap
+// set, overflowing content continues on extra rows instead, and the
+// opposite panel is padded with blank rows to stay in sync. o.Width is
+// not used; callers derive maxPanelWidth from it.
 // Returns the items and the maximum visible widths of the left and
 // right panels.
-func buildSBSItems(hunks []align.AnnotatedHunk, s Styles, maxPanelWidth int) (items []sbsItem, maxLeftVW, maxRightVW int) {
-	maxOld, maxNew := maxLineNumbers(hunks)
-	oldNumWidth := digitCount(maxOld)
-	newNumWidth := digitCount(maxNew)
+func buildSBSItems(hunks []align.AnnotatedHunk, s Styles, o Options, maxPanelWidth int) (items []sbsItem, maxLeftVW, maxRightVW int) {
+	oldNumWidth, newNumWidth := gutterWidths(hunks)
+	r := lineRenderer{s: s, o: o}
+
+	panel := func(numStr string, line styledLine, numWidth int) []string {
+		return sbsPanelRows(numStr, line, numWidth, maxPanelWidth, o.Wrap, s)
+	}
 
 	for i, h := range hunks {
 		if i > 0 {
 			items = append(items, sbsItem{separator: "\n"})
 		}
 
-		if h.Skipped > 0 {
-			noun := "lines"
-			if h.Skipped == 1 {
-				noun = "line"
-			}
-			line := fmt.Sprintf("~~~ %d %s skipped ~~~", h.Skipped, noun)
-			items = append(items, sbsItem{separator: s.Separator(line)})
+		switch {
+		case o.HunkHeaders:
+			items = append(items, sbsItem{header: s.Separator(hunkHeader(h))})
+		case h.Skipped > 0:
+			items = append(items, sbsItem{separator: s.Separator(skippedSeparator(h.Skipped))})
 		}
 
 		rows := walkHunk(h)
@@ -44,47 +48,62 @@ func buildSBSItems(hunks []align.AnnotatedHunk, s Styles, maxPanelWidth int) (it
 		newNum := h.NewStart
 
 		for _, row := range rows {
-			var left, right string
+			var left, right []string
 
 			switch {
 			case row.IsContext:
-				left = sbsPanelContent(s.LineNum(formatLineNum(oldNum, oldNumWidth)),
-					s.Plain("  "+row.Left.Content), maxPanelWidth)
-				right = sbsPanelContent(s.LineNum(formatLineNum(newNum, newNumWidth)),
-					s.Plain("  "+row.Right.Content), maxPanelWidth)
+				left = panel(s.LineNum(formatLineLabel(row.Left.OldLabel, oldNum, oldNumWidth)),
+					r.context(row.Left.Content), oldNumWidth)
+				right = panel(s.LineNum(formatLineLabel(row.Right.NewLabel, newNum, newNumWidth)),
+					r.context(row.Right.Content), newNumWidth)
 				oldNum++
 				newNum++
 
 			case row.IsPaired:
-				leftContent := RenderAnnotatedLine(row.Pair.Alignment.Old, s.Removed, s.RemovedEmph)
-				left = sbsPanelContent(s.LineNum(formatLineNum(oldNum, oldNumWidth)),
-					s.Removed("- ")+leftContent, maxPanelWidth)
-				rightContent := RenderAnnotatedLine(row.Pair.Alignment.New, s.Added, s.AddedEmph)
-				right = sbsPanelContent(s.LineNum(formatLineNum(newNum, newNumWidth)),
-					s.Added("+ ")+rightContent, maxPanelWidth)
+				left = panel(s.LineNum(formatLineLabel(row.Left.OldLabel, oldNum, oldNumWidth)),
+					r.annotated("- ", row.Pair.Alignment.Old, s.Removed, s.RemovedEmph), oldNumWidth)
+				right = panel(s.LineNum(formatLineLabel(row.Right.NewLabel, newNum, newNumWidth)),
+					r.annotated("+ ", row.Pair.Alignment.New, s.Added, s.AddedEmph), newNumWidth)
 				oldNum++
 				newNum++
 
 			case row.Left != nil:
-				left = sbsPanelContent(s.LineNum(formatLineNum(oldNum, oldNumWidth)),
-					s.Removed("- "+row.Left.Content), maxPanelWidth)
-				right = sbsEmptyContent(s, newNumWidth)
+				left = panel(s.LineNum(formatLineLabel(row.Left.OldLabel, oldNum, oldNumWidth)),
+					r.unpaired("- ", row.Left.Content, s.Removed), oldNumWidth)
+				right = []string{sbsEmptyContent(s, newNumWidth)}
 				oldNum++
 
 			case row.Right != nil:
-				left = sbsEmptyContent(s, oldNumWidth)
-				right = sbsPanelContent(s.LineNum(formatLineNum(newNum, newNumWidth)),
-					s.Added("+ "+row.Right.Content), maxPanelWidth)
+				left = []string{sbsEmptyContent(s, oldNumWidth)}
+				right = panel(s.LineNum(formatLineLabel(row.Right.NewLabel, newNum, newNumWidth)),
+					r.unpaired("+ ", row.Right.Content, s.Added), newNumWidth)
 				newNum++
 			}
 
-			if vw := visibleWidth(left); vw > maxLeftVW {
-				maxLeftVW = vw
+			if !row.IsContext {
+				if row.Left != nil && row.Left.NoNewline {
+					left = append(left, sbsPanelContent(blankLineNum(oldNumWidth), s.Separator(noNewlineNote), maxPanelWidth))
+				}
+				if row.Right != nil && row.Right.NoNewline {
+					right = append(right, sbsPanelContent(blankLineNum(newNumWidth), s.Separator(noNewlineNote), maxPanelWidth))
+				}
+			}
+
+			for len(left) < len(right) {
+				left = append(left, sbsBlankContent(oldNumWidth))
+			}
+			for len(right) < len(left) {
+				right = append(right, sbsBlankContent(newNumWidth))
 			}
-			if vw := visibleWidth(right); vw > maxRightVW {
-				maxRightVW = vw
+			for k := range left {
+				if vw := visibleWidth(left[k]); vw > maxLeftVW {
+					maxLeftVW = vw
+				}
+				if vw := visibleWidth(right[k]); vw > maxRightVW {
+					maxRightVW = vw
+				}
+				items = append(items, sbsItem{left: left[k], right: right[k]})
 			}
-			items = append(items, sbsItem{left: left, right: right})
 		}
 	}
 
@@ -92,9 +111,10 @@ func buildSBSItems(hunks []align.AnnotatedHunk, s Styles, maxPanelWidth int) (it
 }
 
 // MeasureSideBySideWidth returns the total terminal width needed to
-// render hunks in side-by-side mode without any truncation.
-func MeasureSideBySideWidth(hunks []align.AnnotatedHunk, s Styles) int {
-	_, maxLeftVW, maxRightVW := buildSBSItems(hunks, s, 0)
+// render hunks in side-by-side mode without any truncation. Only the
+// content-affecting settings of o (such as TabWidth) are used.
+func MeasureSideBySideWidth(hunks []align.AnnotatedHunk, s Styles, o Options) int {
+	_, maxLeftVW, maxRightVW := buildSBSItems(hunks, s, Options{TabWidth: o.TabWidth}, 0)
 	return maxLeftVW + 3 + maxRightVW
 }
 
@@ -102,21 +122,22 @@ func MeasureSideBySideWidth(hunks []align.AnnotatedHunk, s Styles) int {
 // right panel shows new text, separated by " │ ".
 //
 // Panels are sized to fit their content rather than always filling half
-// the terminal width. termWidth is used only as the truncation ceiling.
-func RenderSideBySide(hunks []align.AnnotatedHunk, s Styles, termWidth int) string {
+// the terminal width. o.Width is used only as the truncation (or, with
+// o.Wrap, wrapping) ceiling.
+func RenderSideBySide(hunks []align.AnnotatedHunk, s Styles, o Options) string {
 	if len(hunks) == 0 {
 		return ""
 	}
 
 	var maxPanelWidth int
-	if termWidth > 0 {
-		maxPanelWidth = (termWidth - 3) / 2 // 3 for " │ "
+	if o.Width > 0 {
+		maxPanelWidth = (o.Width - 3) / 2 // 3 for " │ "
 		if maxPanelWidth < 1 {
 			maxPanelWidth = 1
 		}
 	}
 
-	items, maxLeftVW, maxRightVW := buildSBSItems(hunks, s, maxPanelWidth)
+	items, maxLeftVW, maxRightVW := buildSBSItems(hunks, s, o, maxPanelWidth)
 
 	// Second pass: pad left panels to maxLeftVW, join with separator.
 	var b strings.Builder
@@ -124,6 +145,14 @@ func RenderSideBySide(hunks []align.AnnotatedHunk, s Styles, termWidth int) stri
 	totalContentWidth := maxLeftVW + 3 + maxRightVW
 
 	for _, item := range items {
+		if item.header != "" {
+			// Headers are left-aligned, like in the inline layouts.
+			if o.Width > 0 && visibleWidth(item.header) > o.Width {
+				item.header = truncateToWidth(item.header, o.Width-1) + "…"
+			}
+			b.WriteString(item.header + "\n")
+			continue
+		}
 		if item.separator != "" {
 			if item.separator == "\n" {
 				b.WriteString("\n")
@@ -157,12 +186,40 @@ func sbsPanelContent(numStr, content string, maxWidth int) string {
 	return truncateToWidth(inner, maxWidth-1) + "…"
 }
 
+// sbsPanelRows formats one panel's line as one or more rows. Without
+// wrap, or when the line fits, this is a single sbsPanelContent row.
+// With wrap, the body continues on rows whose gutter shows a "↪" marker
+// in place of the line number, indented to line up under the body.
+func sbsPanelRows(numStr string, line styledLine, numWidth, maxWidth int, wrap bool, s Styles) []string {
+	gutter := numStr + " │ "
+	prefixWidth := visibleWidth(line.prefix)
+	bodyWidth := maxWidth - visibleWidth(gutter) - prefixWidth
+	if !wrap || maxWidth <= 0 || bodyWidth < 1 || visibleWidth(line.body) <= bodyWidth {
+		return []string{sbsPanelContent(numStr, line.whole, maxWidth)}
+	}
+
+	chunks := wrapToWidth(line.body, bodyWidth)
+	rows := make([]string, len(chunks))
+	rows[0] = gutter + line.prefix + chunks[0]
+	marker := blankLineNum(numWidth-1) + s.LineNum("↪") + " │ " + strings.Repeat(" ", prefixWidth)
+	for k := 1; k < len(chunks); k++ {
+		rows[k] = marker + chunks[k]
+	}
+	return rows
+}
+
 // sbsEmptyContent creates a blank panel with a dimmed "~" placeholder.
 // No trailing padding.
 func sbsEmptyContent(s Styles, numWidth int) string {
 	return blankLineNum(numWidth) + " │ " + s.Separator("~")
 }
 
+// sbsBlankContent creates a filler row for a panel whose counterpart
+// wrapped onto more rows. No trailing padding.
+func sbsBlankContent(numWidth int) string {
+	return blankLineNum(numWidth) + " │"
+}
+
 // centerPad centers text by prepending spaces (approximate).
 func centerPad(s string, width int) string {
 	vl := visibleWidth(s)
@@ -20,7 +20,7 @@ func TestMeasureSideBySideWidth(t *testing.T) {
 		},
 	}
 	s := NoColorStyles()
-	width := MeasureSideBySideWidth(hunks, s)
+	width := MeasureSideBySideWidth(hunks, s, Options{})
 
 	// Build what we expect: "1 │   same line" on each side, plus " │ "
 	// Line num "1" = 1 char, " │ " = 3, "  same line" = 11 => panel = 15
@@ -44,7 +44,7 @@ func TestMeasureSideBySideWidthAsymmetric(t *testing.T) {
 		},
 	}
 	s := NoColorStyles()
-	width := MeasureSideBySideWidth(hunks, s)
+	width := MeasureSideBySideWidth(hunks, s, Options{})
 
 	// Left: "1 │ - short" = 1+3+7 = 11
 	// Right empty: "  │ ~" or "1 │ + this is a much longer line" = 1+3+28 = 32
@@ -61,7 +61,7 @@ func TestMeasureSideBySideWidthAsymmetric(t *testing.T) {
 }
 
 func TestRenderSideBySideEmpty(t *testing.T) {
-	result := RenderSideBySide(nil, NoColorStyles(), 80)
+	result := RenderSideBySide(nil, NoColorStyles(), Options{Width: 80})
 	if result != "" {
 		t.Errorf("expected empty, got %q", result)
 	}
@@ -78,7 +78,7 @@ func TestRenderSideBySideContextLines(t *testing.T) {
 			},
 		},
 	}
-	result := RenderSideBySide(hunks, NoColorStyles(), 80)
+	result := RenderSideBySide(hunks, NoColorStyles(), Options{Width: 80})
 	lines := strings.Split(strings.TrimRight(result, "\n"), "\n")
 	if len(lines) != 1 {
 		t.Fatalf("expected 1 line, got %d: %q", len(lines), result)
@@ -104,7 +104,7 @@ func TestRenderSideBySideUnpairedRemove(t *testing.T) {
 		},
 	}
 	s := markerStyles()
-	result := RenderSideBySide(hunks, s, 80)
+	result := RenderSideBySide(hunks, s, Options{Width: 80})
 	if !strings.Contains(result, "removed") {
 		t.Errorf("expected 'removed' in output, got:\n%s", result)
 	}
@@ -125,7 +125,7 @@ func TestRenderSideBySideUnpairedInsert(t *testing.T) {
 		},
 	}
 	s := markerStyles()
-	result := RenderSideBySide(hunks, s, 80)
+	result := RenderSideBySide(hunks, s, Options{Width: 80})
 	if !strings.Contains(result, "added") {
 		t.Errorf("expected 'added' in output, got:\n%s", result)
 	}
@@ -154,7 +154,7 @@ func TestRenderSideBySidePairedLines(t *testing.T) {
 		},
 	}
 	s := markerStyles()
-	result := RenderSideBySide(hunks, s, 80)
+	result := RenderSideBySide(hunks, s, Options{Width: 80})
 	lines := strings.Split(strings.TrimRight(result, "\n"), "\n")
 	if len(lines) != 1 {
 		t.Fatalf("expected 1 line (paired), got %d:\n%s", len(lines), result)
@@ -177,7 +177,7 @@ func TestRenderSideBySideHunkSeparator(t *testing.T) {
 		}}},
 	}
 	s := markerStyles()
-	result := RenderSideBySide(hunks, s, 80)
+	result := RenderSideBySide(hunks, s, Options{Width: 80})
 	if !strings.Contains(result, "5 lines skipped") {
 		t.Errorf("expected '5 lines skipped', got:\n%s", result)
 	}
@@ -194,7 +194,7 @@ func TestRenderSideBySideNarrowTerminal(t *testing.T) {
 			},
 		},
 	}
-	result := RenderSideBySide(hunks, NoColorStyles(), 20)
+	result := RenderSideBySide(hunks, NoColorStyles(), Options{Width: 20})
 	if result == "" {
 		t.Error("narrow terminal should still produce output")
 	}
@@ -212,7 +212,7 @@ func TestRenderSideBySideNoTruncationWhenZeroWidth(t *testing.T) {
 			},
 		},
 	}
-	result := RenderSideBySide(hunks, NoColorStyles(), 0)
+	result := RenderSideBySide(hunks, NoColorStyles(), Options{Width: 0})
 	if result == "" {
 		t.Fatal("zero width should still produce output")
 	}
@@ -236,7 +236,7 @@ func TestRenderSideBySideLongLineTruncated(t *testing.T) {
 			},
 		},
 	}
-	result := RenderSideBySide(hunks, NoColorStyles(), 80)
+	result := RenderSideBySide(hunks, NoColorStyles(), Options{Width: 80})
 	lines := strings.Split(strings.TrimRight(result, "\n"), "\n")
 	for _, line := range lines {
 		vw := visibleWidth(line)
@@ -416,3 +416,45 @@ func TestTruncateToWidthPreservesOSC(t *testing.T) {
 		t.Errorf("expected OSC preserved, got %q", result)
 	}
 }
+
+func TestRenderSideBySideWrapKeepsPanelsInSync(t *testing.T) {
+	long := strings.Repeat("word ", 20)
+	hunks := []align.AnnotatedHunk{
+		{
+			Hunk: diff.Hunk{
+				OldStart: 1, NewStart: 1,
+				Lines: []diff.Line{
+					{Kind: diff.OpEqual, Content: "short"},
+					{Kind: diff.OpInsert, Content: long},
+				},
+			},
+		},
+	}
+	result := RenderSideBySide(hunks, NoColorStyles(), Options{Width: 60, Wrap: true})
+	if strings.Contains(result, "…") {
+		t.Errorf("wrapped output should not truncate, got:\n%s", result)
+	}
+	lines := strings.Split(strings.TrimSuffix(result, "\n"), "\n")
+	if len(lines) < 4 {
+		t.Fatalf("expected continuation rows, got:\n%s", result)
+	}
+	for _, line := range lines {
+		if vw := visibleWidth(line); vw > 60 {
+			t.Errorf("line exceeds width (%d): %q", vw, line)
+		}
+		if strings.Count(line, "│") != 3 {
+			t.Errorf("every row should have both panels, got %q", line)
+		}
+	}
+	if !strings.Contains(result, "↪ │") {
+		t.Errorf("expected wrap marker in gutter, got:\n%s", result)
+	}
+	// All of the content survives, just split across rows
+	var words []string
+	for _, line := range lines[1:] {
+		words = append(words, strings.Fields(line[strings.LastIndex(line, "│ ")+len("│ "):])...)
+	}
+	if got := strings.Join(words, " "); got != "+ "+strings.TrimSpace(long) {
+		t.Errorf("content lost while wrapping, got %q", got)
+	}
+}
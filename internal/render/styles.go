@@ -12,6 +12,9 @@ type Styles struct {
 	LineNum     func(string) string // line numbers in gutter
 	Separator   func(string) string // hunk separator text
 	Plain       func(string) string // default / context text
+	Whitespace  func(string) string // trailing whitespace in changed lines
+
+	markers bool // emphasis formatters add markers; see WithEmphasisMarkers
 }
 
 // NoColorStyles returns Styles where every formatter is the identity
@@ -26,5 +29,18 @@ func NoColorStyles() Styles {
 		LineNum:     id,
 		Separator:   id,
 		Plain:       id,
+		Whitespace:  id,
 	}
 }
+
+// WithEmphasisMarkers returns a copy of s whose emphasis formatters
+// also wrap changed segments in git word-diff style markers:
+// "[-removed-]" and "{+added+}". This keeps word-level emphasis
+// visible when color is unavailable.
+func WithEmphasisMarkers(s Styles) Styles {
+	removedEmph, addedEmph := s.RemovedEmph, s.AddedEmph
+	s.RemovedEmph = func(t string) string { return removedEmph("[-" + t + "-]") }
+	s.AddedEmph = func(t string) string { return addedEmph("{+" + t + "+}") }
+	s.markers = true
+	return s
+}
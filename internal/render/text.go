@@ -0,0 +1,214 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/amterp/go-delta/internal/align"
+	"github.com/mattn/go-runewidth"
+)
+
+// WhitespaceMode controls where whitespace is drawn with visible
+// symbols.
+type WhitespaceMode int
+
+const (
+	WhitespaceNone       WhitespaceMode = iota // whitespace is drawn as-is
+	WhitespaceChanged                          // in removed and added lines
+	WhitespaceEmphasized                       // only inside emphasized segments
+)
+
+// lineRenderer renders the body of each line, applying the display
+// settings shared by all layouts: tab expansion, control characters,
+// and whitespace visualization.
+type lineRenderer struct {
+	s Styles
+	o Options
+}
+
+// context renders an unchanged line.
+func (r lineRenderer) context(content string) styledLine {
+	return r.unstyled("  ", content, r.s.Plain)
+}
+
+// unpaired renders a removed or added line with no counterpart, entirely
+// in style.
+func (r lineRenderer) unpaired(marker, content string, style func(string) string) styledLine {
+	if r.o.Whitespace == WhitespaceNone {
+		return r.unstyled(marker, content, style)
+	}
+	prefix := style(marker)
+	body := r.body([]segment{{text: content, style: style}}, true)
+	return styledLine{whole: prefix + body, prefix: prefix, body: body}
+}
+
+// annotated renders a paired line with emphasis on changed tokens.
+func (r lineRenderer) annotated(marker string, tokens []align.AlignedToken, baseStyle, emphStyle func(string) string) styledLine {
+	prefix := baseStyle(marker)
+	body := r.body(annotatedSegments(tokens, baseStyle, emphStyle), true)
+	return styledLine{whole: prefix + body, prefix: prefix, body: body}
+}
+
+// unstyled renders a line in a single style, with marker and content
+// styled together.
+func (r lineRenderer) unstyled(marker, content string, style func(string) string) styledLine {
+	content = newLineFormatter(r.o).format(content, false)
+	return styledLine{
+		whole:  style(marker + content),
+		prefix: style(marker),
+		body:   style(content),
+	}
+}
+
+// body formats and styles a line's segments. In changed lines, whitespace
+// is visualized according to the whitespace mode, and trailing
+// whitespace (before any final \r) is drawn in the Whitespace style.
+func (r lineRenderer) body(segs []segment, changed bool) string {
+	f := newLineFormatter(r.o)
+	mode := r.o.Whitespace
+	if !changed {
+		mode = WhitespaceNone
+	}
+
+	var raw strings.Builder
+	for _, seg := range segs {
+		raw.WriteString(seg.text)
+	}
+	trailStart, trailEnd := 0, 0
+	if mode != WhitespaceNone {
+		trailStart, trailEnd = trailingWhitespace(raw.String())
+	}
+
+	trailStyle := r.s.Whitespace
+	var b strings.Builder
+	offset := 0
+	for _, seg := range segs {
+		// With emphasis markers, an emphasized segment is styled as a
+		// whole, around the style of its trailing whitespace, so the
+		// markers enclose all of it.
+		whole := seg.emph && r.s.markers
+		var emph strings.Builder
+		text := seg.text
+		for text != "" {
+			// Split the segment where it enters or leaves the trailing
+			// whitespace, since that part gets its own style.
+			n := len(text)
+			trailing := offset >= trailStart && offset < trailEnd
+			switch {
+			case trailing:
+				n = min(n, trailEnd-offset)
+			case offset < trailStart:
+				n = min(n, trailStart-offset)
+			}
+
+			visible := trailing || mode == WhitespaceChanged ||
+				(mode == WhitespaceEmphasized && seg.emph)
+			part := f.format(text[:n], visible)
+			switch {
+			case trailing && trailStyle != nil && whole:
+				emph.WriteString(trailStyle(part))
+			case whole:
+				emph.WriteString(part)
+			case trailing && trailStyle != nil:
+				b.WriteString(trailStyle(part))
+			default:
+				b.WriteString(seg.style(part))
+			}
+			text = text[n:]
+			offset += n
+		}
+		if emph.Len() > 0 {
+			b.WriteString(seg.style(emph.String()))
+		}
+	}
+	return b.String()
+}
+
+// trailingWhitespace returns the byte range of the spaces and tabs at the
+// end of line, ignoring a final \r (which belongs to the line ending).
+func trailingWhitespace(line string) (start, end int) {
+	end = len(strings.TrimSuffix(line, "\r"))
+	start = len(strings.TrimRight(line[:end], " \t"))
+	return start, end
+}
+
+// lineFormatter turns line content into display text. Tabs expand to
+// the next tab stop, measured from the start of the content rather than
+// the start of the terminal row, so the gutter doesn't shift them.
+// Other C0 control characters, which would otherwise move the cursor or
+// corrupt the terminal, become their Unicode control pictures (e.g. \r
+// becomes ␍). That includes ESC, so escape sequences in the input show
+// as text, such as ␛[2J, rather than clearing the screen. Bytes that
+// aren't valid UTF-8 are shown escaped as \xNN.
+//
+// A line may be formatted in several segments (e.g. one per emphasis
+// group); the formatter carries the column across them.
+type lineFormatter struct {
+	tabWidth int // <= 0 leaves tabs unexpanded
+	col      int
+}
+
+func newLineFormatter(o Options) *lineFormatter {
+	return &lineFormatter{tabWidth: o.TabWidth}
+}
+
+// format converts the next segment of the line to display text. With
+// visibleWS set, spaces are drawn as "·" and tabs as "→" followed by
+// padding to the tab stop. A nil formatter returns text unchanged.
+func (f *lineFormatter) format(text string, visibleWS bool) string {
+	if f == nil {
+		return text
+	}
+	var b strings.Builder
+	for i, r := range text {
+		if r == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(text[i:]); size == 1 {
+				fmt.Fprintf(&b, "\\x%02x", text[i])
+				f.col += 4
+				continue
+			}
+		}
+
+		switch {
+		case r == '\t' && f.tabWidth > 0:
+			n := f.tabWidth - f.col%f.tabWidth
+			if visibleWS {
+				b.WriteString("→" + strings.Repeat(" ", n-1))
+			} else {
+				b.WriteString(strings.Repeat(" ", n))
+			}
+			f.col += n
+		case r == '\t' && visibleWS:
+			b.WriteRune('→')
+			f.col++
+		case r == '\t':
+			b.WriteRune(r)
+		case r == ' ' && visibleWS:
+			b.WriteRune('·')
+			f.col++
+		case isControl(r):
+			b.WriteRune(controlPicture(r))
+			f.col++
+		default:
+			b.WriteRune(r)
+			f.col += runewidth.RuneWidth(r)
+		}
+	}
+	return b.String()
+}
+
+// isControl reports whether r is a C0 control character or DEL.
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
+
+// controlPicture returns the visible symbol for a control character
+// from the Unicode Control Pictures block: U+2400 + c for C0, and ␡ for
+// DEL.
+func controlPicture(r rune) rune {
+	if r == 0x7f {
+		return '␡'
+	}
+	return 0x2400 + r
+}
@@ -0,0 +1,190 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/amterp/go-delta/internal/align"
+	"github.com/amterp/go-delta/internal/diff"
+)
+
+func TestLineFormatterExpandsTabs(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"\tx", "    x"},
+		{"a\tb", "a   b"},
+		{"abcd\te", "abcd    e"},
+		{"世\tx", "世  x"},
+	}
+	for _, tt := range tests {
+		f := &lineFormatter{tabWidth: 4}
+		if got := f.format(tt.input, false); got != tt.want {
+			t.Errorf("format(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLineFormatterTracksColumnAcrossSegments(t *testing.T) {
+	f := &lineFormatter{tabWidth: 4}
+	got := f.format("ab", false) + f.format("\tc", false)
+	if got != "ab  c" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestLineFormatterControlPictures(t *testing.T) {
+	f := &lineFormatter{tabWidth: 4}
+	got := f.format("a\rb\x00c\x7f", false)
+	if got != "a␍b␀c␡" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestLineFormatterShowsEscapes(t *testing.T) {
+	f := &lineFormatter{tabWidth: 4}
+	got := f.format("\x1b[31mab\x1b[0m\tc", false)
+	if got != "␛[31mab␛[0m c" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestLineFormatterNilIsIdentity(t *testing.T) {
+	var f *lineFormatter
+	if got := f.format("a\tb\r", false); got != "a\tb\r" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRenderSideBySideTabsAlign(t *testing.T) {
+	hunks := []align.AnnotatedHunk{
+		{
+			Hunk: diff.Hunk{
+				OldStart: 1, NewStart: 1,
+				Lines: []diff.Line{
+					{Kind: diff.OpEqual, Content: "\tx := 1"},
+					{Kind: diff.OpDelete, Content: "\treturn x"},
+					{Kind: diff.OpInsert, Content: "\treturn y"},
+				},
+			},
+		},
+	}
+	o := Options{Width: 80, TabWidth: 4}
+	result := RenderSideBySide(hunks, NoColorStyles(), o)
+	if strings.Contains(result, "\t") {
+		t.Errorf("tabs should be expanded, got:\n%q", result)
+	}
+	lines := strings.Split(strings.TrimSuffix(result, "\n"), "\n")
+	// The panel separator is the second "│" on each row.
+	sepCol := func(line string) int {
+		first := strings.Index(line, "│")
+		second := first + len("│") + strings.Index(line[first+len("│"):], "│")
+		return visibleWidth(line[:second])
+	}
+	for _, line := range lines[1:] {
+		if sepCol(line) != sepCol(lines[0]) {
+			t.Errorf("panel separator misaligned:\n%s", result)
+		}
+	}
+	widest := 0
+	for _, line := range lines {
+		widest = max(widest, visibleWidth(line))
+	}
+	if w := MeasureSideBySideWidth(hunks, NoColorStyles(), o); w != widest {
+		t.Errorf("measured width %d doesn't match rendered width %d", w, widest)
+	}
+}
+
+func TestLineFormatterVisibleWhitespace(t *testing.T) {
+	f := &lineFormatter{tabWidth: 4}
+	got := f.format("a b\tc\r", true)
+	if want := "a·b→c␍"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTrailingWhitespace(t *testing.T) {
+	tests := []struct {
+		line       string
+		start, end int
+	}{
+		{"abc", 3, 3},
+		{"abc  ", 3, 5},
+		{"abc \t\r", 3, 5},
+		{"   ", 0, 3},
+		{"", 0, 0},
+	}
+	for _, tt := range tests {
+		start, end := trailingWhitespace(tt.line)
+		if start != tt.start || end != tt.end {
+			t.Errorf("trailingWhitespace(%q) = %d, %d, want %d, %d", tt.line, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestLineRendererWhitespaceModes(t *testing.T) {
+	s := markerStyles()
+	segs := []segment{
+		{text: "a b", style: s.Removed},
+		{text: " c", style: s.RemovedEmph, emph: true},
+		{text: "  ", style: s.Removed},
+	}
+	tests := []struct {
+		mode WhitespaceMode
+		want string
+	}{
+		{WhitespaceNone, "[R:a b][RE: c][R:  ]"},
+		{WhitespaceChanged, "[R:a·b][RE:·c][W:··]"},
+		{WhitespaceEmphasized, "[R:a b][RE:·c][W:··]"},
+	}
+	for _, tt := range tests {
+		r := lineRenderer{s: s, o: Options{Whitespace: tt.mode}}
+		if got := r.body(segs, true); got != tt.want {
+			t.Errorf("mode %d: got %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestLineRendererContextWhitespaceHidden(t *testing.T) {
+	r := lineRenderer{s: markerStyles(), o: Options{Whitespace: WhitespaceChanged}}
+	if got := r.context("a b  ").whole; got != "  a b  " {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestLineRendererTrailingSplitsSegment(t *testing.T) {
+	s := markerStyles()
+	r := lineRenderer{s: s, o: Options{Whitespace: WhitespaceEmphasized}}
+	got := r.unpaired("+ ", "x \t", s.Added).whole
+	if want := "[A:+ ][A:x][W:·→]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLineFormatterEscapesInvalidUTF8(t *testing.T) {
+	f := &lineFormatter{tabWidth: 4}
+	got := f.format("a\xff\tb\uFFFD", false)
+	if want := "a\\xff   b\uFFFD"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLineRendererTrailingWhitespaceKeepsMarkers(t *testing.T) {
+	s := WithEmphasisMarkers(markerStyles())
+	segs := []segment{
+		{text: "x", style: s.Added},
+		{text: " y  ", style: s.AddedEmph, emph: true},
+	}
+	r := lineRenderer{s: s, o: Options{Whitespace: WhitespaceChanged}}
+	if got, want := r.body(segs, true), "[A:x][AE:{+·y[W:··]+}]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Without color, a change to trailing whitespace alone still shows.
+	s = WithEmphasisMarkers(NoColorStyles())
+	r = lineRenderer{s: s, o: Options{Whitespace: WhitespaceChanged}}
+	if got, want := r.body([]segment{{text: "x", style: s.Removed}, {text: " ", style: s.RemovedEmph, emph: true}}, true), "x[-·-]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
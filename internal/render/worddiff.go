@@ -0,0 +1,142 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/amterp/go-delta/internal/align"
+	"github.com/amterp/go-delta/internal/diff"
+)
+
+// RenderWordDiff produces a single-stream diff in the style of
+// git diff --word-diff. Each paired line is rendered once, with removed
+// tokens styled RemovedEmph and added tokens styled AddedEmph in place.
+// Unpaired lines fall back to the inline "-"/"+" rows. Wrapping works
+// as in RenderInline.
+func RenderWordDiff(hunks []align.AnnotatedHunk, s Styles, o Options) string {
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	g := newInlineGutter(hunks)
+	w := newInlineWriter(g, s, o)
+	r := lineRenderer{s: s, o: o}
+
+	for i, h := range hunks {
+		if i > 0 {
+			w.b.WriteString("\n")
+		}
+
+		writeHunkSeparator(&w.b, h, s, o)
+
+		oldNum := h.OldStart
+		newNum := h.NewStart
+
+		for _, row := range walkHunk(h) {
+			switch {
+			case row.IsContext:
+				gutter := g.format(diff.OpEqual, row.Left, oldNum, newNum, s)
+				w.writeRow(gutter, r.context(row.Left.Content), s.LineNum)
+				oldNum++
+				newNum++
+
+			case row.IsPaired:
+				// The line exists on both sides, so it gets both numbers,
+				// or labels.
+				both := diff.Line{OldLabel: row.Left.OldLabel, NewLabel: row.Right.NewLabel}
+				gutter := g.format(diff.OpEqual, &both, oldNum, newNum, s)
+				prefix := s.Separator("~ ")
+				body := r.body(mergedSegments(mergeAlignment(row.Pair.Alignment), s), true)
+				w.writeRow(gutter, styledLine{whole: prefix + body, prefix: prefix, body: body}, s.Separator)
+				// The merged row doesn't say which side lacks the
+				// newline, so the note takes that side's color.
+				w.writeNoNewline(row.Left, s.Removed)
+				w.writeNoNewline(row.Right, s.Added)
+				oldNum++
+				newNum++
+
+			case row.Left != nil:
+				gutter := g.format(diff.OpDelete, row.Left, oldNum, newNum, s)
+				w.writeRow(gutter, r.unpaired("- ", row.Left.Content, s.Removed), s.Removed)
+				w.writeNoNewline(row.Left, s.Separator)
+				oldNum++
+
+			case row.Right != nil:
+				gutter := g.format(diff.OpInsert, row.Right, oldNum, newNum, s)
+				w.writeRow(gutter, r.unpaired("+ ", row.Right.Content, s.Added), s.Added)
+				w.writeNoNewline(row.Right, s.Separator)
+				newNum++
+			}
+		}
+	}
+
+	return w.b.String()
+}
+
+// mergeAlignment interleaves the two sides of an alignment into one
+// token stream. Between consecutive matched tokens, all deleted tokens
+// come first, then all inserted tokens, so each change reads as
+// "old then new".
+func mergeAlignment(a align.Alignment) []align.AlignedToken {
+	merged := make([]align.AlignedToken, 0, len(a.Old)+len(a.New))
+	i, j := 0, 0
+	for i < len(a.Old) || j < len(a.New) {
+		for i < len(a.Old) && a.Old[i].Op == align.AlignDelete {
+			merged = append(merged, a.Old[i])
+			i++
+		}
+		for j < len(a.New) && a.New[j].Op == align.AlignInsert {
+			merged = append(merged, a.New[j])
+			j++
+		}
+		if i < len(a.Old) && j < len(a.New) {
+			// both sides are at a matched token
+			merged = append(merged, a.New[j])
+			i++
+			j++
+		} else {
+			// Matches always come in pairs, so one side running out
+			// means the other only has changes left, and those were
+			// consumed above.
+			break
+		}
+	}
+	return merged
+}
+
+// mergedSegments groups a merged token stream into segments: matched
+// tokens plain, deleted tokens RemovedEmph, inserted tokens AddedEmph.
+// Consecutive tokens with the same op are grouped, as in
+// RenderAnnotatedLine.
+func mergedSegments(tokens []align.AlignedToken, s Styles) []segment {
+	var segs []segment
+	var buf strings.Builder
+	var current align.AlignOp
+
+	flush := func() {
+		if buf.Len() == 0 {
+			return
+		}
+		seg := segment{text: buf.String(), style: s.Plain, emph: true}
+		switch current {
+		case align.AlignDelete:
+			seg.style = s.RemovedEmph
+		case align.AlignInsert:
+			seg.style = s.AddedEmph
+		default:
+			seg.emph = false
+		}
+		segs = append(segs, seg)
+		buf.Reset()
+	}
+
+	for _, at := range tokens {
+		if at.Op != current {
+			flush()
+		}
+		current = at.Op
+		buf.WriteString(at.Token.Text)
+	}
+	flush()
+
+	return segs
+}
@@ -0,0 +1,80 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/amterp/go-delta/internal/align"
+	"github.com/amterp/go-delta/internal/diff"
+)
+
+func pairedHunk(old, new string) align.AnnotatedHunk {
+	alignment := align.Align(align.Tokenize(old), align.Tokenize(new))
+	return align.AnnotatedHunk{
+		Hunk: diff.Hunk{
+			OldStart: 1, NewStart: 1,
+			Lines: []diff.Line{
+				{Kind: diff.OpDelete, Content: old},
+				{Kind: diff.OpInsert, Content: new},
+			},
+		},
+		Pairs: []align.LinePair{{OldIdx: 0, NewIdx: 1, Alignment: alignment}},
+	}
+}
+
+func TestRenderWordDiffEmpty(t *testing.T) {
+	if result := RenderWordDiff(nil, NoColorStyles(), Options{}); result != "" {
+		t.Errorf("expected empty, got %q", result)
+	}
+}
+
+func TestRenderWordDiffPairedLineRenderedOnce(t *testing.T) {
+	hunks := []align.AnnotatedHunk{pairedHunk(`"age": 30,`, `"age": 31,`)}
+	result := RenderWordDiff(hunks, WithEmphasisMarkers(NoColorStyles()), Options{})
+	want := "1 1 │ ~ \"age\": [-30-]{+31+},\n"
+	if result != want {
+		t.Errorf("got %q, want %q", result, want)
+	}
+}
+
+func TestRenderWordDiffStyles(t *testing.T) {
+	hunks := []align.AnnotatedHunk{pairedHunk("hello world", "hello earth")}
+	result := RenderWordDiff(hunks, markerStyles(), Options{})
+	if !strings.Contains(result, "[RE:world][AE:earth]") {
+		t.Errorf("expected removed then added emphasis, got:\n%s", result)
+	}
+	if strings.Contains(result, "[R:") || strings.Contains(result, "[A:") {
+		t.Errorf("paired line should not use base removed/added styles, got:\n%s", result)
+	}
+}
+
+func TestRenderWordDiffUnpairedFallsBack(t *testing.T) {
+	hunks := []align.AnnotatedHunk{
+		{
+			Hunk: diff.Hunk{
+				OldStart: 1, NewStart: 1,
+				Lines: []diff.Line{
+					{Kind: diff.OpDelete, Content: "hello"},
+					{Kind: diff.OpInsert, Content: "world"},
+				},
+			},
+		},
+	}
+	result := RenderWordDiff(hunks, markerStyles(), Options{})
+	if !strings.Contains(result, "[R:- hello]") || !strings.Contains(result, "[A:+ world]") {
+		t.Errorf("expected inline fallback rows, got:\n%s", result)
+	}
+}
+
+func TestMergeAlignmentOrdersDeletesBeforeInserts(t *testing.T) {
+	a := align.Align(align.Tokenize("a b c"), align.Tokenize("a x y c"))
+	var texts []string
+	for _, at := range mergeAlignment(a) {
+		texts = append(texts, at.Token.Text)
+	}
+	got := strings.Join(texts, "|")
+	want := "a| |b|x| |y| |c"
+	if got != want {
+		t.Errorf("merged = %q, want %q", got, want)
+	}
+}
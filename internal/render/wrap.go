@@ -0,0 +1,190 @@
+package render
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/amterp/go-delta/internal/align"
+	"github.com/mattn/go-runewidth"
+)
+
+// wrapToWidth splits a string into chunks of at most width visible
+// columns. Breaks prefer to follow whitespace, then fall on any token
+// boundary (as produced by align.Tokenize), and only split a token when
+// it is wider than a whole row.
+//
+// ANSI escape sequences are preserved, and SGR styling that is active at
+// a break is closed with a reset at the end of the chunk and re-applied
+// at the start of the next, so each chunk renders correctly on its own
+// row. A wide character that doesn't fit moves to the next chunk,
+// leaving the current one short.
+func wrapToWidth(s string, width int) []string {
+	text, widths := visibleRunes(s)
+	return splitAtRunes(s, planWraps(text, widths, width))
+}
+
+// visibleRunes returns the runes of s outside ANSI escape sequences,
+// with their display widths.
+func visibleRunes(s string) (text []rune, widths []int) {
+	state := ansiNone
+	for _, r := range s {
+		prev := state
+		state = ansiNext(state, r)
+		if state != ansiNone || prev != ansiNone {
+			continue
+		}
+		text = append(text, r)
+		widths = append(widths, runewidth.RuneWidth(r))
+	}
+	return text, widths
+}
+
+// planWraps chooses where to break a line of visible runes so that each
+// chunk fits in width columns. It returns the rune indices at which new
+// chunks start.
+func planWraps(text []rune, widths []int, width int) []int {
+	// soft[i] marks a token boundary before rune i; preferred[i] marks
+	// one that directly follows whitespace.
+	soft := make([]bool, len(text)+1)
+	preferred := make([]bool, len(text)+1)
+	idx := 0
+	prevSpace := false
+	for _, tok := range align.Tokenize(string(text)) {
+		if idx > 0 {
+			soft[idx] = true
+			preferred[idx] = prevSpace
+		}
+		r, _ := utf8.DecodeRuneInString(tok.Text)
+		prevSpace = unicode.IsSpace(r)
+		idx += utf8.RuneCountInString(tok.Text)
+	}
+
+	var cuts []int
+	start := 0
+	for {
+		// end is the first rune that doesn't fit on this row
+		end, used := start, 0
+		for end < len(text) && used+widths[end] <= width {
+			used += widths[end]
+			end++
+		}
+		if end >= len(text) {
+			return cuts
+		}
+		if end == start {
+			end = start + 1 // a single rune wider than the row
+		}
+
+		cut := end
+		if c := lastMarked(preferred, start, end); c > 0 {
+			cut = c
+		} else if c := lastMarked(soft, start, end); c > 0 {
+			cut = c
+		}
+		cuts = append(cuts, cut)
+		start = cut
+	}
+}
+
+// lastMarked returns the largest i in (start, end] with marks[i] set,
+// or 0 if there is none.
+func lastMarked(marks []bool, start, end int) int {
+	for i := end; i > start; i-- {
+		if marks[i] {
+			return i
+		}
+	}
+	return 0
+}
+
+// splitAtRunes splits s before each of the given visible rune indices,
+// carrying SGR state across the splits (see wrapToWidth).
+func splitAtRunes(s string, cuts []int) []string {
+	var chunks []string
+	var b strings.Builder
+	var esc strings.Builder
+	var active []string // SGR sequences in effect since the last reset
+	runeIdx := 0
+	state := ansiNone
+
+	for _, r := range s {
+		prevState := state
+		state = ansiNext(state, r)
+
+		if state != ansiNone || prevState != ansiNone {
+			esc.WriteRune(r)
+			if state == ansiNone {
+				// sequence complete
+				seq := esc.String()
+				esc.Reset()
+				active = trackSGR(active, seq)
+				b.WriteString(seq)
+			}
+			continue
+		}
+
+		if len(cuts) > 0 && runeIdx == cuts[0] {
+			cuts = cuts[1:]
+			if len(active) > 0 {
+				b.WriteString("\x1b[0m")
+			}
+			chunks = append(chunks, b.String())
+			b.Reset()
+			for _, seq := range active {
+				b.WriteString(seq)
+			}
+		}
+		b.WriteRune(r)
+		runeIdx++
+	}
+	b.WriteString(esc.String()) // unterminated escape at end of input
+	chunks = append(chunks, b.String())
+	return chunks
+}
+
+// trackSGR updates the list of active SGR sequences after seeing seq.
+// A full reset (ESC[m or a 0 parameter) clears the list; non-SGR
+// sequences leave it unchanged.
+func trackSGR(active []string, seq string) []string {
+	if !strings.HasPrefix(seq, "\x1b[") || !strings.HasSuffix(seq, "m") {
+		return active
+	}
+	params := strings.Split(seq[2:len(seq)-1], ";")
+	resetAt := -1
+	for i := 0; i < len(params); i++ {
+		switch params[i] {
+		case "", "0":
+			resetAt = i
+		case "38", "48", "58":
+			// extended color: skip its arguments, which may contain 0
+			if i+1 < len(params) && params[i+1] == "5" {
+				i += 2
+			} else if i+1 < len(params) && params[i+1] == "2" {
+				i += 4
+			}
+		}
+	}
+	if resetAt < 0 {
+		return append(active, seq)
+	}
+	// After a full reset, parameters that only switch attributes off
+	// (like the "0;27" the color library emits) leave nothing active.
+	for _, p := range params[resetAt+1:] {
+		if !isSGROff(p) {
+			return []string{seq}
+		}
+	}
+	return nil
+}
+
+// isSGROff reports whether an SGR parameter only turns an attribute
+// off: 22-29 (intensity, italic, underline, blink, reverse, conceal,
+// strikethrough) and 39/49 (default colors).
+func isSGROff(p string) bool {
+	switch p {
+	case "22", "23", "24", "25", "26", "27", "28", "29", "39", "49":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWrapToWidthPlain(t *testing.T) {
+	got := wrapToWidth("abcdefgh", 3)
+	want := []string{"abc", "def", "gh"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWrapToWidthCarriesStyleAcrossBreak(t *testing.T) {
+	s := "ab\x1b[31;7mcdef\x1b[0mg"
+	got := wrapToWidth(s, 4)
+	want := []string{"ab\x1b[31;7mcd\x1b[0m", "\x1b[31;7mef\x1b[0mg"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	for _, chunk := range got {
+		if vw := visibleWidth(chunk); vw > 4 {
+			t.Errorf("chunk %q has width %d", chunk, vw)
+		}
+	}
+}
+
+func TestWrapToWidthWideChar(t *testing.T) {
+	got := wrapToWidth("a世界", 2)
+	want := []string{"a", "世", "界"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTrackSGR(t *testing.T) {
+	active := trackSGR(nil, "\x1b[31m")
+	active = trackSGR(active, "\x1b[7m")
+	if len(active) != 2 {
+		t.Fatalf("expected 2 active sequences, got %q", active)
+	}
+	if active = trackSGR(active, "\x1b[0;32m"); len(active) != 1 {
+		t.Errorf("reset followed by params should keep only that sequence, got %q", active)
+	}
+	if got := trackSGR(active, "\x1b[0;27m"); got != nil {
+		t.Errorf("reset followed by off-only params should clear, got %q", got)
+	}
+	if active = trackSGR(active, "\x1b[0m"); active != nil {
+		t.Errorf("full reset should clear, got %q", active)
+	}
+	if active = trackSGR(nil, "\x1b[38;2;255;0;0m"); len(active) != 1 {
+		t.Errorf("zero color components are not resets, got %q", active)
+	}
+	if active = trackSGR([]string{"\x1b[2m"}, "\x1b[2K"); len(active) != 1 {
+		t.Errorf("non-SGR sequences should be ignored, got %q", active)
+	}
+}
+
+func TestWrapToWidthPrefersWhitespace(t *testing.T) {
+	got := wrapToWidth("hello wonderful world", 12)
+	want := []string{"hello ", "wonderful ", "world"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWrapToWidthFallsBackToTokenBoundary(t *testing.T) {
+	got := wrapToWidth("foo.bar.baz", 9)
+	want := []string{"foo.bar.", "baz"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWrapToWidthBoundariesIgnoreEscapes(t *testing.T) {
+	s := "\x1b[31mhello\x1b[0m \x1b[32mworld\x1b[0m"
+	got := wrapToWidth(s, 8)
+	if len(got) != 2 || visibleWidth(got[0]) != 6 || visibleWidth(got[1]) != 5 {
+		t.Errorf("expected break after the space, got %q", got)
+	}
+}
@@ -0,0 +1,99 @@
+// Package structdiff holds what the structural diffs (JSON, YAML, XML,
+// tables, records) share: matching elements by ID, and building the
+// lines of a diff whose changed lines are labeled and paired by the
+// caller rather than by a line diff.
+package structdiff
+
+import (
+	"github.com/amterp/go-delta/internal/align"
+	"github.com/amterp/go-delta/internal/diff"
+)
+
+// Printed is a line of a pretty-printed value and the path of the value
+// it belongs to, such as $.address.zip, for structural diffs that label
+// changed lines with paths instead of line numbers.
+type Printed struct {
+	Text, Path string
+}
+
+// Builder collects the lines of a structural diff.
+type Builder struct {
+	Lines  []diff.Line
+	Pairs  []diff.Pair             // removed and added lines showing the same value
+	aligns map[int]align.Alignment // known emphasis, by index of a pair's removed line
+}
+
+// Emit adds a line, labeling it with its path if it is changed.
+func (b *Builder) Emit(kind diff.OpKind, p Printed) {
+	l := diff.Line{Kind: kind, Content: p.Text}
+	switch kind {
+	case diff.OpDelete:
+		l.OldLabel = p.Path
+	case diff.OpInsert:
+		l.NewLabel = p.Path
+	}
+	b.Lines = append(b.Lines, l)
+}
+
+// EmitAll adds lines of the same kind.
+func (b *Builder) EmitAll(kind diff.OpKind, ps []Printed) {
+	for _, p := range ps {
+		b.Emit(kind, p)
+	}
+}
+
+// EmitPair adds a removed and an added line showing the same value.
+func (b *Builder) EmitPair(old, new Printed) {
+	b.Pairs = append(b.Pairs, diff.Pair{Old: len(b.Lines), New: len(b.Lines) + 1})
+	b.Emit(diff.OpDelete, old)
+	b.Emit(diff.OpInsert, new)
+}
+
+// EmitLine adds a line that may print differently on each side: as
+// unchanged if the texts are equal, otherwise as a pair.
+func (b *Builder) EmitLine(old, new Printed) {
+	if old.Text == new.Text {
+		b.Emit(diff.OpEqual, new)
+		return
+	}
+	b.EmitPair(old, new)
+}
+
+// AddPair adds a removed and an added line built from tokens marked
+// with their changes, such as table rows whose changed cells are known.
+func (b *Builder) AddPair(old, new []align.AlignedToken, oldLabel, newLabel string) {
+	if b.aligns == nil {
+		b.aligns = map[int]align.Alignment{}
+	}
+	b.aligns[len(b.Lines)] = align.Alignment{Old: old, New: new}
+	b.EmitPair(Printed{Text: Text(old), Path: oldLabel}, Printed{Text: Text(new), Path: newLabel})
+}
+
+// Annotate pairs the lines of hunks computed from Lines as Pairs says.
+// Pairs added with AddPair keep the emphasis they were given; the others
+// are aligned token by token.
+func (b *Builder) Annotate(hunks []diff.Hunk) []align.AnnotatedHunk {
+	annotated := make([]align.AnnotatedHunk, len(hunks))
+	start := 0 // index of the hunk's first line in Lines
+	p := 0
+	for i, h := range hunks {
+		start += h.Skipped
+		end := start + len(h.Lines)
+		annotated[i] = align.AnnotatedHunk{Hunk: h}
+		for ; p < len(b.Pairs) && b.Pairs[p].New < end; p++ {
+			pair := b.Pairs[p]
+			if pair.Old < start {
+				continue
+			}
+			lp := align.LinePair{OldIdx: pair.Old - start, NewIdx: pair.New - start}
+			if a, ok := b.aligns[pair.Old]; ok {
+				lp.Alignment = a
+			} else {
+				lp.Alignment = align.Align(align.Tokenize(h.Lines[lp.OldIdx].Content), align.Tokenize(h.Lines[lp.NewIdx].Content))
+			}
+			annotated[i].Pairs = append(annotated[i].Pairs, lp)
+		}
+		start = end
+	}
+	return annotated
+}
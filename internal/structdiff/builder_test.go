@@ -0,0 +1,63 @@
+package structdiff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/amterp/go-delta/internal/align"
+	"github.com/amterp/go-delta/internal/diff"
+)
+
+func TestBuilder(t *testing.T) {
+	var b Builder
+	b.Emit(diff.OpEqual, Printed{Text: "{", Path: "$"})
+	b.EmitLine(Printed{Text: `  "a": 1`, Path: "$.a"}, Printed{Text: `  "a": 1`, Path: "$.a"})
+	b.EmitLine(Printed{Text: `  "b": 1`, Path: "$.b"}, Printed{Text: `  "b": 2`, Path: "$.b"})
+	b.EmitAll(diff.OpInsert, []Printed{{Text: `  "c": 3`, Path: "$.c"}})
+	b.Emit(diff.OpEqual, Printed{Text: "}", Path: "$"})
+
+	want := []diff.Line{
+		{Kind: diff.OpEqual, Content: "{"},
+		{Kind: diff.OpEqual, Content: `  "a": 1`},
+		{Kind: diff.OpDelete, Content: `  "b": 1`, OldLabel: "$.b"},
+		{Kind: diff.OpInsert, Content: `  "b": 2`, NewLabel: "$.b"},
+		{Kind: diff.OpInsert, Content: `  "c": 3`, NewLabel: "$.c"},
+		{Kind: diff.OpEqual, Content: "}"},
+	}
+	if !reflect.DeepEqual(b.Lines, want) {
+		t.Errorf("Lines = %+v, want %+v", b.Lines, want)
+	}
+	if wantPairs := []diff.Pair{{Old: 2, New: 3}}; !reflect.DeepEqual(b.Pairs, wantPairs) {
+		t.Errorf("Pairs = %v, want %v", b.Pairs, wantPairs)
+	}
+}
+
+func TestBuilderAnnotate(t *testing.T) {
+	line := func(text string, op align.AlignOp) []align.AlignedToken {
+		return AppendToken(AppendToken(nil, "x=", align.AlignMatch), text, op)
+	}
+	var b Builder
+	for i := 0; i < 5; i++ {
+		b.Lines = append(b.Lines, diff.Line{Kind: diff.OpEqual, Content: "same"})
+	}
+	b.AddPair(line("1", align.AlignDelete), line("2", align.AlignInsert), "old", "new")
+	b.EmitPair(Printed{Text: "y=1"}, Printed{Text: "y=2"})
+
+	hunks := b.Annotate(diff.ComputeHunks(b.Lines, 1))
+	if len(hunks) != 1 {
+		t.Fatalf("expected one hunk, got %d", len(hunks))
+	}
+	h := hunks[0]
+	if len(h.Lines) != 5 || h.Lines[1].Content != "x=1" || h.Lines[1].OldLabel != "old" || h.Lines[2].NewLabel != "new" {
+		t.Fatalf("unexpected lines %+v", h.Lines)
+	}
+	if len(h.Pairs) != 2 || h.Pairs[0].OldIdx != 1 || h.Pairs[0].NewIdx != 2 || h.Pairs[1].OldIdx != 3 {
+		t.Fatalf("expected the pairs at (1,2) and (3,4) within the hunk, got %+v", h.Pairs)
+	}
+	if a := h.Pairs[0].Alignment; a.New[1].Op != align.AlignInsert || a.New[1].Token.Text != "2" {
+		t.Errorf("expected the given emphasis, got %+v", a)
+	}
+	if a := h.Pairs[1].Alignment; len(a.New) == 0 || a.New[len(a.New)-1].Op != align.AlignInsert {
+		t.Errorf("expected a pair without emphasis to be aligned, got %+v", a)
+	}
+}
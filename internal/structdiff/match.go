@@ -0,0 +1,106 @@
+package structdiff
+
+import "github.com/amterp/go-delta/internal/diff"
+
+// Match is an element in a merged order and its index in each sequence,
+// -1 where it is missing.
+type Match struct {
+	Old, New int
+}
+
+// MatchByID matches elements with equal IDs wherever they are, repeated
+// IDs in order, and returns them in new's order. Elements only in old
+// follow the element matched to the nearest one before them in old, or
+// come first if there is none.
+func MatchByID(old, new []string) []Match {
+	byID := map[string][]int{}
+	for i, id := range old {
+		byID[id] = append(byID[id], i)
+	}
+	newOf := make([]int, len(old)) // new index of each old element, or -1
+	for i := range newOf {
+		newOf[i] = -1
+	}
+	oldOf := make([]int, len(new))
+	for j, id := range new {
+		oldOf[j] = -1
+		if idx := byID[id]; len(idx) > 0 {
+			oldOf[j], newOf[idx[0]] = idx[0], j
+			byID[id] = idx[1:]
+		}
+	}
+	removedAfter := map[int][]int{} // new index (-1 for first) -> old indices
+	after := -1
+	for i, j := range newOf {
+		if j >= 0 {
+			after = j
+			continue
+		}
+		removedAfter[after] = append(removedAfter[after], i)
+	}
+
+	out := make([]Match, 0, len(old)+len(new))
+	removed := func(after int) {
+		for _, i := range removedAfter[after] {
+			out = append(out, Match{i, -1})
+		}
+	}
+	removed(-1)
+	for j, i := range oldOf {
+		out = append(out, Match{i, j})
+		removed(j)
+	}
+	return out
+}
+
+// ScriptMatches returns the matches an edit script describes, in order.
+func ScriptMatches(ops []diff.OpKind) []Match {
+	out := make([]Match, len(ops))
+	i, j := 0, 0
+	for k, op := range ops {
+		out[k] = Match{Old: -1, New: -1}
+		if op != diff.OpInsert {
+			out[k].Old = i
+			i++
+		}
+		if op != diff.OpDelete {
+			out[k].New = j
+			j++
+		}
+	}
+	return out
+}
+
+// WalkMatches walks matches in order, passing each matched element to
+// same. The elements removed and added between two matches are paired
+// up in order for as long as pairable reports they correspond, so
+// changes inside them can show in place, and the pairs passed to same;
+// the rest are passed to removed, then added.
+func WalkMatches(matches []Match, pairable func(old, new int) bool, same func(old, new int), removed func(old int), added func(new int)) {
+	var dels, ins []int
+	flush := func() {
+		k := 0
+		for ; k < len(dels) && k < len(ins) && pairable(dels[k], ins[k]); k++ {
+			same(dels[k], ins[k])
+		}
+		for _, i := range dels[k:] {
+			removed(i)
+		}
+		for _, j := range ins[k:] {
+			added(j)
+		}
+		dels, ins = dels[:0], ins[:0]
+	}
+	for _, m := range matches {
+		switch {
+		case m.Old >= 0 && m.New >= 0:
+			flush()
+			same(m.Old, m.New)
+		case m.Old >= 0:
+			dels = append(dels, m.Old)
+		default:
+			ins = append(ins, m.New)
+		}
+	}
+	flush()
+}
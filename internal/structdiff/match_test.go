@@ -0,0 +1,55 @@
+package structdiff
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/amterp/go-delta/internal/diff"
+)
+
+func TestMatchByID(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new []string
+		want     []Match
+	}{
+		{"identical", []string{"a", "b"}, []string{"a", "b"}, []Match{{0, 0}, {1, 1}}},
+		{"moved", []string{"a", "b"}, []string{"b", "a"}, []Match{{1, 0}, {0, 1}}},
+		{"removed first", []string{"x", "a"}, []string{"a"}, []Match{{0, -1}, {1, 0}}},
+		{"removed after", []string{"a", "x", "b"}, []string{"b", "a", "c"}, []Match{{2, 0}, {0, 1}, {1, -1}, {-1, 2}}},
+		{"repeated in order", []string{"a", "a"}, []string{"a"}, []Match{{0, 0}, {1, -1}}},
+		{"empty", nil, []string{"a"}, []Match{{-1, 0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchByID(tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScriptMatches(t *testing.T) {
+	got := ScriptMatches([]diff.OpKind{diff.OpDelete, diff.OpEqual, diff.OpInsert, diff.OpEqual})
+	want := []Match{{0, -1}, {1, 0}, {-1, 1}, {2, 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestWalkMatches(t *testing.T) {
+	var got []string
+	matches := []Match{{0, -1}, {1, -1}, {-1, 0}, {2, 1}, {3, -1}, {-1, 2}, {-1, 3}}
+	WalkMatches(matches,
+		func(old, new int) bool { return old != 3 },
+		func(old, new int) { got = append(got, fmt.Sprintf("%d=%d", old, new)) },
+		func(old int) { got = append(got, fmt.Sprintf("-%d", old)) },
+		func(new int) { got = append(got, fmt.Sprintf("+%d", new)) })
+	// Old 0 pairs with new 0, leaving 1 removed; old 3 isn't pairable,
+	// so it is removed and new 2 and 3 are added.
+	want := []string{"0=0", "-1", "2=1", "-3", "+2", "+3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
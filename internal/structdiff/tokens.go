@@ -0,0 +1,32 @@
+package structdiff
+
+import (
+	"strings"
+
+	"github.com/amterp/go-delta/internal/align"
+)
+
+// AppendToken appends text to a line built from tokens, as one token
+// marked op. Empty text adds nothing.
+func AppendToken(tokens []align.AlignedToken, text string, op align.AlignOp) []align.AlignedToken {
+	if text == "" {
+		return tokens
+	}
+	pos := 0
+	if len(tokens) > 0 {
+		pos = tokens[len(tokens)-1].Token.End
+	}
+	return append(tokens, align.AlignedToken{
+		Op:    op,
+		Token: align.Token{Text: text, Start: pos, End: pos + len(text)},
+	})
+}
+
+// Text joins tokens into a line.
+func Text(tokens []align.AlignedToken) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString(t.Token.Text)
+	}
+	return b.String()
+}
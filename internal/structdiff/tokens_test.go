@@ -0,0 +1,20 @@
+package structdiff
+
+import (
+	"testing"
+
+	"github.com/amterp/go-delta/internal/align"
+)
+
+func TestAppendToken(t *testing.T) {
+	var tokens []align.AlignedToken
+	tokens = AppendToken(tokens, "a=", align.AlignMatch)
+	tokens = AppendToken(tokens, "", align.AlignDelete)
+	tokens = AppendToken(tokens, "1", align.AlignDelete)
+	if got := Text(tokens); got != "a=1" {
+		t.Errorf("Text = %q, want %q", got, "a=1")
+	}
+	if len(tokens) != 2 || tokens[1].Token.Start != 2 || tokens[1].Token.End != 3 || tokens[1].Op != align.AlignDelete {
+		t.Errorf("expected empty text skipped and offsets running on, got %+v", tokens)
+	}
+}
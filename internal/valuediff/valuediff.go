@@ -0,0 +1,387 @@
+// Package valuediff pretty-prints Go values deterministically and diffs
+// the printed forms line by line.
+package valuediff
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+	"unsafe"
+
+	"github.com/amterp/go-delta/internal/diff"
+)
+
+// indent is the indentation per nesting level.
+const indent = "  "
+
+// bytesPerLine is how many bytes a line of a binary []byte shows.
+const bytesPerLine = 16
+
+// Options controls how values are printed.
+type Options struct {
+	// Unexported includes unexported struct fields.
+	Unexported bool
+}
+
+// Line is a printed line and the path of the value it belongs to.
+type Line struct {
+	Text string
+	Path string // such as .Address.Zip, .Tags[2] or .Meta["k"]; "" for the root
+}
+
+// Diff prints want and got and returns the line diff of the printed
+// forms, with changed lines labeled with their path in OldLabel or
+// NewLabel. Returns nil if both print the same.
+func Diff(want, got any, opts Options) []diff.Line {
+	old, new := Format(want, opts), Format(got, opts)
+	oldText, newText := texts(old), texts(new)
+	if slices.Equal(oldText, newText) {
+		return nil
+	}
+	var lines []diff.Line
+	i, j := 0, 0
+	for _, op := range diff.EditScript(oldText, newText) {
+		switch op {
+		case diff.OpEqual:
+			lines = append(lines, diff.Line{Kind: diff.OpEqual, Content: new[j].Text})
+			i++
+			j++
+		case diff.OpDelete:
+			lines = append(lines, diff.Line{Kind: diff.OpDelete, Content: old[i].Text, OldLabel: old[i].Path})
+			i++
+		case diff.OpInsert:
+			lines = append(lines, diff.Line{Kind: diff.OpInsert, Content: new[j].Text, NewLabel: new[j].Path})
+			j++
+		}
+	}
+	return lines
+}
+
+func texts(lines []Line) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = l.Text
+	}
+	return out
+}
+
+// Format prints v in Go-like syntax, one field or element per line:
+// map entries are sorted by key, pointers are followed (a pointer back
+// to a value being printed shows as a cycle), time.Time and
+// time.Duration print as their text, and []byte prints as a string if
+// it is printable text, otherwise as hex bytes. Composite values are
+// prefixed with their type at the root and wherever the static type is
+// an interface.
+func Format(v any, opts Options) []Line {
+	p := printer{opts: opts, visiting: map[visit]string{}}
+	p.value(reflect.ValueOf(v), 0, "", "", "", true)
+	return p.lines
+}
+
+// visit identifies a pointer or map being printed.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+type printer struct {
+	opts     Options
+	lines    []Line
+	visiting map[visit]string // path of each pointer or map being printed
+}
+
+func (p *printer) emit(depth int, text, path string) {
+	p.lines = append(p.lines, Line{Text: strings.Repeat(indent, depth) + text, Path: path})
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	bytesType    = reflect.TypeOf([]byte(nil))
+)
+
+// value prints v at depth between prefix and suffix. showType prefixes
+// composite values, and scalars of non-default types, with their type.
+func (p *printer) value(v reflect.Value, depth int, prefix, suffix, path string, showType bool) {
+	line := func(text string) {
+		p.emit(depth, prefix+text+suffix, path)
+	}
+	if !v.IsValid() {
+		line("nil")
+		return
+	}
+	v = readable(v)
+	t := v.Type()
+	typ := ""
+	if showType {
+		typ = t.String()
+	}
+
+	switch t {
+	case timeType:
+		if v.CanInterface() {
+			line("time.Time(" + v.Interface().(time.Time).Format(time.RFC3339Nano) + ")")
+			return
+		}
+	case durationType:
+		line("time.Duration(" + time.Duration(v.Int()).String() + ")")
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128, reflect.String:
+		s := scalar(v)
+		if showType && !defaultType(t) {
+			s = typ + "(" + s + ")"
+		}
+		line(s)
+
+	case reflect.Pointer:
+		if v.IsNil() {
+			line(nilOf(typ))
+			return
+		}
+		if cycle, ok := p.enter(v, path); ok {
+			line("&<cycle to " + cycle + ">")
+			return
+		}
+		defer delete(p.visiting, visit{v.Pointer(), t})
+		p.value(v.Elem(), depth, prefix+"&", suffix, path, showType)
+
+	case reflect.Interface:
+		if v.IsNil() {
+			line("nil")
+			return
+		}
+		p.value(v.Elem(), depth, prefix, suffix, path, true)
+
+	case reflect.Struct:
+		var fields []int
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).IsExported() || p.opts.Unexported {
+				fields = append(fields, i)
+			}
+		}
+		if len(fields) == 0 {
+			line(typ + "{}")
+			return
+		}
+		p.emit(depth, prefix+typ+"{", path)
+		for _, i := range fields {
+			name := t.Field(i).Name
+			p.value(v.Field(i), depth+1, name+": ", ",", path+"."+name, false)
+		}
+		p.emit(depth, "}"+suffix, path)
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			line(nilOf(typ))
+			return
+		}
+		if t.Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+			typ := t.String()
+			if t == bytesType {
+				typ = "[]byte"
+			}
+			p.bytes(v, depth, prefix, suffix, path, typ)
+			return
+		}
+		if v.Len() == 0 {
+			line(typ + "{}")
+			return
+		}
+		p.emit(depth, prefix+typ+"{", path)
+		for i := 0; i < v.Len(); i++ {
+			p.value(v.Index(i), depth+1, "", ",", fmt.Sprintf("%s[%d]", path, i), false)
+		}
+		p.emit(depth, "}"+suffix, path)
+
+	case reflect.Map:
+		if v.IsNil() {
+			line(nilOf(typ))
+			return
+		}
+		if v.Len() == 0 {
+			line(typ + "{}")
+			return
+		}
+		if cycle, ok := p.enter(v, path); ok {
+			line("<cycle to " + cycle + ">")
+			return
+		}
+		defer delete(p.visiting, visit{v.Pointer(), t})
+		type entry struct {
+			key  reflect.Value
+			text string
+		}
+		var entries []entry
+		for _, k := range v.MapKeys() {
+			entries = append(entries, entry{k, p.inline(k)})
+		}
+		slices.SortFunc(entries, func(a, b entry) int {
+			if c := compareKeys(a.key, b.key); c != 0 {
+				return c
+			}
+			return strings.Compare(a.text, b.text)
+		})
+		p.emit(depth, prefix+typ+"{", path)
+		for _, e := range entries {
+			p.value(v.MapIndex(e.key), depth+1, e.text+": ", ",", path+"["+e.text+"]", false)
+		}
+		p.emit(depth, "}"+suffix, path)
+
+	default: // func, chan, unsafe.Pointer
+		if v.IsNil() {
+			line(nilOf(typ))
+			return
+		}
+		line("<" + t.String() + ">")
+	}
+}
+
+// enter marks the pointer or map v at path as being printed, or
+// returns the path where it already is.
+func (p *printer) enter(v reflect.Value, path string) (string, bool) {
+	key := visit{v.Pointer(), v.Type()}
+	if at, ok := p.visiting[key]; ok {
+		if at == "" {
+			at = "root"
+		}
+		return at, true
+	}
+	p.visiting[key] = path
+	return "", false
+}
+
+// inline prints v on a single line, for map keys.
+func (p *printer) inline(v reflect.Value) string {
+	sub := printer{opts: p.opts, visiting: map[visit]string{}}
+	sub.value(v, 0, "", "", "", v.Kind() == reflect.Interface)
+	parts := make([]string, len(sub.lines))
+	for i, l := range sub.lines {
+		parts[i] = strings.TrimSpace(l.Text)
+	}
+	return strings.Join(parts, " ")
+}
+
+// bytes prints a []byte as a string if it is printable text, otherwise
+// as hex, bytesPerLine to a line.
+func (p *printer) bytes(v reflect.Value, depth int, prefix, suffix, path, typ string) {
+	b := make([]byte, v.Len())
+	reflect.Copy(reflect.ValueOf(b), v)
+	if printable(b) {
+		p.emit(depth, prefix+typ+"("+strconv.Quote(string(b))+")"+suffix, path)
+		return
+	}
+	p.emit(depth, prefix+typ+"{", path)
+	for i := 0; i < len(b); i += bytesPerLine {
+		row := b[i:min(i+bytesPerLine, len(b))]
+		hex := make([]string, len(row))
+		for k, c := range row {
+			hex[k] = fmt.Sprintf("0x%02x,", c)
+		}
+		p.emit(depth+1, strings.Join(hex, " "), fmt.Sprintf("%s[%d]", path, i))
+	}
+	p.emit(depth, "}"+suffix, path)
+}
+
+// printable reports whether b is UTF-8 text without control characters
+// other than newlines and tabs.
+func printable(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// readable returns v in a form whose unexported fields can be read
+// through their addresses: as is if it is addressable, read through
+// its address if it was reached through an unexported field, and
+// otherwise, such as for a map value, as an addressable copy.
+func readable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		if !v.CanInterface() {
+			v = reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+		}
+		return v
+	}
+	if !v.CanInterface() {
+		return v
+	}
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+// scalar prints a bool, number or string.
+func scalar(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32)
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	case reflect.Complex64, reflect.Complex128:
+		return fmt.Sprint(v.Complex())
+	default:
+		return strconv.Quote(v.String())
+	}
+}
+
+// defaultType reports whether t is the type an untyped constant of its
+// kind defaults to, so its values need no conversion to be read.
+func defaultType(t reflect.Type) bool {
+	switch t {
+	case reflect.TypeOf(false), reflect.TypeOf(0), reflect.TypeOf(0.0), reflect.TypeOf(""):
+		return true
+	}
+	return false
+}
+
+// nilOf prints a nil value of type typ, or plain nil if typ is empty.
+func nilOf(typ string) string {
+	if typ == "" {
+		return "nil"
+	}
+	return "(" + typ + ")(nil)"
+}
+
+// compareKeys orders map keys of the same ordered kind by value, so
+// numeric keys sort numerically. Other keys compare equal.
+func compareKeys(a, b reflect.Value) int {
+	if a.Kind() == reflect.Interface {
+		a, b = a.Elem(), b.Elem()
+	}
+	if !a.IsValid() || !b.IsValid() || a.Kind() != b.Kind() {
+		return 0
+	}
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.String:
+		return cmp.Compare(a.String(), b.String())
+	}
+	return 0
+}
@@ -0,0 +1,181 @@
+package valuediff
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amterp/go-delta/internal/diff"
+)
+
+type address struct {
+	City, Zip string
+}
+
+type user struct {
+	Name    string
+	Tags    []string
+	Address *address
+	Meta    map[string]any
+	next    *user
+}
+
+// text joins printed lines as "<text>  # <path>" rows.
+func text(lines []Line) string {
+	var b strings.Builder
+	for _, l := range lines {
+		b.WriteString(l.Text)
+		if l.Path != "" {
+			b.WriteString("  # " + l.Path)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func TestFormat(t *testing.T) {
+	u := user{
+		Name:    "Alice",
+		Tags:    []string{"admin"},
+		Address: &address{City: "Springfield"},
+		Meta:    map[string]any{"z": 1, "a": int32(2), "m": []int{}},
+	}
+	want := `valuediff.user{
+  Name: "Alice",  # .Name
+  Tags: {  # .Tags
+    "admin",  # .Tags[0]
+  },  # .Tags
+  Address: &{  # .Address
+    City: "Springfield",  # .Address.City
+    Zip: "",  # .Address.Zip
+  },  # .Address
+  Meta: {  # .Meta
+    "a": int32(2),  # .Meta["a"]
+    "m": []int{},  # .Meta["m"]
+    "z": 1,  # .Meta["z"]
+  },  # .Meta
+}
+`
+	if got := text(Format(u, Options{})); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatScalars(t *testing.T) {
+	type level int
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{nil, "nil"},
+		{42, "42"},
+		{level(3), "valuediff.level(3)"},
+		{1.5, "1.5"},
+		{"a\"b", `"a\"b"`},
+		{(*int)(nil), "(*int)(nil)"},
+		{[]string(nil), "([]string)(nil)"},
+		{time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "time.Time(2024-01-02T03:04:05Z)"},
+		{90 * time.Second, "time.Duration(1m30s)"},
+		{[]byte("hi\n"), `[]byte("hi\n")`},
+		{func() {}, "<func()>"},
+	}
+	for _, tt := range tests {
+		if got := text(Format(tt.v, Options{})); got != tt.want+"\n" {
+			t.Errorf("Format(%#v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestFormatBinaryBytes(t *testing.T) {
+	b := make([]byte, 18)
+	b[0] = 0xff
+	lines := Format(b, Options{})
+	if len(lines) != 4 || !strings.HasPrefix(lines[1].Text, "  0xff, 0x00,") || lines[2].Path != "[16]" {
+		t.Errorf("expected hex rows of 16 bytes, got:\n%s", text(lines))
+	}
+}
+
+func TestFormatMapKeysSorted(t *testing.T) {
+	lines := Format(map[int]bool{10: true, 9: false, -1: true}, Options{})
+	var keys []string
+	for _, l := range lines[1 : len(lines)-1] {
+		k, _, _ := strings.Cut(strings.TrimSpace(l.Text), ":")
+		keys = append(keys, k)
+	}
+	if got := strings.Join(keys, " "); got != "-1 9 10" {
+		t.Errorf("keys in order %q, want numeric order", got)
+	}
+}
+
+func TestFormatCycle(t *testing.T) {
+	u := &user{Name: "a"}
+	u.next = &user{Name: "b", next: u}
+	got := text(Format(u, Options{Unexported: true}))
+	if !strings.Contains(got, "next: &<cycle to root>,  # .next.next") {
+		t.Errorf("expected the cycle back to the root, got:\n%s", got)
+	}
+	if strings.Contains(text(Format(u, Options{})), "next") {
+		t.Error("unexported fields should be omitted by default")
+	}
+}
+
+func TestFormatSharedPointer(t *testing.T) {
+	a := &address{City: "x"}
+	got := text(Format([]*address{a, a}, Options{}))
+	if strings.Contains(got, "cycle") {
+		t.Errorf("a pointer seen twice but not nested isn't a cycle, got:\n%s", got)
+	}
+}
+
+func TestFormatUnexportedTime(t *testing.T) {
+	type event struct {
+		at time.Time
+	}
+	got := text(Format(event{at: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}, Options{Unexported: true}))
+	if !strings.Contains(got, "at: time.Time(2024-01-02T00:00:00Z),") {
+		t.Errorf("expected the unexported time formatted, got:\n%s", got)
+	}
+
+	// Map values and interface contents aren't addressable.
+	at := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	type log struct {
+		byName map[string]event
+		times  map[string]time.Time
+		last   any
+	}
+	got = text(Format(log{
+		byName: map[string]event{"a": {at: at}},
+		times:  map[string]time.Time{"b": at},
+		last:   event{at: at},
+	}, Options{Unexported: true}))
+	for _, want := range []string{
+		`at: time.Time(2024-01-02T00:00:00Z),  # .byName["a"].at`,
+		`"b": time.Time(2024-01-02T00:00:00Z),  # .times["b"]`,
+		`at: time.Time(2024-01-02T00:00:00Z),  # .last.at`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	want := user{Name: "Alice", Address: &address{City: "Springfield", Zip: "62701"}}
+	got := user{Name: "Alice", Address: &address{City: "Springfield", Zip: "62704"}}
+	lines := Diff(want, got, Options{})
+	var changed []string
+	for _, l := range lines {
+		switch l.Kind {
+		case diff.OpDelete:
+			changed = append(changed, "-"+l.OldLabel)
+		case diff.OpInsert:
+			changed = append(changed, "+"+l.NewLabel)
+		}
+	}
+	if strings.Join(changed, " ") != "-.Address.Zip +.Address.Zip" {
+		t.Errorf("changed lines %v, want the zip", changed)
+	}
+	if Diff(want, want, Options{}) != nil {
+		t.Error("equal values should have no diff")
+	}
+}
@@ -0,0 +1,544 @@
+// Package xmldiff compares XML and HTML documents as trees and lays out
+// the result as a line diff of their canonical pretty-printed forms.
+package xmldiff
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/amterp/go-delta/internal/diff"
+	"github.com/amterp/go-delta/internal/structdiff"
+)
+
+// indent is the canonical indentation per nesting level.
+const indent = "  "
+
+// preformatted are the HTML elements whose text is kept as written.
+var preformatted = map[string]bool{"pre": true, "textarea": true}
+
+// rawText are the HTML elements whose content is text, not markup.
+var rawText = map[string]bool{"script": true, "style": true}
+
+// keyAttrs are the attributes identifying an element among its
+// siblings, in order of preference.
+var keyAttrs = []string{"id", "key"}
+
+// voidElements are the HTML elements that have no content or end tag.
+var voidElements = map[string]bool{}
+
+// impliedEnd lists, for HTML elements whose start tag closes open
+// elements without end tags, the elements it closes.
+var impliedEnd = map[string][]string{
+	"li":     {"li"},
+	"dt":     {"dt", "dd"},
+	"dd":     {"dt", "dd"},
+	"tr":     {"tr", "td", "th"},
+	"td":     {"td", "th"},
+	"th":     {"td", "th"},
+	"option": {"option"},
+}
+
+func init() {
+	for _, name := range xml.HTMLAutoClose {
+		voidElements[name] = true
+	}
+	for _, name := range []string{"p", "div", "ul", "ol", "dl", "table", "pre", "blockquote", "h1", "h2", "h3", "h4", "h5", "h6"} {
+		impliedEnd[name] = append(impliedEnd[name], "p")
+	}
+}
+
+type kind int
+
+const (
+	element kind = iota
+	text
+	comment
+	procInst  // <?target inst?>
+	directive // <!DOCTYPE ...>
+)
+
+// node is a parsed element, text run, comment, processing instruction
+// or directive.
+type node struct {
+	kind     kind
+	name     string     // tag or processing instruction target
+	attrs    []xml.Attr // sorted by name
+	text     string     // text, comment, instruction or directive content
+	children []*node
+	path     string // XPath-like location, such as /html/body/p[2]
+	preserve bool   // whitespace in the element's text is significant
+	raw      bool   // text of a <script> or <style>, printed unescaped
+
+	lines []string // canonical form, filled in by render
+}
+
+// Document is a parsed XML or HTML document.
+type Document struct {
+	nodes []*node
+	html  bool
+}
+
+// Parse reads an XML document, or with html set, an HTML document:
+// tag and attribute names are case-insensitive, void elements such as
+// <br> need no end tag, elements such as <li> and <p> are closed by the
+// start of the next one or by their parent's end tag, attribute values
+// may be unquoted words, and <script> and <style> hold raw text.
+//
+// Whitespace-only text is dropped, and other text has its whitespace
+// collapsed to single spaces, keeping a space before or after it only
+// where it meets a sibling element, as in "Hello <b>world</b>". Text in
+// HTML <pre> and <textarea> elements and in elements with
+// xml:space="preserve" is kept as written.
+func Parse(data []byte, html bool) (Document, error) {
+	if html {
+		data = escapeRawText(data)
+	}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	if html {
+		dec.Strict = false
+		dec.Entity = xml.HTMLEntity
+	}
+
+	root := &node{kind: element}
+	stack := []*node{root}
+	add := func(n *node) {
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, n)
+	}
+	for {
+		tok, err := dec.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Document{}, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{kind: element, name: qualified(t.Name, html)}
+			for _, a := range t.Attr {
+				n.attrs = append(n.attrs, xml.Attr{Name: xml.Name{Local: qualified(a.Name, html)}, Value: a.Value})
+			}
+			sort.SliceStable(n.attrs, func(i, j int) bool { return n.attrs[i].Name.Local < n.attrs[j].Name.Local })
+			if html {
+				for len(stack) > 1 && slices.Contains(impliedEnd[n.name], stack[len(stack)-1].name) {
+					stack = stack[:len(stack)-1]
+				}
+			}
+			n.preserve = stack[len(stack)-1].preserve || html && preformatted[n.name]
+			for _, a := range n.attrs {
+				if a.Name.Local == "xml:space" {
+					n.preserve = a.Value == "preserve"
+				}
+			}
+			add(n)
+			if !html || !voidElements[n.name] {
+				stack = append(stack, n)
+			}
+		case xml.EndElement:
+			name := qualified(t.Name, html)
+			open := len(stack) - 1
+			for open > 0 && stack[open].name != name {
+				open--
+			}
+			switch {
+			case open > 0 && (html || open == len(stack)-1):
+				stack = stack[:open] // closes any unclosed HTML elements inside
+			case html:
+				// An end tag matching no open element is ignored.
+			case len(stack) == 1:
+				line, _ := dec.InputPos()
+				return Document{}, fmt.Errorf("line %d: unexpected end element </%s>", line, name)
+			default:
+				line, _ := dec.InputPos()
+				return Document{}, fmt.Errorf("line %d: element <%s> closed by </%s>", line, stack[len(stack)-1].name, name)
+			}
+		case xml.CharData:
+			// Adjacent runs, such as text around a CDATA section, are
+			// one text node; whitespace is normalized once the
+			// siblings are known.
+			parent := stack[len(stack)-1]
+			if k := len(parent.children) - 1; k >= 0 && parent.children[k].kind == text {
+				parent.children[k].text += string(t)
+			} else {
+				add(&node{kind: text, text: string(t), raw: html && rawText[parent.name]})
+			}
+		case xml.Comment:
+			add(&node{kind: comment, text: strings.TrimSpace(string(t))})
+		case xml.ProcInst:
+			add(&node{kind: procInst, name: t.Target, text: strings.TrimSpace(string(t.Inst))})
+		case xml.Directive:
+			add(&node{kind: directive, text: strings.Join(strings.Fields(string(t)), " ")})
+		}
+	}
+	if !html && len(stack) > 1 {
+		return Document{}, fmt.Errorf("element <%s> is not closed", stack[len(stack)-1].name)
+	}
+	normalizeText(root, html)
+	setPaths(root.children, "")
+	return Document{nodes: root.children, html: html}, nil
+}
+
+// normalizeText drops whitespace-only text among n's descendants and
+// collapses whitespace in the rest, except where it is preserved. A
+// text run keeps one space at an edge where it had whitespace and meets
+// a sibling, since there the space separates words when rendered. As
+// in HTML, a newline right after a <pre> or <textarea> start tag is
+// ignored.
+func normalizeText(n *node, html bool) {
+	if n.preserve {
+		if c := n.children; html && preformatted[n.name] && len(c) > 0 && c[0].kind == text {
+			c[0].text = strings.TrimPrefix(strings.TrimPrefix(c[0].text, "\r"), "\n")
+		}
+	} else {
+		var kept []*node
+		for _, c := range n.children {
+			if c.kind != text || strings.TrimSpace(c.text) != "" {
+				kept = append(kept, c)
+			}
+		}
+		n.children = kept
+	}
+	for k, c := range n.children {
+		switch {
+		case c.kind == element:
+			normalizeText(c, html)
+		case c.kind == text && !n.preserve:
+			s := strings.Join(strings.Fields(c.text), " ")
+			if k > 0 && startsWithSpace(c.text) {
+				s = " " + s
+			}
+			if k < len(n.children)-1 && endsWithSpace(c.text) {
+				s += " "
+			}
+			c.text = s
+		}
+	}
+	n.children = slices.DeleteFunc(n.children, func(c *node) bool { return c.kind == text && c.text == "" })
+}
+
+func startsWithSpace(s string) bool {
+	return strings.TrimLeftFunc(s, unicode.IsSpace) != s
+}
+
+func endsWithSpace(s string) bool {
+	return strings.TrimRightFunc(s, unicode.IsSpace) != s
+}
+
+// escapeRawText escapes the content of HTML <script> and <style>
+// elements, which is raw text rather than markup, so the tokenizer
+// reads a script's "a < b" as text.
+func escapeRawText(data []byte) []byte {
+	lower := asciiLower(data)
+	var out []byte
+	last := 0 // end of the input copied to out
+	for i := 0; i < len(data); {
+		k := bytes.IndexByte(lower[i:], '<')
+		if k < 0 {
+			break
+		}
+		i += k + 1
+		var name string
+		for tag := range rawText {
+			if rest := lower[i:]; bytes.HasPrefix(rest, []byte(tag)) &&
+				len(rest) > len(tag) && strings.IndexByte(" \t\r\n/>", rest[len(tag)]) >= 0 {
+				name = tag
+			}
+		}
+		if name == "" {
+			continue
+		}
+		open := bytes.IndexByte(lower[i:], '>')
+		if open < 0 {
+			break
+		}
+		start := i + open + 1
+		end := bytes.Index(lower[start:], []byte("</"+name))
+		if end < 0 {
+			end = len(data) - start
+		}
+		end += start
+		out = append(out, data[last:start]...)
+		out = append(out, textEscaper.Replace(string(data[start:end]))...)
+		last, i = end, end
+	}
+	if out == nil {
+		return data
+	}
+	return append(out, data[last:]...)
+}
+
+// asciiLower lowercases only ASCII letters, so each byte of the result
+// is at the same offset as in data. (bytes.ToLower can change lengths,
+// such as for İ.)
+func asciiLower(data []byte) []byte {
+	lower := make([]byte, len(data))
+	for i, c := range data {
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		lower[i] = c
+	}
+	return lower
+}
+
+// qualified returns a name as written, with its namespace prefix, and
+// lowercased for HTML.
+func qualified(n xml.Name, html bool) string {
+	s := n.Local
+	if n.Space != "" {
+		s = n.Space + ":" + s
+	}
+	if html {
+		s = strings.ToLower(s)
+	}
+	return s
+}
+
+// setPaths sets the path of each of nodes, the children of the node at
+// parent. Elements are named by tag, with their key attribute, or with
+// a 1-based position among same-tag siblings where there are several.
+func setPaths(nodes []*node, parent string) {
+	count := map[string]int{}
+	for _, n := range nodes {
+		count[step(n)]++
+	}
+	seen := map[string]int{}
+	for _, n := range nodes {
+		s := step(n)
+		seen[s]++
+		switch {
+		case n.kind == element:
+			if attr, value, ok := key(n); ok {
+				s = fmt.Sprintf("%s[@%s=%q]", s, attr, value)
+			} else if count[s] > 1 {
+				s = fmt.Sprintf("%s[%d]", s, seen[s])
+			}
+		case count[s] > 1:
+			s = fmt.Sprintf("%s[%d]", s, seen[s])
+		}
+		n.path = parent + "/" + s
+		setPaths(n.children, n.path)
+	}
+}
+
+// step returns the unindexed path step naming n.
+func step(n *node) string {
+	switch n.kind {
+	case element:
+		return n.name
+	case text:
+		return "text()"
+	case comment:
+		return "comment()"
+	case procInst:
+		return "processing-instruction()"
+	}
+	return "node()"
+}
+
+// key returns the key attribute of an element and its value.
+func key(n *node) (attr, value string, ok bool) {
+	if n.kind != element {
+		return "", "", false
+	}
+	for _, k := range keyAttrs {
+		for _, a := range n.attrs {
+			if a.Name.Local == k {
+				return k, a.Value, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// Diff compares two documents and returns their canonical forms as a
+// line diff: one element, text run or comment per line, indented by
+// nesting, with attributes sorted by name. An element whose only child
+// is text is printed on one line. Sibling elements are matched by tag
+// and id or key attribute, or by content, and matched elements are
+// diffed child by child. Changed lines are labeled with the path of the
+// node they belong to, such as "/html/body/p[2]", in OldLabel or
+// NewLabel. Elements with changed attributes and changed text runs are
+// returned as pairs. Returns nil if the documents are equivalent.
+func Diff(old, new Document) ([]diff.Line, []diff.Pair) {
+	d := differ{html: new.html}
+	d.children(old.nodes, new.nodes, 0)
+	for _, l := range d.Lines {
+		if l.Kind != diff.OpEqual {
+			return d.Lines, d.Pairs
+		}
+	}
+	return nil, nil
+}
+
+type differ struct {
+	structdiff.Builder
+	html bool
+}
+
+// children diffs two lists of sibling nodes, matching them by identity
+// and diffing matched nodes.
+func (d *differ) children(old, new []*node, depth int) {
+	oldIDs, newIDs := make([]string, len(old)), make([]string, len(new))
+	for i, n := range old {
+		oldIDs[i] = d.identity(n)
+	}
+	for j, n := range new {
+		newIDs[j] = d.identity(n)
+	}
+
+	// Nodes removed and added next to each other are diffed pairwise
+	// when they are the same kind of node, so changes inside them show
+	// in place. Keyed elements with different keys are different
+	// elements and are never paired.
+	structdiff.WalkMatches(structdiff.ScriptMatches(diff.EditScript(oldIDs, newIDs)),
+		func(i, j int) bool { return pairable(old[i], new[j]) },
+		func(i, j int) { d.node(old[i], new[j], depth) },
+		func(i int) { d.EmitAll(diff.OpDelete, d.render(old[i], depth)) },
+		func(j int) { d.EmitAll(diff.OpInsert, d.render(new[j], depth)) })
+}
+
+// node diffs two matched or paired nodes.
+func (d *differ) node(old, new *node, depth int) {
+	oldLines, newLines := d.render(old, depth), d.render(new, depth)
+	if canonical(oldLines) == canonical(newLines) {
+		d.EmitAll(diff.OpEqual, newLines)
+		return
+	}
+	if old.kind == element && new.kind == element && old.name == new.name && len(oldLines) > 1 && len(newLines) > 1 {
+		d.EmitLine(oldLines[0], newLines[0])
+		d.children(old.children, new.children, depth+1)
+		d.Emit(diff.OpEqual, newLines[len(newLines)-1])
+		return
+	}
+	if len(oldLines) == 1 && len(newLines) == 1 {
+		d.EmitPair(oldLines[0], newLines[0])
+		return
+	}
+	d.EmitAll(diff.OpDelete, oldLines)
+	d.EmitAll(diff.OpInsert, newLines)
+}
+
+// identity returns what a node is matched among its siblings by: its
+// tag and key attribute for keyed elements, otherwise its content.
+func (d *differ) identity(n *node) string {
+	if attr, value, ok := key(n); ok {
+		return fmt.Sprintf("key:%s[@%s=%q]", n.name, attr, value)
+	}
+	return "value:" + canonical(d.render(n, 0))
+}
+
+// pairable reports whether a removed and an added node should be
+// diffed as one changed node.
+func pairable(old, new *node) bool {
+	if old.kind != new.kind {
+		return false
+	}
+	if old.kind != element {
+		return true
+	}
+	_, _, oldKeyed := key(old)
+	_, _, newKeyed := key(new)
+	return old.name == new.name && !oldKeyed && !newKeyed
+}
+
+// render prints n in canonical form at depth, with each line labeled
+// with the path of the node it prints.
+func (d *differ) render(n *node, depth int) []structdiff.Printed {
+	if n.lines == nil {
+		n.lines = d.format(n)
+	}
+	pad := strings.Repeat(indent, depth)
+	out := make([]structdiff.Printed, len(n.lines))
+	for i, l := range n.lines {
+		out[i] = structdiff.Printed{Text: pad + l, Path: n.path}
+	}
+	// Lines of descendants are labeled with their own paths.
+	if len(n.lines) > 1 {
+		k := 1
+		for _, c := range n.children {
+			for _, p := range d.render(c, depth+1) {
+				out[k].Path = p.Path
+				k++
+			}
+		}
+	}
+	return out
+}
+
+// format prints n in canonical form at depth 0.
+func (d *differ) format(n *node) []string {
+	switch n.kind {
+	case text:
+		if n.raw {
+			return strings.Split(n.text, "\n")
+		}
+		return strings.Split(escapeText(n.text), "\n")
+	case comment:
+		return []string{"<!--" + n.text + "-->"}
+	case procInst:
+		if n.text == "" {
+			return []string{"<?" + n.name + "?>"}
+		}
+		return []string{"<?" + n.name + " " + n.text + "?>"}
+	case directive:
+		return []string{"<!" + n.text + ">"}
+	}
+
+	var b strings.Builder
+	b.WriteString("<" + n.name)
+	for _, a := range n.attrs {
+		b.WriteString(" " + a.Name.Local + `="` + escapeAttr(a.Value) + `"`)
+	}
+	open := b.String()
+	switch {
+	case len(n.children) == 0 && d.html && voidElements[n.name]:
+		return []string{open + ">"}
+	case len(n.children) == 0 && d.html:
+		return []string{open + "></" + n.name + ">"}
+	case len(n.children) == 0:
+		return []string{open + "/>"}
+	case len(n.children) == 1 && n.children[0].kind == text && !strings.Contains(n.children[0].text, "\n"):
+		return []string{open + ">" + d.format(n.children[0])[0] + "</" + n.name + ">"}
+	}
+	lines := []string{open + ">"}
+	for _, c := range n.children {
+		if c.lines == nil {
+			c.lines = d.format(c)
+		}
+		for _, l := range c.lines {
+			lines = append(lines, indent+l)
+		}
+	}
+	return append(lines, "</"+n.name+">")
+}
+
+// canonical joins printed lines for comparison.
+func canonical(ps []structdiff.Printed) string {
+	var b strings.Builder
+	for _, p := range ps {
+		b.WriteString(p.Text + "\n")
+	}
+	return b.String()
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;")
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func escapeAttr(s string) string {
+	return attrEscaper.Replace(s)
+}
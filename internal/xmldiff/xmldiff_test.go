@@ -0,0 +1,202 @@
+package xmldiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/amterp/go-delta/internal/diff"
+)
+
+func mustParse(t *testing.T, s string, html bool) Document {
+	t.Helper()
+	doc, err := Parse([]byte(s), html)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", s, err)
+	}
+	return doc
+}
+
+// format renders lines as "<op> <content>  # <label>" rows.
+func format(lines []diff.Line) string {
+	var b strings.Builder
+	for _, l := range lines {
+		switch l.Kind {
+		case diff.OpEqual:
+			b.WriteString("  " + l.Content + "\n")
+		case diff.OpDelete:
+			b.WriteString("- " + l.Content + "  # " + l.OldLabel + "\n")
+		case diff.OpInsert:
+			b.WriteString("+ " + l.Content + "  # " + l.NewLabel + "\n")
+		}
+	}
+	return b.String()
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, s := range []string{`<a><b></a>`, `<a>`, `<a x=1/>`} {
+		if _, err := Parse([]byte(s), false); err == nil {
+			t.Errorf("Parse(%q) should fail as XML", s)
+		}
+	}
+	for _, s := range []string{`<a><b></a>`, `<a>`, `<a x=1/>`, `</b><p>x`} {
+		if _, err := Parse([]byte(s), true); err != nil {
+			t.Errorf("Parse(%q) as HTML: %v", s, err)
+		}
+	}
+}
+
+func TestDiffEquivalent(t *testing.T) {
+	tests := []struct {
+		old, new string
+		html     bool
+	}{
+		{`<a x="1" y="2"><b>text</b></a>`, "<a y='2' x='1'>\n  <b>\n    text\n  </b>\n</a>", false},
+		{`<p>two  words</p>`, "<p>two\n\twords</p>", false},
+		{`<P CLASS=x>a<BR>b</P>`, `<p class="x">a<br/>b</p>`, true},
+		{`<ul><li>a<li>b</ul>`, `<ul><li>a</li><li>b</li></ul>`, true},
+	}
+	for _, tt := range tests {
+		lines, pairs := Diff(mustParse(t, tt.old, tt.html), mustParse(t, tt.new, tt.html))
+		if lines != nil || pairs != nil {
+			t.Errorf("Diff(%q, %q) should be empty, got:\n%s", tt.old, tt.new, format(lines))
+		}
+	}
+}
+
+func TestDiffAttributesAndText(t *testing.T) {
+	old := `<root><item id="a" class="x"><name>Alpha</name><size>1</size></item></root>`
+	new := `<root><item class="y" id="a"><name>Alpha</name><size>2</size></item></root>`
+	lines, pairs := Diff(mustParse(t, old, false), mustParse(t, new, false))
+	want := `  <root>
+-   <item class="x" id="a">  # /root/item[@id="a"]
++   <item class="y" id="a">  # /root/item[@id="a"]
+      <name>Alpha</name>
+-     <size>1</size>  # /root/item[@id="a"]/size
++     <size>2</size>  # /root/item[@id="a"]/size
+    </item>
+  </root>
+`
+	if got := format(lines); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if len(pairs) != 2 {
+		t.Errorf("expected the tag and text changes as pairs, got %v", pairs)
+	}
+}
+
+func TestDiffKeyedElements(t *testing.T) {
+	old := `<list><row id="1"><v>a</v></row><row id="2"><v>b</v></row></list>`
+	new := `<list><row id="3"><v>c</v></row><row id="1"><v>a</v></row><row id="2"><v>B</v></row></list>`
+	lines, _ := Diff(mustParse(t, old, false), mustParse(t, new, false))
+	got := format(lines)
+	if !strings.Contains(got, "+   <row id=\"3\">  # /list/row[@id=\"3\"]\n") {
+		t.Errorf("expected row 3 added whole, got:\n%s", got)
+	}
+	if !strings.Contains(got, "-     <v>b</v>  # /list/row[@id=\"2\"]/v\n+     <v>B</v>") {
+		t.Errorf("expected row 2 diffed in place, got:\n%s", got)
+	}
+	if strings.Contains(got, "row[@id=\"1\"]") {
+		t.Errorf("row 1 is unchanged, got:\n%s", got)
+	}
+}
+
+func TestDiffUnkeyedSiblings(t *testing.T) {
+	old := `<r><a>1</a><b/><a>2</a></r>`
+	new := `<r><a>1</a><b/><a>3</a></r>`
+	lines, pairs := Diff(mustParse(t, old, false), mustParse(t, new, false))
+	got := format(lines)
+	if !strings.Contains(got, "-   <a>2</a>  # /r/a[2]\n+   <a>3</a>  # /r/a[2]\n") {
+		t.Errorf("expected the second <a> changed, got:\n%s", got)
+	}
+	if len(pairs) != 1 {
+		t.Errorf("expected one pair, got %v", pairs)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	doc := mustParse(t, `<?xml version="1.0"?><!-- note --><a q='&quot;&lt;'>x &amp; y<e/></a>`, false)
+	lines, _ := Diff(Document{}, doc)
+	want := `+ <?xml version="1.0"?>  # /processing-instruction()
++ <!--note-->  # /comment()
++ <a q="&quot;&lt;">  # /a
++   x &amp; y  # /a/text()
++   <e/>  # /a/e
++ </a>  # /a
+`
+	if got := format(lines); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	html := mustParse(t, `<div><br><span></span><img src=a></div>`, true)
+	lines, _ = Diff(Document{html: true}, html)
+	if got := format(lines); !strings.Contains(got, "+   <br>  #") || !strings.Contains(got, "+   <span></span>  #") {
+		t.Errorf("expected HTML void and empty elements, got:\n%s", got)
+	}
+}
+
+func TestDiffSignificantWhitespace(t *testing.T) {
+	tests := []struct {
+		old, new string
+		html     bool
+	}{
+		{`<p>Hello <b>world</b></p>`, `<p>Hello<b>world</b></p>`, true},
+		{`<p><b>Hello</b> world</p>`, `<p><b>Hello</b>world</p>`, true},
+		{"<pre>a  b\n  c</pre>", "<pre>a b\n  c</pre>", true},
+		{"<textarea>x </textarea>", "<textarea>x</textarea>", true},
+		{`<a xml:space="preserve"><b>x  y</b></a>`, `<a xml:space="preserve"><b>x y</b></a>`, false},
+	}
+	for _, tt := range tests {
+		if lines, _ := Diff(mustParse(t, tt.old, tt.html), mustParse(t, tt.new, tt.html)); lines == nil {
+			t.Errorf("Diff(%q, %q) should show the whitespace change", tt.old, tt.new)
+		}
+	}
+
+	// Whitespace at the edges of an element's content, and a newline
+	// opening a <pre>, still don't matter.
+	for _, tt := range []struct{ old, new string }{
+		{"<p>\n  Hello <b>world</b>\n</p>", `<p>Hello <b>world</b></p>`},
+		{"<pre>\ncode</pre>", "<pre>code</pre>"},
+	} {
+		if lines, _ := Diff(mustParse(t, tt.old, true), mustParse(t, tt.new, true)); lines != nil {
+			t.Errorf("Diff(%q, %q) should be empty, got:\n%s", tt.old, tt.new, format(lines))
+		}
+	}
+}
+
+func TestParseRawText(t *testing.T) {
+	old := mustParse(t, `<script>if (a < b && c) { go("</p>") }</script><STYLE>p > b {}</STYLE>`, true)
+	lines, _ := Diff(Document{html: true}, old)
+	want := `+ <script>if (a < b && c) { go("</p>") }</script>  # /script
++ <style>p > b {}</style>  # /style
+`
+	if got := format(lines); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestParseRawTextAfterCaseChangingText(t *testing.T) {
+	// İ lowercases to a longer sequence; the script must still be found
+	// at its offset in the input.
+	text := strings.Repeat("İ", 40)
+	doc := mustParse(t, "<p>"+text+"</p><script>if (a < b) {}</script>", true)
+	lines, _ := Diff(Document{html: true}, doc)
+	want := "+ <p>" + text + "</p>  # /p\n+ <script>if (a < b) {}</script>  # /script\n"
+	if got := format(lines); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatPreformatted(t *testing.T) {
+	doc := mustParse(t, "<div><pre>line 1\n  line 2</pre></div>", true)
+	lines, _ := Diff(Document{html: true}, doc)
+	want := `+ <div>  # /div
++   <pre>  # /div/pre
++     line 1  # /div/pre/text()
++       line 2  # /div/pre/text()
++   </pre>  # /div/pre
++ </div>  # /div
+`
+	if got := format(lines); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
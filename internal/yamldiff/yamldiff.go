@@ -0,0 +1,524 @@
+// Package yamldiff compares multi-document YAML structurally and lays
+// out the result as a line diff of the documents in block style.
+package yamldiff
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/amterp/go-delta/internal/diff"
+	"github.com/amterp/go-delta/internal/structdiff"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultDocumentKey identifies Kubernetes manifests.
+var DefaultDocumentKey = []string{"apiVersion", "kind", "metadata.name"}
+
+// Options controls how documents are compared.
+type Options struct {
+	// DocumentKey lists the dot-separated paths of the scalars that
+	// identify a document, such as "metadata.name". Documents with the
+	// same values are compared with each other wherever they are in the
+	// stream. Documents missing any of them are matched by position
+	// among such documents. Empty means DefaultDocumentKey.
+	DocumentKey []string
+}
+
+// Document is where a compared document starts in the diff.
+type Document struct {
+	ID    string // the document's key values, space-separated
+	Start int    // index of its "---" line
+}
+
+// Result is a structural diff of two YAML streams.
+type Result struct {
+	Lines     []diff.Line
+	Pairs     []diff.Pair // removed and added lines for the same value
+	Documents []Document
+}
+
+// Parse decodes a YAML stream into its documents' root nodes, skipping
+// empty documents.
+func Parse(data []byte) ([]*yaml.Node, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	var docs []*yaml.Node
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(doc.Content) > 0 && !isEmpty(doc.Content[0]) {
+			docs = append(docs, doc.Content[0])
+		}
+	}
+}
+
+// isEmpty reports whether a document's root is the implicit null of a
+// document with no content.
+func isEmpty(root *yaml.Node) bool {
+	return root.Kind == yaml.ScalarNode && root.ShortTag() == "!!null" && root.Value == ""
+}
+
+// Diff compares two parsed streams, as returned by Parse. Documents are
+// matched by key (see Options) and each is shown after a "---" line, in
+// the new stream's order; removed documents follow the document before
+// them in the old stream. Mappings are compared by key, keeping the new
+// document's key order, and sequences by content and position. Changed
+// lines are labeled with their path in the document, such as
+// ".spec.replicas", in OldLabel or NewLabel. Comments, quoting and
+// indentation don't show as changes.
+func Diff(old, new []*yaml.Node, opts Options) Result {
+	keys := opts.DocumentKey
+	if len(keys) == 0 {
+		keys = DefaultDocumentKey
+	}
+	oldIDs, newIDs := documentIDs(old, keys), documentIDs(new, keys)
+
+	d := differ{}
+	for _, m := range structdiff.MatchByID(oldIDs, newIDs) {
+		if m.New < 0 {
+			d.documents = append(d.documents, Document{ID: displayID(oldIDs[m.Old]), Start: len(d.Lines)})
+			d.Emit(diff.OpDelete, structdiff.Printed{Text: "---", Path: "."})
+			d.EmitAll(diff.OpDelete, render(old[m.Old], "", "", true, "."))
+			continue
+		}
+		d.documents = append(d.documents, Document{ID: displayID(newIDs[m.New]), Start: len(d.Lines)})
+		if m.Old >= 0 {
+			d.Emit(diff.OpEqual, structdiff.Printed{Text: "---"})
+			d.value(old[m.Old], new[m.New], node{inline: true, oldPath: ".", newPath: "."})
+		} else {
+			d.Emit(diff.OpInsert, structdiff.Printed{Text: "---", Path: "."})
+			d.EmitAll(diff.OpInsert, render(new[m.New], "", "", true, "."))
+		}
+	}
+	return Result{Lines: d.Lines, Pairs: d.Pairs, Documents: d.documents}
+}
+
+// documentIDs returns each document's ID: its key values, or its
+// position among documents without all of them.
+func documentIDs(docs []*yaml.Node, keys []string) []string {
+	ids := make([]string, len(docs))
+	unkeyed := 0
+	for i, doc := range docs {
+		var vals []string
+		for _, k := range keys {
+			n := lookup(doc, strings.Split(k, "."))
+			if n == nil || n.Kind != yaml.ScalarNode {
+				vals = nil
+				break
+			}
+			vals = append(vals, n.Value)
+		}
+		if vals == nil {
+			ids[i] = fmt.Sprintf("#%d", unkeyed)
+			unkeyed++
+			continue
+		}
+		ids[i] = "=" + strings.Join(vals, " ")
+	}
+	return ids
+}
+
+// displayID returns the ID of a keyed document for display, or "" for
+// documents matched by position.
+func displayID(id string) string {
+	if k, ok := strings.CutPrefix(id, "="); ok {
+		return k
+	}
+	return ""
+}
+
+// lookup follows a path of mapping keys from n.
+func lookup(n *yaml.Node, path []string) *yaml.Node {
+	for _, k := range path {
+		n = resolve(n)
+		if n.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == k {
+				next = n.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		n = next
+	}
+	return resolve(n)
+}
+
+// resolve follows aliases to the node they refer to.
+func resolve(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	return n
+}
+
+// Equal reports whether two nodes have the same content, comparing
+// scalars by their decoded values, so 0x10 and 16 are equal but "1"
+// and 1 aren't.
+func Equal(a, b *yaml.Node) bool {
+	a, b = resolve(a), resolve(b)
+	if a.Kind != b.Kind {
+		return false
+	}
+	switch a.Kind {
+	case yaml.ScalarNode:
+		if a.ShortTag() != b.ShortTag() {
+			return false
+		}
+		if a.Value == b.Value {
+			return true
+		}
+		var av, bv any
+		if a.Decode(&av) != nil || b.Decode(&bv) != nil {
+			return false
+		}
+		return reflect.DeepEqual(av, bv)
+	case yaml.MappingNode:
+		if len(a.Content) != len(b.Content) {
+			return false
+		}
+		for i := 0; i+1 < len(a.Content); i += 2 {
+			bv := value(b, a.Content[i].Value)
+			if bv == nil || !Equal(a.Content[i+1], bv) {
+				return false
+			}
+		}
+		return true
+	case yaml.SequenceNode:
+		if len(a.Content) != len(b.Content) {
+			return false
+		}
+		for i := range a.Content {
+			if !Equal(a.Content[i], b.Content[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return true
+}
+
+// value returns the value of key in mapping m, or nil.
+func value(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// node is where a value is printed. first is the text before the value
+// on its first line on each side, such as "  image: " or "  - ", and
+// cont is the indentation of its following lines. inline is set where
+// a mapping or sequence can start on the first line (after "- " or at
+// the top level) rather than below a key.
+type node struct {
+	oldFirst, newFirst string
+	cont               string
+	inline             bool
+	oldPath, newPath   string
+}
+
+type differ struct {
+	structdiff.Builder
+	documents []Document
+}
+
+// value diffs old and new at n.
+func (d *differ) value(old, new *yaml.Node, n node) {
+	old, new = resolve(old), resolve(new)
+	if Equal(old, new) {
+		// Equal values print the same but for the first line's lead.
+		ps := render(new, n.newFirst, n.cont, n.inline, n.newPath)
+		d.EmitLine(render(new, n.oldFirst, n.cont, n.inline, n.oldPath)[0], ps[0])
+		d.EmitAll(diff.OpEqual, ps[1:])
+		return
+	}
+
+	if old.Kind == new.Kind && len(old.Content) > 0 && len(new.Content) > 0 {
+		switch old.Kind {
+		case yaml.MappingNode:
+			d.mapping(old, new, n)
+			return
+		case yaml.SequenceNode:
+			d.sequence(old, new, n)
+			return
+		}
+	}
+	oldLines := render(old, n.oldFirst, n.cont, n.inline, n.oldPath)
+	newLines := render(new, n.newFirst, n.cont, n.inline, n.newPath)
+	if len(oldLines) == 1 && len(newLines) == 1 {
+		d.EmitPair(oldLines[0], newLines[0])
+		return
+	}
+	d.EmitAll(diff.OpDelete, oldLines)
+	d.EmitAll(diff.OpInsert, newLines)
+}
+
+// header emits the "key:" line above a mapping or sequence that isn't
+// inline, and returns the first-line texts of its entries.
+func (d *differ) header(n node) (oldFirst, newFirst string) {
+	if n.inline {
+		return n.oldFirst, n.newFirst
+	}
+	d.EmitLine(structdiff.Printed{Text: strings.TrimRight(n.oldFirst, " "), Path: n.oldPath}, structdiff.Printed{Text: strings.TrimRight(n.newFirst, " "), Path: n.newPath})
+	return n.cont, n.cont
+}
+
+// mapping diffs two non-empty mappings key by key, in the new mapping's
+// key order. Removed keys follow the key before them in the old one.
+func (d *differ) mapping(old, new *yaml.Node, n node) {
+	oldFirst, newFirst := d.header(n)
+	oldKeys, newKeys := keys(old), keys(new)
+
+	inNew := map[string]bool{}
+	for _, k := range newKeys {
+		inNew[k] = true
+	}
+	// Removed keys, by the surviving key before them ("" for first).
+	removedAfter := map[string][]int{}
+	prev := ""
+	for i, k := range oldKeys {
+		if inNew[k] {
+			prev = k
+			continue
+		}
+		removedAfter[prev] = append(removedAfter[prev], i)
+	}
+
+	oldIdx := map[string]int{}
+	for i, k := range oldKeys {
+		oldIdx[k] = i
+	}
+	// entryNode places the entry for k at the given positions, -1 where
+	// it's missing.
+	entryNode := func(k string, oldPos, newPos int) node {
+		c := node{cont: n.cont + "  ", oldPath: keyPath(n.oldPath, k), newPath: keyPath(n.newPath, k)}
+		if oldPos >= 0 {
+			c.oldFirst = n.cont
+			if oldPos == 0 {
+				c.oldFirst = oldFirst
+			}
+			c.oldFirst += keyText(old.Content[2*oldPos], k) + ": "
+		}
+		if newPos >= 0 {
+			c.newFirst = n.cont
+			if newPos == 0 {
+				c.newFirst = newFirst
+			}
+			c.newFirst += keyText(new.Content[2*newPos], k) + ": "
+		}
+		return c
+	}
+	removed := func(after string) {
+		for _, i := range removedAfter[after] {
+			k := oldKeys[i]
+			c := entryNode(k, i, -1)
+			d.EmitAll(diff.OpDelete, render(old.Content[2*i+1], c.oldFirst, c.cont, false, c.oldPath))
+		}
+	}
+
+	removed("")
+	for j, k := range newKeys {
+		nv := new.Content[2*j+1]
+		if i, ok := oldIdx[k]; ok {
+			d.value(old.Content[2*i+1], nv, entryNode(k, i, j))
+		} else {
+			c := entryNode(k, -1, j)
+			d.EmitAll(diff.OpInsert, render(nv, c.newFirst, c.cont, false, c.newPath))
+		}
+		removed(k)
+	}
+}
+
+// sequence diffs two non-empty sequences, matching their items by
+// content and diffing removed and added items next to each other
+// pairwise when they are of the same kind.
+func (d *differ) sequence(old, new *yaml.Node, n node) {
+	oldFirst, newFirst := d.header(n)
+	ids := func(items []*yaml.Node) []string {
+		out := make([]string, len(items))
+		for i, item := range items {
+			var b strings.Builder
+			for _, p := range render(item, "", "", true, "") {
+				b.WriteString(p.Text + "\n")
+			}
+			out[i] = b.String()
+		}
+		return out
+	}
+
+	child := func(i, j int) node {
+		c := node{cont: n.cont + "  ", inline: true}
+		if i >= 0 {
+			c.oldFirst = n.cont
+			if i == 0 {
+				c.oldFirst = oldFirst
+			}
+			c.oldFirst += "- "
+			c.oldPath = fmt.Sprintf("%s[%d]", n.oldPath, i)
+		}
+		if j >= 0 {
+			c.newFirst = n.cont
+			if j == 0 {
+				c.newFirst = newFirst
+			}
+			c.newFirst += "- "
+			c.newPath = fmt.Sprintf("%s[%d]", n.newPath, j)
+		}
+		return c
+	}
+	structdiff.WalkMatches(structdiff.ScriptMatches(diff.EditScript(ids(old.Content), ids(new.Content))),
+		func(i, j int) bool { return pairable(old.Content[i], new.Content[j]) },
+		func(i, j int) { d.value(old.Content[i], new.Content[j], child(i, j)) },
+		func(i int) {
+			c := child(i, -1)
+			d.EmitAll(diff.OpDelete, render(old.Content[i], c.oldFirst, c.cont, true, c.oldPath))
+		},
+		func(j int) {
+			c := child(-1, j)
+			d.EmitAll(diff.OpInsert, render(new.Content[j], c.newFirst, c.cont, true, c.newPath))
+		})
+}
+
+// pairable reports whether a removed and an added sequence item should
+// be diffed as one changed item: both mappings or both sequences.
+func pairable(old, new *yaml.Node) bool {
+	old, new = resolve(old), resolve(new)
+	return old.Kind == new.Kind && old.Kind != yaml.ScalarNode
+}
+
+// render prints n in block style, with first before its first line and
+// cont as the indentation of its following lines. Lines are labeled
+// with the path of the value they print, starting from path.
+func render(n *yaml.Node, first, cont string, inline bool, path string) []structdiff.Printed {
+	n = resolve(n)
+	switch n.Kind {
+	case yaml.MappingNode:
+		if len(n.Content) == 0 {
+			return []structdiff.Printed{{Text: first + "{}", Path: path}}
+		}
+		var out []structdiff.Printed
+		entryFirst := first
+		if !inline {
+			out = append(out, structdiff.Printed{Text: strings.TrimRight(first, " "), Path: path})
+			entryFirst = cont
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k := n.Content[i].Value
+			lead := cont
+			if i == 0 {
+				lead = entryFirst
+			}
+			out = append(out, render(n.Content[i+1], lead+keyText(n.Content[i], k)+": ", cont+"  ", false, keyPath(path, k))...)
+		}
+		return out
+	case yaml.SequenceNode:
+		if len(n.Content) == 0 {
+			return []structdiff.Printed{{Text: first + "[]", Path: path}}
+		}
+		var out []structdiff.Printed
+		itemFirst := first
+		if !inline {
+			out = append(out, structdiff.Printed{Text: strings.TrimRight(first, " "), Path: path})
+			itemFirst = cont
+		}
+		for i, item := range n.Content {
+			lead := cont
+			if i == 0 {
+				lead = itemFirst
+			}
+			out = append(out, render(item, lead+"- ", cont+"  ", true, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return out
+	default:
+		text := scalarText(n)
+		lines := strings.Split(text, "\n")
+		out := []structdiff.Printed{{Text: first + lines[0], Path: path}}
+		for _, l := range lines[1:] {
+			if l == "" {
+				out = append(out, structdiff.Printed{Text: "", Path: path})
+				continue
+			}
+			out = append(out, structdiff.Printed{Text: cont + l, Path: path})
+		}
+		return out
+	}
+}
+
+// scalarText formats a scalar as yaml.v3 would, dropping its original
+// quoting style. Multi-line strings become literal blocks, whose lines
+// render indents.
+func scalarText(n *yaml.Node) string {
+	if n.ShortTag() == "!!str" && strings.Contains(n.Value, "\n") {
+		body := strings.TrimSuffix(n.Value, "\n")
+		header := "|"
+		switch {
+		case !strings.HasSuffix(n.Value, "\n"):
+			header = "|-"
+		case strings.HasSuffix(body, "\n"):
+			header = "|+"
+		}
+		if strings.HasPrefix(body, " ") {
+			header = header[:1] + "2" + header[1:]
+		}
+		var b strings.Builder
+		b.WriteString(header)
+		for _, l := range strings.Split(body, "\n") {
+			b.WriteString("\n" + l)
+		}
+		return b.String()
+	}
+	out, err := yaml.Marshal(&yaml.Node{Kind: yaml.ScalarNode, Tag: n.Tag, Value: n.Value})
+	if err != nil {
+		return n.Value
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
+
+// keyText formats a mapping key.
+func keyText(k *yaml.Node, name string) string {
+	if k.Kind != yaml.ScalarNode {
+		return name
+	}
+	return scalarText(k)
+}
+
+// keys returns a mapping's keys in order.
+func keys(m *yaml.Node) []string {
+	out := make([]string, 0, len(m.Content)/2)
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		out = append(out, m.Content[i].Value)
+	}
+	return out
+}
+
+// identifier matches keys that can be written as .key in a path.
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// keyPath returns the path of the key k of the mapping at path.
+func keyPath(path, k string) string {
+	if path == "." {
+		path = ""
+	}
+	if identifier.MatchString(k) {
+		return path + "." + k
+	}
+	return path + "[" + fmt.Sprintf("%q", k) + "]"
+}
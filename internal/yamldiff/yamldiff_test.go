@@ -0,0 +1,170 @@
+package yamldiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/amterp/go-delta/internal/diff"
+	"gopkg.in/yaml.v3"
+)
+
+func mustParse(t *testing.T, s string) []*yaml.Node {
+	t.Helper()
+	docs, err := Parse([]byte(s))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return docs
+}
+
+// format renders lines as "<op> <content>  # <label>" rows.
+func format(lines []diff.Line) string {
+	var b strings.Builder
+	for _, l := range lines {
+		switch l.Kind {
+		case diff.OpEqual:
+			b.WriteString("  " + l.Content + "\n")
+		case diff.OpDelete:
+			b.WriteString("- " + l.Content + "  # " + l.OldLabel + "\n")
+		case diff.OpInsert:
+			b.WriteString("+ " + l.Content + "  # " + l.NewLabel + "\n")
+		}
+	}
+	return b.String()
+}
+
+func changes(lines []diff.Line) int {
+	n := 0
+	for _, l := range lines {
+		if l.Kind != diff.OpEqual {
+			n++
+		}
+	}
+	return n
+}
+
+func TestParseMultiDocument(t *testing.T) {
+	docs := mustParse(t, "a: 1\n---\n---\nb: 2\n")
+	if len(docs) != 2 {
+		t.Errorf("expected 2 documents (empty ones skipped), got %d", len(docs))
+	}
+	if _, err := Parse([]byte("a: [1\n")); err == nil {
+		t.Error("expected an error for invalid YAML")
+	}
+}
+
+func TestDiffIgnoresFormatting(t *testing.T) {
+	old := "a: 1\nb: {c: 'x', d: [1, 2]}\n"
+	new := "# comment\nb:\n  d:\n    - 1\n    - 0x2\n  c: x\na: 1\n"
+	if n := changes(Diff(mustParse(t, old), mustParse(t, new), Options{}).Lines); n != 0 {
+		t.Errorf("expected no changes, got %d", n)
+	}
+}
+
+func TestDiffScalarTypes(t *testing.T) {
+	r := Diff(mustParse(t, "a: 1\n"), mustParse(t, "a: \"1\"\n"), Options{})
+	want := `  ---
+- a: 1  # .a
++ a: "1"  # .a
+`
+	if got := format(r.Lines); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if len(r.Pairs) != 1 || r.Pairs[0] != (diff.Pair{Old: 1, New: 2}) {
+		t.Errorf("pairs = %v", r.Pairs)
+	}
+}
+
+func TestDiffNested(t *testing.T) {
+	old := "spec:\n  containers:\n    - name: web\n      image: nginx:1.25\n"
+	new := "spec:\n  containers:\n    - name: web\n      image: nginx:1.27\n    - name: log\n      image: busybox\n"
+	want := `  ---
+  spec:
+    containers:
+      - name: web
+-       image: nginx:1.25  # .spec.containers[0].image
++       image: nginx:1.27  # .spec.containers[0].image
++     - name: log  # .spec.containers[1].name
++       image: busybox  # .spec.containers[1].image
+`
+	if got := format(Diff(mustParse(t, old), mustParse(t, new), Options{}).Lines); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffFirstKeyOfItemChanges(t *testing.T) {
+	old := "- a: 1\n  b: 2\n"
+	new := "- b: 2\n"
+	want := `  ---
+- - a: 1  # .[0].a
+-   b: 2  # .[0].b
++ - b: 2  # .[0].b
+`
+	if got := format(Diff(mustParse(t, old), mustParse(t, new), Options{}).Lines); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffMatchesDocuments(t *testing.T) {
+	svc := "apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n"
+	cm := func(v string) string {
+		return "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\ndata:\n  v: " + v + "\n"
+	}
+	r := Diff(mustParse(t, svc+"---\n"+cm("a")), mustParse(t, cm("b")+"---\n"+svc), Options{})
+	if n := changes(r.Lines); n != 2 {
+		t.Errorf("expected only data.v to change, got:\n%s", format(r.Lines))
+	}
+	if len(r.Documents) != 2 || r.Documents[0].ID != "v1 ConfigMap cfg" || r.Documents[1].Start != 8 {
+		t.Errorf("documents = %+v", r.Documents)
+	}
+}
+
+func TestDiffDocumentKey(t *testing.T) {
+	old := "id: 1\nv: a\n---\nid: 2\nv: b\n"
+	new := "id: 2\nv: b\n---\nid: 1\nv: c\n"
+	r := Diff(mustParse(t, old), mustParse(t, new), Options{DocumentKey: []string{"id"}})
+	if n := changes(r.Lines); n != 2 {
+		t.Errorf("expected only v of id 1 to change, got:\n%s", format(r.Lines))
+	}
+	// Without the key, documents lacking the default key match by position.
+	if n := changes(Diff(mustParse(t, old), mustParse(t, new), Options{}).Lines); n != 8 {
+		t.Errorf("expected positional matching, got %d changes", n)
+	}
+}
+
+func TestDiffRemovedDocument(t *testing.T) {
+	old := "id: 1\n---\nid: 2\n---\nid: 3\n"
+	new := "id: 1\n---\nid: 3\n"
+	r := Diff(mustParse(t, old), mustParse(t, new), Options{DocumentKey: []string{"id"}})
+	want := `  ---
+  id: 1
+- ---  # .
+- id: 2  # .id
+  ---
+  id: 3
+`
+	if got := format(r.Lines); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderLiteralBlock(t *testing.T) {
+	docs := mustParse(t, "k:\n  s: \"line one\\nline two\\n\"\n")
+	var got []string
+	for _, p := range render(docs[0], "", "", true, ".") {
+		got = append(got, p.Text)
+	}
+	want := "k:\n  s: |\n    line one\n    line two"
+	if strings.Join(got, "\n") != want {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), want)
+	}
+}
+
+func TestKeyPath(t *testing.T) {
+	if got := keyPath(".", "spec"); got != ".spec" {
+		t.Errorf("got %s", got)
+	}
+	if got := keyPath(".metadata.labels", "app.kubernetes.io/name"); got != `.metadata.labels["app.kubernetes.io/name"]` {
+		t.Errorf("got %s", got)
+	}
+}
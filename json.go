@@ -0,0 +1,61 @@
+package godelta
+
+import (
+	"fmt"
+
+	"github.com/amterp/go-delta/internal/align"
+	"github.com/amterp/go-delta/internal/diff"
+	"github.com/amterp/go-delta/internal/jsondiff"
+)
+
+// DiffJSON compares two JSON documents structurally and renders the
+// differences in their canonical pretty-printed form: objects with
+// sorted keys and two-space indentation. Key order and formatting never
+// show as changes, and numbers compare by value. Array elements are
+// matched by content and position by default; see WithJSONArrayKeys and
+// WithJSONIgnoreArrayOrder. Gutters show the JSON path of each changed
+// line (such as $.address.zip) instead of its line number, and with
+// WithHunkHeaders, headers show the path of the hunk's first change.
+// Returns an empty string if the documents are equal, or an error if
+// either isn't valid JSON.
+func DiffJSON(old, new []byte, opts ...Option) (string, error) {
+	oldValue, err := jsondiff.Parse(old)
+	if err != nil {
+		return "", fmt.Errorf("old JSON: %w", err)
+	}
+	newValue, err := jsondiff.Parse(new)
+	if err != nil {
+		return "", fmt.Errorf("new JSON: %w", err)
+	}
+
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	lines, pairs := jsondiff.Diff(oldValue, newValue, jsondiff.Options{
+		ArrayKeys:        cfg.jsonArrayKeys,
+		IgnoreArrayOrder: cfg.jsonIgnoreArrayOrder,
+	})
+	hunks := diff.ComputeHunks(lines, cfg.contextLines)
+	if len(hunks) == 0 {
+		return "", nil
+	}
+	if cfg.hunkHeaders {
+		setPathContext(hunks)
+	}
+	return renderHunks(align.AnnotatePairs(hunks, pairs), cfg, resolveStyles(cfg)), nil
+}
+
+// setPathContext sets each hunk's Context to the path labeling its first
+// changed line.
+func setPathContext(hunks []diff.Hunk) {
+	for i, h := range hunks {
+		for _, l := range h.Lines {
+			if path := l.OldLabel + l.NewLabel; path != "" {
+				hunks[i].Context = path
+				break
+			}
+		}
+	}
+}
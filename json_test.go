@@ -0,0 +1,85 @@
+package godelta
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffJSONEquivalent(t *testing.T) {
+	out, err := DiffJSON([]byte(`{"a": 1, "b": [1, 2]}`), []byte("{\n  \"b\": [1, 2.0],\n  \"a\": 1\n}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "" {
+		t.Errorf("reordered, reformatted JSON should have no diff, got:\n%s", out)
+	}
+}
+
+func TestDiffJSONLargeIntegers(t *testing.T) {
+	// Both IDs round to the same float64.
+	out, err := DiffJSON([]byte(`{"id": 9007199254740993}`), []byte(`{"id": 9007199254740992}`), WithColor(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "9007199254740993") || !strings.Contains(out, "9007199254740992") {
+		t.Errorf("expected distinct 64-bit IDs to differ, got:\n%s", out)
+	}
+}
+
+func TestDiffJSONInvalid(t *testing.T) {
+	if _, err := DiffJSON([]byte(`{`), []byte(`{}`)); err == nil || !strings.Contains(err.Error(), "old JSON") {
+		t.Errorf("expected an old JSON error, got %v", err)
+	}
+	if _, err := DiffJSON([]byte(`{}`), []byte(`[1,]`)); err == nil || !strings.Contains(err.Error(), "new JSON") {
+		t.Errorf("expected a new JSON error, got %v", err)
+	}
+}
+
+func TestDiffJSONPaths(t *testing.T) {
+	out, err := DiffJSON([]byte(`{"user": {"zip": "1"}}`), []byte(`{"user": {"zip": "2"}}`), WithColor(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, `"zip"`) && !strings.HasPrefix(strings.TrimSpace(line), "$.user.zip ") {
+			t.Errorf("expected the path in the gutter, got %q", line)
+		}
+	}
+}
+
+func TestDiffJSONHunkHeaders(t *testing.T) {
+	out, err := DiffJSON([]byte(`{"a": 1, "b": 2}`), []byte(`{"a": 1, "b": 3}`),
+		WithColor(false), WithContextLines(0), WithHunkHeaders(Language{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "@@ -3 +3 @@ $.b\n") {
+		t.Errorf("expected a header with the change's path, got:\n%s", out)
+	}
+}
+
+func TestDiffJSONIgnoreArrayOrder(t *testing.T) {
+	old, new := []byte(`{"tags": ["a", "b", "c"]}`), []byte(`{"tags": ["c", "a", "b"]}`)
+	out, err := DiffJSON(old, new, WithJSONIgnoreArrayOrder(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "" {
+		t.Errorf("expected no diff, got:\n%s", out)
+	}
+	if out, _ := DiffJSON(old, new); out == "" {
+		t.Error("array order should matter by default")
+	}
+}
+
+func TestDiffJSONArrayKeys(t *testing.T) {
+	old := []byte(`[{"id": 1, "role": "admin"}, {"id": 2, "role": "user"}]`)
+	new := []byte(`[{"id": 2, "role": "owner"}, {"id": 1, "role": "admin"}]`)
+	out, err := DiffJSON(old, new, WithColor(false), WithJSONArrayKeys("id"), WithJSONIgnoreArrayOrder(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out, "│ -") != 1 || strings.Count(out, "│ +") != 1 || !strings.Contains(out, `"role": "owner"`) {
+		t.Errorf("expected only the role of id 2 to change, got:\n%s", out)
+	}
+}
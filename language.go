@@ -0,0 +1,217 @@
+package godelta
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/amterp/go-delta/internal/diff"
+)
+
+// Language describes how to recognize the lines that start a function,
+// class or similar section in a programming language's source, for
+// hunk headers. The zero Language recognizes nothing.
+type Language struct {
+	Name     string
+	patterns []funcPattern
+}
+
+// funcPattern is one line of a funcname definition.
+type funcPattern struct {
+	re     *regexp.Regexp
+	negate bool
+}
+
+// NewLanguage defines a language by its funcname patterns, in the format
+// of git's diff.<driver>.xfuncname: one regular expression per line,
+// tried in order. The first pattern matching a line decides: a line
+// starting with "!" rejects the line, any other pattern accepts it.
+// The section name shown is the pattern's first submatch, or the whole
+// match if it has none.
+func NewLanguage(name, funcname string) (Language, error) {
+	lang := Language{Name: name}
+	for _, p := range strings.Split(funcname, "\n") {
+		if p == "" {
+			continue
+		}
+		negate := strings.HasPrefix(p, "!")
+		re, err := regexp.Compile(strings.TrimPrefix(p, "!"))
+		if err != nil {
+			return Language{}, fmt.Errorf("language %s: %w", name, err)
+		}
+		lang.patterns = append(lang.patterns, funcPattern{re: re, negate: negate})
+	}
+	return lang, nil
+}
+
+func mustLanguage(name, funcname string) Language {
+	lang, err := NewLanguage(name, funcname)
+	if err != nil {
+		panic(err)
+	}
+	return lang
+}
+
+// Built-in languages, with funcname patterns adapted from git's.
+var (
+	LangGo = mustLanguage("go",
+		`^[ \t]*(func[ \t]*.*(\{[ \t]*)?)$`+"\n"+
+			`^[ \t]*(type[ \t].*(struct|interface)[ \t]*(\{[ \t]*)?)$`)
+
+	LangPython = mustLanguage("python",
+		`^[ \t]*((class|(async[ \t]+)?def)[ \t].*)$`)
+
+	LangJavaScript = mustLanguage("javascript",
+		`!^[ \t]*((if|else|for|while|do|switch|catch|return)\b|function[ \t]*\()`+"\n"+
+			`^[ \t]*((export[ \t]+)?(default[ \t]+)?(async[ \t]+)?function\b.*)$`+"\n"+
+			`^[ \t]*((export[ \t]+)?(default[ \t]+)?(abstract[ \t]+)?class[ \t].*)$`+"\n"+
+			`^[ \t]*((export[ \t]+)?(const|let|var)[ \t]+[A-Za-z_$][\w$]*[ \t]*=[ \t]*(async[ \t]+)?(function\b|\([^)]*\)[ \t]*=>|[A-Za-z_$][\w$]*[ \t]*=>).*)$`+"\n"+
+			`^[ \t]*(((async|static|get|set)[ \t]+)*[A-Za-z_$][\w$]*[ \t]*\([^;]*\)[ \t]*\{[ \t]*)$`)
+
+	LangJava = mustLanguage("java",
+		`!^[ \t]*(catch|do|for|if|instanceof|new|return|switch|throw|while)\b`+"\n"+
+			`^[ \t]*(([A-Za-z_][A-Za-z_0-9]*[ \t]+)+[A-Za-z_][A-Za-z_0-9]*[ \t]*\([^;]*)$`)
+
+	LangC = mustLanguage("c",
+		`!^[ \t]*[A-Za-z_][A-Za-z_0-9]*:[[:space:]]*($|/[/*])`+"\n"+
+			`^((::[[:space:]]*)?[A-Za-z_].*)$`)
+)
+
+// funcName returns the section name if line starts a section.
+func (l Language) funcName(line string) (string, bool) {
+	for _, p := range l.patterns {
+		m := p.re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if p.negate {
+			return "", false
+		}
+		name := m[0]
+		if len(m) > 1 {
+			name = m[1]
+		}
+		return strings.TrimRightFunc(name, func(r rune) bool { return r == ' ' || r == '\t' || r == '\r' }), true
+	}
+	return "", false
+}
+
+// setFuncContext sets each hunk's Context to the nearest line of the old
+// text before the hunk that starts a section in lang, as git does for
+// hunk headers.
+func setFuncContext(lines []diff.Line, hunks []diff.Hunk, lang Language) {
+	if len(lang.patterns) == 0 {
+		return
+	}
+	var oldLines []string
+	for _, l := range lines {
+		if l.Kind != diff.OpInsert {
+			oldLines = append(oldLines, l.Content)
+		}
+	}
+	for i := range hunks {
+		// OldStart is 1-based, so the line before the hunk is at
+		// index OldStart-2.
+		for j := min(hunks[i].OldStart-2, len(oldLines)-1); j >= 0; j-- {
+			if name, ok := lang.funcName(oldLines[j]); ok {
+				hunks[i].Context = name
+				break
+			}
+		}
+	}
+}
+
+// findBlock is a diff.BlockFinder for lang's functions. The block
+// starts at the nearest line at or before line i that starts a section
+// (see funcName), and ends at its matching closing brace or, for
+// languages without braces, before the next line indented no deeper
+// than the section's first line. Braces inside strings and comments are
+// not told apart from code.
+func (l Language) findBlock(text []string, i int) (start, end int, ok bool) {
+	start = -1
+	for j := i; j >= 0; j-- {
+		if _, ok := l.funcName(text[j]); ok {
+			start = j
+			break
+		}
+	}
+	if start < 0 {
+		return 0, 0, false
+	}
+	end, ok = braceBlockEnd(text, start)
+	if !ok {
+		end = indentBlockEnd(text, start)
+	}
+	if i >= end {
+		return 0, 0, false // i is after the section, not inside it
+	}
+	return start, end, true
+}
+
+// braceBlockEnd returns the end of the block starting at line start if
+// it is delimited by braces: the block must open on the first line, or
+// on a later line indented no deeper than it (such as the ") {" ending
+// a multi-line signature, or an Allman-style "{"). Deeper lines before
+// the opening brace are parameters and are skipped.
+func braceBlockEnd(text []string, start int) (int, bool) {
+	base := indentWidth(text[start])
+	depth := 0
+	opened := false
+	for k := start; k < len(text); k++ {
+		line := text[k]
+		if !opened && k > start && strings.TrimSpace(line) != "" && indentWidth(line) > base {
+			continue
+		}
+		for _, r := range line {
+			switch r {
+			case '{':
+				depth++
+				opened = true
+			case '}':
+				depth--
+			}
+		}
+		if opened && depth <= 0 {
+			return k + 1, true
+		}
+		if !opened && k > start && strings.TrimSpace(line) != "" {
+			return 0, false
+		}
+	}
+	return len(text), opened
+}
+
+// indentBlockEnd returns the end of the block starting at line start,
+// as delimited by indentation: it covers the following lines indented
+// deeper than the first, up to the last such non-blank line.
+func indentBlockEnd(text []string, start int) int {
+	base := indentWidth(text[start])
+	end := start + 1
+	for k := start + 1; k < len(text); k++ {
+		if strings.TrimSpace(text[k]) == "" {
+			continue
+		}
+		if indentWidth(text[k]) <= base {
+			break
+		}
+		end = k + 1
+	}
+	return end
+}
+
+// indentWidth returns the width of line's leading whitespace, counting
+// a tab as advancing to the next multiple of 8 columns.
+func indentWidth(line string) int {
+	w := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			w++
+		case '\t':
+			w += 8 - w%8
+		default:
+			return w
+		}
+	}
+	return w
+}
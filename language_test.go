@@ -0,0 +1,127 @@
+package godelta
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/amterp/go-delta/internal/diff"
+)
+
+func TestLanguageFuncName(t *testing.T) {
+	tests := []struct {
+		lang Language
+		line string
+		want string // "" = not a section start
+	}{
+		{LangGo, "func (s *Server) Handle(w http.ResponseWriter) {", "func (s *Server) Handle(w http.ResponseWriter) {"},
+		{LangGo, "type Config struct {", "type Config struct {"},
+		{LangGo, "\treturn nil", ""},
+		{LangGo, "\tgo func() {", ""},
+		{LangPython, "    async def fetch(self, url):", "async def fetch(self, url):"},
+		{LangPython, "class Handler(Base):", "class Handler(Base):"},
+		{LangPython, "    return x", ""},
+		{LangJavaScript, "export async function load(id) {", "export async function load(id) {"},
+		{LangJavaScript, "const add = (a, b) => a + b;", "const add = (a, b) => a + b;"},
+		{LangJavaScript, "  render() {", "render() {"},
+		{LangJavaScript, "  if (ok) {", ""},
+		{LangJavaScript, "class Widget extends Base {", "class Widget extends Base {"},
+		{LangJava, "    public static void main(String[] args) {", "public static void main(String[] args) {"},
+		{LangJava, "        return new Foo(x);", ""},
+		{LangJava, "        if (x) {", ""},
+		{LangC, "static int parse(const char *s)", "static int parse(const char *s)"},
+		{LangC, "out:", ""},
+		{LangC, "    x++;", ""},
+		{Language{}, "func f() {", ""},
+	}
+	for _, tt := range tests {
+		got, ok := tt.lang.funcName(tt.line)
+		if ok != (tt.want != "") || got != tt.want {
+			t.Errorf("%s: funcName(%q) = %q, %v; want %q", tt.lang.Name, tt.line, got, ok, tt.want)
+		}
+	}
+}
+
+func TestNewLanguageInvalidPattern(t *testing.T) {
+	if _, err := NewLanguage("bad", "^(unclosed"); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestNewLanguageNegation(t *testing.T) {
+	lang, err := NewLanguage("ini", "!^\\[skip\\]\n^\\[(.*)\\]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := lang.funcName("[skip]"); ok {
+		t.Error("negated pattern should reject the line")
+	}
+	if got, ok := lang.funcName("[core]"); !ok || got != "core" {
+		t.Errorf("got %q, %v", got, ok)
+	}
+}
+
+func TestSetFuncContext(t *testing.T) {
+	old := "package main\n\nfunc a() {\n\tx := 1\n\ty := 2\n\tz := 3\n\tw := 4\n}\n"
+	new := strings.Replace(old, "w := 4", "w := 5", 1)
+	lines := diff.Diff(old, new)
+	hunks := diff.ComputeHunks(lines, 1)
+	setFuncContext(lines, hunks, LangGo)
+	if len(hunks) != 1 || hunks[0].Context != "func a() {" {
+		t.Errorf("got %+v", hunks)
+	}
+
+	// A change on the function line itself looks further back.
+	lines = diff.Diff(old, strings.Replace(old, "func a()", "func b()", 1))
+	hunks = diff.ComputeHunks(lines, 0)
+	setFuncContext(lines, hunks, LangGo)
+	if hunks[0].Context != "" {
+		t.Errorf("expected no context before the first function, got %q", hunks[0].Context)
+	}
+}
+
+func TestFindBlockBraces(t *testing.T) {
+	text := strings.Split(`package p
+
+func a(
+	x int,
+) int {
+	if x > 0 {
+		return 1
+	}
+	return 0
+}
+
+var v = 1`, "\n")
+	for _, i := range []int{2, 5, 8, 9} {
+		start, end, ok := LangGo.findBlock(text, i)
+		if !ok || start != 2 || end != 10 {
+			t.Errorf("line %d: got [%d, %d) %v, want [2, 10)", i, start, end, ok)
+		}
+	}
+	if _, _, ok := LangGo.findBlock(text, 11); ok {
+		t.Error("line after the function should not be in a block")
+	}
+}
+
+func TestFindBlockIndentation(t *testing.T) {
+	text := strings.Split(`class A:
+    def f(self):
+        x = {"a": 1}
+
+        return x
+
+    def g(self):
+        pass`, "\n")
+	start, end, ok := LangPython.findBlock(text, 4)
+	if !ok || start != 1 || end != 5 {
+		t.Errorf("got [%d, %d) %v, want [1, 5)", start, end, ok)
+	}
+}
+
+func TestFindBlockAllman(t *testing.T) {
+	text := strings.Split("int main(void)\n{\n    return 0;\n}\nint x;", "\n")
+	start, end, ok := LangC.findBlock(text, 2)
+	if !ok || start != 0 || end != 4 {
+		t.Errorf("got [%d, %d) %v, want [0, 4)", start, end, ok)
+	}
+}
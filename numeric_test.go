@@ -0,0 +1,40 @@
+package godelta
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNumericToleranceEqual(t *testing.T) {
+	old := "epoch 1 loss=0.4312871 acc=0.8812\nepoch 2 loss=0.3120442 acc=0.9001\n"
+	new := "epoch 1 loss=0.4312868 acc=0.8812\nepoch 2 loss=0.3120447 acc=0.9001\n"
+	if out := DiffWith(old, new, WithNumericTolerance(1e-6, 0)); out != "" {
+		t.Errorf("numbers within tolerance should show no diff, got:\n%s", out)
+	}
+	if out := DiffWith(old, new); out == "" {
+		t.Error("without a tolerance the numbers differ")
+	}
+}
+
+func TestNumericToleranceEmphasis(t *testing.T) {
+	old := "a\nloss=0.4312871 acc=0.8812\n"
+	new := "a\nloss=0.4312868 acc=0.9100\n"
+	out := DiffWith(old, new, WithColor(false), WithEmphasis(EmphasisMarkers), WithNumericTolerance(1e-6, 0))
+	if !strings.Contains(out, "acc=[-0.8812-]") || !strings.Contains(out, "acc={+0.9100+}") {
+		t.Errorf("expected the accuracy emphasized, got:\n%s", out)
+	}
+	if strings.Contains(out, "[-0.4312871-]") || strings.Contains(out, "{+0.4312868+}") {
+		t.Errorf("expected the loss within tolerance not emphasized, got:\n%s", out)
+	}
+}
+
+func TestNumericToleranceRelative(t *testing.T) {
+	old := "total: 1000000\n"
+	new := "total: 1000400\n"
+	if out := DiffWith(old, new, WithNumericTolerance(0, 1e-3)); out != "" {
+		t.Errorf("expected a relative tolerance to accept the change, got:\n%s", out)
+	}
+	if out := DiffWith(old, new, WithNumericTolerance(0, 1e-4)); out == "" {
+		t.Error("expected a tighter relative tolerance to reject it")
+	}
+}
@@ -1,5 +1,7 @@
 package godelta
 
+import "github.com/amterp/go-delta/internal/align"
+
 // Option configures the behavior of DiffWith.
 type Option func(*config)
 
@@ -18,18 +20,76 @@ const (
 	// cannot be detected), uses side-by-side since there is no
 	// truncation.
 	LayoutPreferSideBySide
+	// LayoutWordDiff renders each modified line once, with removed and
+	// added words shown in place, like git diff --word-diff. Lines with
+	// no similar counterpart fall back to inline "-"/"+" rows. Without
+	// color, changes are marked as "[-old-]{+new+}".
+	LayoutWordDiff
+)
+
+// EmphasisMode controls how changed segments within a line are marked.
+type EmphasisMode int
+
+const (
+	// EmphasisColor highlights changed segments with color only. Without
+	// color, word-level emphasis is not visible.
+	EmphasisColor EmphasisMode = iota
+	// EmphasisMarkers additionally wraps changed segments in textual
+	// markers, like git diff --word-diff=plain: "[-old-]" in removed
+	// lines and "{+new+}" in added lines. Works in every layout, with
+	// or without color.
+	EmphasisMarkers
+)
+
+// WhitespaceMode controls where whitespace is drawn with visible
+// symbols.
+type WhitespaceMode int
+
+const (
+	// WhitespaceHidden draws whitespace as-is.
+	WhitespaceHidden WhitespaceMode = iota
+	// WhitespaceChangedLines draws spaces as "·", tabs as "→" and
+	// carriage returns as "␍" throughout removed and added lines.
+	WhitespaceChangedLines
+	// WhitespaceEmphasized only draws whitespace visibly inside
+	// emphasized (changed) segments, so whitespace-only edits stand out
+	// without cluttering the rest of the line.
+	WhitespaceEmphasized
 )
 
 type config struct {
 	contextLines int
 	layout       Layout
-	colorMode    *bool // nil = auto-detect
-	width        int   // 0 = auto-detect terminal width
+	colorMode    *bool       // nil = auto-detect
+	colorLevel   *ColorLevel // nil = auto-detect
+	theme        Theme
+	emphasis     EmphasisMode
+	width        int // 0 = auto-detect terminal width
+	wrap         bool
+	tabWidth     int
+	whitespace   WhitespaceMode
+	hunkHeaders  bool
+	language     Language
+	funcContext  Language
+	indentHeur   bool
+	anchors      []string
+
+	jsonArrayKeys        []string
+	jsonIgnoreArrayOrder bool
+	yamlDocumentKey      []string
+	csvKey               string
+	csvComma             rune
+	unexportedFields     bool
+	numericTol           *align.Tolerance // nil = exact
+	ignoreLineOrder      bool
+	recordKey            string
 }
 
 func defaultConfig() config {
 	return config{
 		contextLines: 3,
+		theme:        DefaultTheme(),
+		tabWidth:     4,
 	}
 }
 
@@ -54,17 +114,46 @@ func WithLayout(mode Layout) Option {
 	}
 }
 
-// WithColor forces color on or off, overriding auto-detection.
+// WithColor forces color on or off, overriding auto-detection. When
+// forced on, the color depth is still inferred from COLORTERM and TERM.
 func WithColor(on bool) Option {
 	return func(c *config) {
 		c.colorMode = &on
 	}
 }
 
-// WithWidth sets the terminal width for side-by-side layout and
-// LayoutPreferSideBySide decisions. Default is 0, which auto-detects
-// from the terminal. If detection fails (non-TTY), panels are not
-// truncated. Ignored in LayoutInline mode.
+// WithColorLevel forces a specific color depth, overriding both
+// auto-detection and WithColor. ColorLevelNone disables color.
+func WithColorLevel(level ColorLevel) Option {
+	return func(c *config) {
+		level = ColorLevel(clampInt(int(level), int(ColorLevelNone), int(ColorLevelTrueColor)))
+		c.colorLevel = &level
+	}
+}
+
+// WithTheme sets the colors and text attributes used for each visual
+// element. Default is DefaultTheme(). Colors the terminal can't
+// display are downgraded to the nearest supported color.
+func WithTheme(t Theme) Option {
+	return func(c *config) {
+		c.theme = t
+	}
+}
+
+// WithEmphasis sets how changed segments within a line are marked.
+// Default is EmphasisColor. Use EmphasisMarkers to keep emphasis
+// visible in plain-text output such as CI logs.
+func WithEmphasis(mode EmphasisMode) Option {
+	return func(c *config) {
+		c.emphasis = mode
+	}
+}
+
+// WithWidth sets the terminal width for side-by-side layout,
+// LayoutPreferSideBySide decisions and wrapping. Default is 0, which
+// auto-detects from the terminal. If detection fails (non-TTY), panels
+// are not truncated and lines are not wrapped. Without WithWrap, inline
+// layouts ignore the width.
 func WithWidth(cols int) Option {
 	return func(c *config) {
 		if cols < 0 {
@@ -73,3 +162,183 @@ func WithWidth(cols int) Option {
 		c.width = cols
 	}
 }
+
+// WithWrap wraps lines that exceed the terminal width onto continuation
+// rows marked with "↪", preferring to break between words. Side-by-side
+// layouts wrap instead of truncating, keeping both panels in step;
+// inline layouts wrap instead of letting the terminal break the gutter
+// alignment. Default is false.
+func WithWrap(on bool) Option {
+	return func(c *config) {
+		c.wrap = on
+	}
+}
+
+// WithTabWidth sets the distance between tab stops. Tabs are expanded
+// to spaces, measured from the start of each line's content, so they
+// line up in every layout. Default is 4. Clamped to [1, 32].
+func WithTabWidth(n int) Option {
+	return func(c *config) {
+		c.tabWidth = clampInt(n, 1, 32)
+	}
+}
+
+// WithShowWhitespace makes whitespace in changed lines visible, so that
+// edits like tabs versus spaces or added trailing spaces can be seen.
+// In either visible mode, trailing whitespace on changed lines is also
+// drawn in the theme's Whitespace style. Context lines are never
+// affected. Default is WhitespaceHidden.
+func WithShowWhitespace(mode WhitespaceMode) Option {
+	return func(c *config) {
+		c.whitespace = mode
+	}
+}
+
+// WithHunkHeaders starts every hunk with a git-style header such as
+// "@@ -12,7 +12,8 @@ func (s *Server) Handle(w http.ResponseWriter) {",
+// instead of separating hunks with a count of skipped lines. The text
+// after the line ranges is the nearest line before the hunk, in the old
+// text, that starts a function or similar section in lang. Pass the
+// zero Language for headers without it. Default is off.
+func WithHunkHeaders(lang Language) Option {
+	return func(c *config) {
+		c.hunkHeaders = true
+		c.language = lang
+	}
+}
+
+// WithFunctionContext widens the context around each change to the
+// whole function or other section enclosing it in lang, like
+// git diff --function-context. Sections start at a line matching lang's
+// funcname patterns and end at the matching closing brace or, for
+// languages without braces, where the indentation returns to the
+// section's level. Changes outside any section get the usual context.
+// Default is off.
+func WithFunctionContext(lang Language) Option {
+	return func(c *config) {
+		c.funcContext = lang
+	}
+}
+
+// WithIndentHeuristic moves ambiguous blocks of added or removed lines
+// to where they read best, using git's indent heuristic. Where a block
+// starts and ends with the same line, such as "}" or a blank line, the
+// diff could show it in several places; the heuristic prefers blocks
+// bounded by blank lines and starting at low indentation, so a new
+// function shows up whole instead of as "}" plus its body plus the next
+// function's first line. Default is off.
+func WithIndentHeuristic(on bool) Option {
+	return func(c *config) {
+		c.indentHeur = on
+	}
+}
+
+// WithAnchors keeps lines starting with any of the given texts
+// unchanged where possible, like git diff --anchored. An anchor line
+// must appear exactly once in each input; the text between anchors is
+// diffed separately. When a block is reordered, this decides which side
+// reads as moved: the anchored lines stay put and the rest moves around
+// them. May be given more than once; anchors accumulate.
+func WithAnchors(lines ...string) Option {
+	return func(c *config) {
+		c.anchors = append(c.anchors, lines...)
+	}
+}
+
+// WithJSONArrayKeys makes DiffJSON match objects in arrays by the first
+// of the given fields they have, such as "id" or "name", instead of by
+// content and position. Matched objects are diffed field by field
+// wherever they moved to; objects with no match show as removed or
+// added.
+func WithJSONArrayKeys(keys ...string) Option {
+	return func(c *config) {
+		c.jsonArrayKeys = append(c.jsonArrayKeys, keys...)
+	}
+}
+
+// WithJSONIgnoreArrayOrder makes DiffJSON compare arrays as unordered
+// collections: elements are sorted (by key, with WithJSONArrayKeys, or
+// by content) before matching, and reordering alone shows no changes.
+// Default is off.
+func WithJSONIgnoreArrayOrder(on bool) Option {
+	return func(c *config) {
+		c.jsonIgnoreArrayOrder = on
+	}
+}
+
+// WithYAMLDocumentKey sets the fields DiffYAML matches documents by,
+// as dot-separated paths to scalars, such as "metadata.name". Documents
+// with the same values are compared wherever they are in the stream;
+// documents missing any of the fields are matched by position. Default
+// is "apiVersion", "kind", "metadata.name", which identifies Kubernetes
+// objects.
+func WithYAMLDocumentKey(paths ...string) Option {
+	return func(c *config) {
+		c.yamlDocumentKey = paths
+	}
+}
+
+// WithCSVKey makes DiffCSV match rows by the named column, such as
+// "id", so reordered rows compare against each other instead of showing
+// as removed and added. The column must be in both headers. Default is
+// to match rows in order.
+func WithCSVKey(column string) Option {
+	return func(c *config) {
+		c.csvKey = column
+	}
+}
+
+// WithCSVDelimiter sets the field delimiter DiffCSV reads, such as ';'.
+// Default is to detect ',' or '\t' from the first line.
+func WithCSVDelimiter(r rune) Option {
+	return func(c *config) {
+		c.csvComma = r
+	}
+}
+
+// WithUnexportedFields makes DiffValues print unexported struct fields
+// too. Default is off.
+func WithUnexportedFields(on bool) Option {
+	return func(c *config) {
+		c.unexportedFields = on
+	}
+}
+
+// WithNumericTolerance makes numbers compare equal when they differ by
+// at most abs, or by at most rel times the larger of the two, such as
+// WithNumericTolerance(1e-6, 0) for output that varies in the sixth
+// decimal place. Numbers are integers and decimals, optionally signed
+// and in scientific notation, but not digits within words such as x86.
+// Lines that differ only in such numbers show as unchanged, with their
+// new text, and within changed lines, numbers within tolerance aren't
+// emphasized. Negative values count as 0. Default is exact comparison.
+func WithNumericTolerance(abs, rel float64) Option {
+	return func(c *config) {
+		c.numericTol = &align.Tolerance{Abs: max(abs, 0), Rel: max(rel, 0)}
+	}
+}
+
+// WithIgnoreLineOrder compares the inputs as unordered collections of
+// lines, for files such as go.sum, .gitignore or CODEOWNERS where order
+// doesn't matter. Only lines that appear more often on one side are
+// shown: all removed lines, then all added lines, each sorted and
+// listed once. Gutters show where each line first appears and, for
+// lines with several extra copies, how many, as in "12 ×3". Similar
+// removed and added lines are still paired, each with its closest
+// match, for emphasis. Hunk headers, anchors and function context
+// don't apply. Default is off.
+func WithIgnoreLineOrder(on bool) Option {
+	return func(c *config) {
+		c.ignoreLineOrder = on
+	}
+}
+
+// WithRecordKey sets the field DiffRecords matches records by, such as
+// "request_id". Records with the same value are compared wherever they
+// are in the stream. JSON values match by type and value, so "1" and 1
+// are different keys while 1 and 1.0 are the same. Default is "id".
+func WithRecordKey(field string) Option {
+	return func(c *config) {
+		c.recordKey = field
+	}
+}
@@ -2,6 +2,7 @@ package godelta
 
 import (
 	"os"
+	"strings"
 
 	"github.com/amterp/go-delta/internal/align"
 	"github.com/amterp/go-delta/internal/diff"
@@ -9,47 +10,126 @@ import (
 	"golang.org/x/term"
 )
 
-// runPipeline executes the three-stage diff pipeline:
+// utf8BOM is the UTF-8 encoded byte order mark.
+const utf8BOM = "\uFEFF"
+
+// runPipeline diffs old and new. A leading UTF-8 byte order mark is
+// stripped from both before diffing, so it can't make the first line
+// differ invisibly; if only one side has it, a note says so above the
+// diff.
+func runPipeline(old, new string, cfg config, styles render.Styles) string {
+	old, oldBOM := strings.CutPrefix(old, utf8BOM)
+	new, newBOM := strings.CutPrefix(new, utf8BOM)
+	out := renderDiff(old, new, cfg, styles)
+	switch {
+	case oldBOM && !newBOM:
+		out = styles.Separator("~~~ UTF-8 byte order mark removed ~~~") + "\n" + out
+	case newBOM && !oldBOM:
+		out = styles.Separator("~~~ UTF-8 byte order mark added ~~~") + "\n" + out
+	}
+	return out
+}
+
+// renderDiff executes the three-stage diff pipeline:
 // 1. Line-level diff (Myers) -> hunks
 // 2. Within-line alignment (tokenize + NW + line pairing)
 // 3. Rendering
-func runPipeline(old, new string, cfg config, styles render.Styles) string {
-	// Stage 1: line-level diff
-	lines := diff.Diff(old, new)
-	if lines == nil {
+func renderDiff(old, new string, cfg config, styles render.Styles) string {
+	_, annotated := annotateDiff(old, new, cfg)
+	if len(annotated) == 0 {
 		return ""
 	}
-	hunks := diff.ComputeHunks(lines, cfg.contextLines)
-	if len(hunks) == 0 {
-		return ""
+	if cfg.ignoreLineOrder {
+		cfg.hunkHeaders = false // positions mean nothing without order
+	}
+	return renderHunks(annotated, cfg, styles)
+}
+
+// annotateDiff runs the first two pipeline stages, returning the full
+// line diff and the annotated hunks built from it. Both are empty if
+// the inputs have the same lines.
+func annotateDiff(old, new string, cfg config) ([]diff.Line, []align.AnnotatedHunk) {
+	// Stage 1: line-level diff
+	var lines []diff.Line
+	switch {
+	case cfg.ignoreLineOrder:
+		lines = diff.DiffUnordered(old, new)
+	case len(cfg.anchors) > 0:
+		lines = diff.DiffAnchored(old, new, cfg.anchors)
+	default:
+		lines = diff.Diff(old, new)
+	}
+	if tol := cfg.numericTol; tol != nil {
+		lines = diff.Equate(lines, tol.LineKey, tol.LinesEqual)
+	}
+	if cfg.indentHeur {
+		lines = diff.IndentHeuristic(lines)
+	}
+	var hunks []diff.Hunk
+	if len(cfg.funcContext.patterns) > 0 && !cfg.ignoreLineOrder {
+		hunks = diff.ComputeFunctionHunks(lines, cfg.contextLines, cfg.funcContext.findBlock)
+	} else {
+		hunks = diff.ComputeHunks(lines, cfg.contextLines)
+	}
+	if cfg.hunkHeaders && !cfg.ignoreLineOrder {
+		setFuncContext(lines, hunks, cfg.language)
 	}
 
 	// Stage 2: within-line alignment
-	annotated := align.AnnotateHunks(hunks)
+	if cfg.ignoreLineOrder {
+		if tol := cfg.numericTol; tol != nil {
+			return lines, align.AnnotateHunksClosest(hunks, align.TokenizeNumeric, tol.Equal)
+		}
+		return lines, align.AnnotateHunksClosest(hunks, align.Tokenize, nil)
+	}
+	if cfg.numericTol != nil {
+		return lines, align.AnnotateHunksTolerance(hunks, *cfg.numericTol)
+	}
+	return lines, align.AnnotateHunks(hunks)
+}
+
+// renderHunks renders annotated hunks in the configured layout.
+func renderHunks(annotated []align.AnnotatedHunk, cfg config, styles render.Styles) string {
+	opts := render.Options{
+		Width:       cfg.width,
+		Wrap:        cfg.wrap,
+		TabWidth:    cfg.tabWidth,
+		Whitespace:  renderWhitespace(cfg.whitespace),
+		HunkHeaders: cfg.hunkHeaders,
+	}
+	if opts.Width <= 0 && (cfg.wrap || cfg.layout == LayoutSideBySide || cfg.layout == LayoutPreferSideBySide) {
+		opts.Width = terminalWidth()
+	}
 
-	// Stage 3: rendering
 	switch cfg.layout {
 	case LayoutSideBySide:
-		width := cfg.width
-		if width <= 0 {
-			width = terminalWidth()
-		}
-		return render.RenderSideBySide(annotated, styles, width)
+		return render.RenderSideBySide(annotated, styles, opts)
 
 	case LayoutPreferSideBySide:
-		width := cfg.width
-		if width <= 0 {
-			width = terminalWidth()
-		}
 		// Non-TTY (width 0): use SBS since there's no truncation concern.
 		// Otherwise: measure and fall back to inline if it won't fit.
-		if width > 0 && render.MeasureSideBySideWidth(annotated, styles) > width {
-			return render.RenderInline(annotated, styles)
+		if opts.Width > 0 && render.MeasureSideBySideWidth(annotated, styles, opts) > opts.Width {
+			return render.RenderInline(annotated, styles, opts)
 		}
-		return render.RenderSideBySide(annotated, styles, width)
+		return render.RenderSideBySide(annotated, styles, opts)
+
+	case LayoutWordDiff:
+		return render.RenderWordDiff(annotated, styles, opts)
+
+	default:
+		return render.RenderInline(annotated, styles, opts)
+	}
+}
 
+// renderWhitespace maps a WhitespaceMode to the renderer's equivalent.
+func renderWhitespace(mode WhitespaceMode) render.WhitespaceMode {
+	switch mode {
+	case WhitespaceChangedLines:
+		return render.WhitespaceChanged
+	case WhitespaceEmphasized:
+		return render.WhitespaceEmphasized
 	default:
-		return render.RenderInline(annotated, styles)
+		return render.WhitespaceNone
 	}
 }
 
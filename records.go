@@ -0,0 +1,26 @@
+package godelta
+
+import (
+	"github.com/amterp/go-delta/internal/recorddiff"
+)
+
+// DiffRecords compares two streams of line-delimited records, one per
+// line: JSON objects (JSON Lines) or logfmt key=value pairs. Records
+// are matched by a key field, "id" unless set with WithRecordKey,
+// wherever they are in the stream, and reported as added, removed or
+// modified. Field order and formatting are ignored. In modified
+// records, changed values and added or removed fields are emphasized
+// whole. Gutters show each record's key instead of line numbers;
+// records without the key are matched by position and show their line
+// number. Returns an empty string if the streams hold the same
+// records, or an error if a line can't be parsed. Layouts and options
+// apply as in DiffCSV, with fields as the unit of change.
+func DiffRecords(old, new []byte, opts ...Option) (string, error) {
+	cfg := cellConfig(opts)
+
+	hunks, err := recorddiff.Hunks(old, new, recorddiff.Options{Key: cfg.recordKey}, cfg.contextLines)
+	if err != nil || hunks == nil {
+		return "", err
+	}
+	return renderHunks(hunks, cfg, resolveStyles(cfg)), nil
+}
@@ -0,0 +1,38 @@
+package godelta
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffRecordsEqual(t *testing.T) {
+	old := `{"id":1,"n":1}` + "\n" + `{"id":2,"n":2}` + "\n"
+	new := `{"n":2,"id":2}` + "\n" + `{"id":1, "n":1}` + "\n"
+	out, err := DiffRecords([]byte(old), []byte(new))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "" {
+		t.Errorf("reordered records should have no diff, got:\n%s", out)
+	}
+}
+
+func TestDiffRecordsKey(t *testing.T) {
+	old := "req=a status=200\nreq=b status=200\n"
+	new := "req=b status=200\nreq=a status=503\n"
+	out, err := DiffRecords([]byte(old), []byte(new), WithColor(false), WithEmphasis(EmphasisMarkers),
+		WithRecordKey("req"), WithLayout(LayoutWordDiff), WithContextLines(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "~~~ 1 line skipped ~~~\n\na   │ - req=a status=[-200-]\n  a │ + req=a status={+503+}\n"
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestDiffRecordsErrors(t *testing.T) {
+	if _, err := DiffRecords([]byte("{\"id\":1\n"), nil); err == nil || !strings.Contains(err.Error(), "old records: line 1") {
+		t.Errorf("expected an old records error, got %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+// Package snapshot implements golden-file tests: a test's output is
+// compared against a snapshot file stored with the package, and
+// mismatches are reported as go-delta diffs.
+//
+// Usage:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(snapshot.Run(m))
+//	}
+//
+//	func TestRender(t *testing.T) {
+//		snapshot.Match(t, render())
+//	}
+//
+// Snapshots are written to testdata/<test name>.snap, with subtests in
+// a directory per parent test. To create or update snapshots, run the
+// tests with UPDATE_SNAPSHOTS=1, or with -update if the test package
+// defines an update flag:
+//
+//	var _ = flag.Bool("update", false, "update snapshot files")
+//
+// With Run in TestMain, a full run fails if a snapshot no test used is
+// left behind, and a full passing run while updating deletes them.
+package snapshot
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	gd "github.com/amterp/go-delta"
+	"github.com/amterp/go-delta/godeltatest"
+)
+
+// ext is the extension of snapshot files.
+const ext = ".snap"
+
+// updating reports whether snapshots are being updated: whether
+// UPDATE_SNAPSHOTS is true, or the test binary defines an update flag
+// and it is set. The package defines no flag of its own, so it never
+// conflicts with one the test package defines.
+func updating() bool {
+	if on, err := strconv.ParseBool(os.Getenv("UPDATE_SNAPSHOTS")); err == nil && on {
+		return true
+	}
+	f := flag.Lookup("update")
+	return f != nil && f.Value.String() == "true"
+}
+
+// Option configures a Match or MatchNamed call.
+type Option func(*config)
+
+type config struct {
+	dir     string
+	markers bool
+}
+
+// WithDir sets the directory snapshots are stored in, relative to the
+// package directory. Default is "testdata".
+func WithDir(dir string) Option {
+	return func(c *config) {
+		c.dir = dir
+	}
+}
+
+// WithANSIMarkers stores output with its ANSI color codes replaced by
+// readable markers (see ANSIToMarkers), so snapshots of colored output
+// can be read and reviewed as text. Default is off.
+func WithANSIMarkers(on bool) Option {
+	return func(c *config) {
+		c.markers = on
+	}
+}
+
+// Match compares got with the snapshot named after the test, such as
+// testdata/TestRender.snap, or testdata/TestRender/dark_theme.snap for
+// the subtest TestRender/dark_theme. See MatchNamed.
+func Match(t testing.TB, got string, opts ...Option) {
+	t.Helper()
+	MatchNamed(t, t.Name(), got, opts...)
+}
+
+// MatchNamed compares got with the snapshot called name, which may
+// contain slashes to use subdirectories. On a mismatch, or if the
+// snapshot doesn't exist, the test fails with a diff from the snapshot
+// to got. When updating, got is written to the snapshot instead.
+func MatchNamed(t testing.TB, name, got string, opts ...Option) {
+	t.Helper()
+	cfg := config{dir: "testdata"}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.markers {
+		got = ANSIToMarkers(got)
+	}
+	path := filepath.Join(cfg.dir, filepath.FromSlash(name)+ext)
+	used.add(cfg.dir, path)
+
+	want, err := os.ReadFile(path)
+	if updating() {
+		if err == nil && string(want) == got {
+			return
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("snapshot %s not found (run with UPDATE_SNAPSHOTS=1 to create it): %v", path, err)
+		return
+	}
+	if string(want) != got {
+		t.Errorf("output does not match snapshot %s (-snapshot +got; run with UPDATE_SNAPSHOTS=1 to accept):\n%s",
+			path, gd.DiffWith(string(want), got, godeltatest.Options()...))
+	}
+}
+
+// sgrRe matches ANSI SGR (color and style) sequences.
+var sgrRe = regexp.MustCompile(`\x1b\[([0-9;]*)m`)
+
+// ANSIToMarkers replaces ANSI SGR sequences with readable markers:
+// \x1b[31m becomes «31», \x1b[0m «0» and \x1b[32;7m «32;7».
+func ANSIToMarkers(s string) string {
+	return sgrRe.ReplaceAllString(s, `«$1»`)
+}
+
+// Run runs the tests, like m.Run, and returns the exit code. When every
+// test ran and passed, snapshot files no Match or MatchNamed call used,
+// in the directories they used, are obsolete: when updating they are
+// deleted, and otherwise they are listed on stderr and the run fails.
+// Snapshots of skipped tests count as obsolete, so run where no tests
+// skip.
+func Run(m *testing.M) int {
+	code := m.Run()
+	if code != 0 || filtered() {
+		return code
+	}
+	return used.clean()
+}
+
+// filtered reports whether only some tests ran.
+func filtered() bool {
+	for _, name := range []string{"test.run", "test.skip"} {
+		if f := flag.Lookup(name); f != nil && f.Value.String() != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// used records the snapshots used in this run.
+var used = usage{dirs: map[string]bool{}, paths: map[string]bool{}}
+
+type usage struct {
+	mu    sync.Mutex
+	dirs  map[string]bool
+	paths map[string]bool
+}
+
+func (u *usage) add(dir, path string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.dirs[filepath.Clean(dir)] = true
+	u.paths[path] = true
+}
+
+// clean deletes obsolete snapshots when updating, and otherwise lists
+// them on stderr. It returns the exit code of a run that otherwise
+// passed: 1 if obsolete snapshots remain or one can't be deleted.
+func (u *usage) clean() int {
+	obsolete, err := u.obsolete()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "snapshot: %v\n", err)
+		return 1
+	}
+	if len(obsolete) > 0 && !updating() {
+		for _, path := range obsolete {
+			fmt.Fprintf(os.Stderr, "snapshot: %s is obsolete (run with UPDATE_SNAPSHOTS=1 to delete it)\n", path)
+		}
+		return 1
+	}
+	for _, path := range obsolete {
+		if err := os.Remove(path); err != nil {
+			fmt.Fprintf(os.Stderr, "snapshot: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "snapshot: deleted obsolete %s\n", path)
+	}
+	return 0
+}
+
+// obsolete returns the snapshot files in the used directories that
+// weren't used, sorted.
+func (u *usage) obsolete() ([]string, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	found := map[string]bool{} // directories may nest
+	for dir := range u.dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.HasSuffix(path, ext) && !u.paths[path] {
+				found[path] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	out := make([]string, 0, len(found))
+	for path := range found {
+		out = append(out, path)
+	}
+	sort.Strings(out)
+	return out, nil
+}
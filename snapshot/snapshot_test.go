@@ -0,0 +1,152 @@
+package snapshot
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recorder is a testing.TB that records failures instead of failing.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+}
+
+// setUpdate sets UPDATE_SNAPSHOTS for the rest of the test.
+func setUpdate(t *testing.T, on bool) {
+	t.Helper()
+	t.Setenv("UPDATE_SNAPSHOTS", fmt.Sprint(on))
+}
+
+func TestMatchNamed(t *testing.T) {
+	dir := t.TempDir()
+	r := &recorder{TB: t}
+	MatchNamed(r, "greeting", "hello\n", WithDir(dir))
+	if len(r.failures) != 1 || !strings.Contains(r.failures[0], "UPDATE_SNAPSHOTS") {
+		t.Fatalf("expected a missing snapshot failure, got %q", r.failures)
+	}
+
+	setUpdate(t, true)
+	r = &recorder{TB: t}
+	MatchNamed(r, "greeting", "hello\n", WithDir(dir))
+	if data, err := os.ReadFile(filepath.Join(dir, "greeting.snap")); err != nil || string(data) != "hello\n" {
+		t.Fatalf("expected the snapshot written, got %q, %v", data, err)
+	}
+
+	setUpdate(t, false)
+	MatchNamed(r, "greeting", "hello\n", WithDir(dir))
+	if len(r.failures) != 0 {
+		t.Fatalf("expected a match, got %q", r.failures)
+	}
+	MatchNamed(r, "greeting", "hello world\n", WithDir(dir))
+	if len(r.failures) != 1 || !strings.Contains(r.failures[0], "greeting.snap") || !strings.Contains(r.failures[0], "world") {
+		t.Errorf("expected a diff against the snapshot, got %q", r.failures)
+	}
+}
+
+func TestMatchUsesTestName(t *testing.T) {
+	dir := t.TempDir()
+	setUpdate(t, true)
+	t.Run("dark theme", func(t *testing.T) {
+		Match(t, "x", WithDir(dir))
+	})
+	if _, err := os.Stat(filepath.Join(dir, "TestMatchUsesTestName", "dark_theme.snap")); err != nil {
+		t.Errorf("expected a snapshot per subtest: %v", err)
+	}
+}
+
+func TestWithANSIMarkers(t *testing.T) {
+	dir := t.TempDir()
+	setUpdate(t, true)
+	MatchNamed(t, "color", "\x1b[31mred\x1b[0m", WithDir(dir), WithANSIMarkers(true))
+	if data, _ := os.ReadFile(filepath.Join(dir, "color.snap")); string(data) != "«31»red«0»" {
+		t.Errorf("expected markers, got %q", data)
+	}
+}
+
+func TestANSIToMarkers(t *testing.T) {
+	if got := ANSIToMarkers("\x1b[32;7mx\x1b[m"); got != "«32;7»x«»" {
+		t.Errorf("ANSIToMarkers = %q", got)
+	}
+}
+
+func TestObsolete(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.snap", "b.snap", "notes.txt", "sub/c.snap"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	u := usage{dirs: map[string]bool{}, paths: map[string]bool{}}
+	u.add(dir, filepath.Join(dir, "a.snap"))
+	u.add(filepath.Join(dir, "sub"), filepath.Join(dir, "x.snap"))
+	got, err := u.obsolete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "b.snap"), filepath.Join(dir, "sub", "c.snap")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("obsolete = %q, want %q", got, want)
+	}
+}
+
+func TestUpdateFlag(t *testing.T) {
+	// Test packages define their own -update flag; defining one here
+	// would make them panic.
+	if flag.Lookup("update") != nil {
+		t.Fatal("the package shouldn't define an update flag")
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	update := fs.Bool("update", false, "")
+	old := flag.CommandLine
+	flag.CommandLine = fs
+	t.Cleanup(func() { flag.CommandLine = old })
+	if updating() {
+		t.Fatal("updating without the flag set")
+	}
+	*update = true
+	if !updating() {
+		t.Error("expected the test package's -update flag to be read")
+	}
+}
+
+func TestClean(t *testing.T) {
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "stale.snap")
+	if err := os.WriteFile(stale, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	u := usage{dirs: map[string]bool{}, paths: map[string]bool{}}
+	u.add(dir, filepath.Join(dir, "used.snap"))
+
+	if code := u.clean(); code != 1 {
+		t.Errorf("clean = %d, want 1 with an obsolete snapshot", code)
+	}
+	if _, err := os.Stat(stale); err != nil {
+		t.Fatalf("expected the obsolete snapshot kept: %v", err)
+	}
+
+	setUpdate(t, true)
+	if code := u.clean(); code != 0 {
+		t.Errorf("clean = %d, want 0 when updating", code)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("expected the obsolete snapshot deleted, got %v", err)
+	}
+}
@@ -1,49 +1,21 @@
-package godelta
+package godelta_test
 
 import (
 	"flag"
 	"os"
-	"path/filepath"
-	"regexp"
+	"strings"
 	"testing"
-)
-
-var updateSnapshots = flag.Bool("update", false, "update snapshot files")
-
-// snapshotTest compares a diff result against a snapshot file. If -update
-// is passed, it writes the snapshot file instead.
-func snapshotTest(t *testing.T, name string, result string) {
-	t.Helper()
-	path := filepath.Join("testdata", name+".snap")
-
-	if *updateSnapshots {
-		err := os.MkdirAll("testdata", 0o755)
-		if err != nil {
-			t.Fatal(err)
-		}
-		err = os.WriteFile(path, []byte(result), 0o644)
-		if err != nil {
-			t.Fatal(err)
-		}
-		return
-	}
 
-	expected, err := os.ReadFile(path)
-	if err != nil {
-		t.Fatalf("snapshot file %s not found (run with -update to create): %v", path, err)
-	}
-	if string(expected) != result {
-		t.Errorf("output does not match snapshot %s\n--- expected ---\n%s\n--- got ---\n%s",
-			path, string(expected), result)
-	}
-}
+	gd "github.com/amterp/go-delta"
+	"github.com/amterp/go-delta/snapshot"
+)
 
-// ansiToMarkers replaces ANSI SGR sequences with readable markers.
-// e.g. \x1b[31m -> «31», \x1b[0m -> «0», \x1b[32;7m -> «32;7»
-var sgrRe = regexp.MustCompile(`\x1b\[([0-9;]*)m`)
+// Run with -update to rewrite the snapshots; the snapshot package reads
+// the flag.
+var _ = flag.Bool("update", false, "update snapshot files")
 
-func ansiToMarkers(s string) string {
-	return sgrRe.ReplaceAllString(s, `«$1»`)
+func TestMain(m *testing.M) {
+	os.Exit(snapshot.Run(m))
 }
 
 // --- Snapshot tests ---
@@ -51,100 +23,100 @@ func ansiToMarkers(s string) string {
 func TestSnapshotInlineBasic(t *testing.T) {
 	old := `{"name": "Alice", "age": 30, "city": "NYC"}`
 	new := `{"name": "Alice", "age": 31, "city": "NYC"}`
-	result := DiffWith(old, new, WithColor(false))
-	snapshotTest(t, "inline_basic", result)
+	result := gd.DiffWith(old, new, gd.WithColor(false))
+	snapshot.MatchNamed(t, "inline_basic", result)
 }
 
 func TestSnapshotInlineMultiLine(t *testing.T) {
 	old := "line1\nline2\nline3\nline4\nline5"
 	new := "line1\nLINE2\nline3\nline4\nLINE5"
-	result := DiffWith(old, new, WithColor(false))
-	snapshotTest(t, "inline_multiline", result)
+	result := gd.DiffWith(old, new, gd.WithColor(false))
+	snapshot.MatchNamed(t, "inline_multiline", result)
 }
 
 func TestSnapshotInlineMultiHunk(t *testing.T) {
 	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl"
 	new := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nK\nl"
-	result := DiffWith(old, new, WithColor(false), WithContextLines(1))
-	snapshotTest(t, "inline_multihunk", result)
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithContextLines(1))
+	snapshot.MatchNamed(t, "inline_multihunk", result)
 }
 
 func TestSnapshotInlineContextZero(t *testing.T) {
 	old := "a\nb\nc\nd\ne"
 	new := "a\nB\nc\nD\ne"
-	result := DiffWith(old, new, WithColor(false), WithContextLines(0))
-	snapshotTest(t, "inline_context0", result)
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithContextLines(0))
+	snapshot.MatchNamed(t, "inline_context0", result)
 }
 
 func TestSnapshotInlineContextFive(t *testing.T) {
 	old := "a\nb\nc\nd\ne\nf\ng\nh"
 	new := "a\nb\nc\nD\ne\nf\ng\nh"
-	result := DiffWith(old, new, WithColor(false), WithContextLines(5))
-	snapshotTest(t, "inline_context5", result)
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithContextLines(5))
+	snapshot.MatchNamed(t, "inline_context5", result)
 }
 
 func TestSnapshotInlineAllAdded(t *testing.T) {
-	result := DiffWith("", "alpha\nbeta\ngamma", WithColor(false))
-	snapshotTest(t, "inline_all_added", result)
+	result := gd.DiffWith("", "alpha\nbeta\ngamma", gd.WithColor(false))
+	snapshot.MatchNamed(t, "inline_all_added", result)
 }
 
 func TestSnapshotInlineAllRemoved(t *testing.T) {
-	result := DiffWith("alpha\nbeta\ngamma", "", WithColor(false))
-	snapshotTest(t, "inline_all_removed", result)
+	result := gd.DiffWith("alpha\nbeta\ngamma", "", gd.WithColor(false))
+	snapshot.MatchNamed(t, "inline_all_removed", result)
 }
 
 func TestSnapshotInlineTrailingNewline(t *testing.T) {
-	result := DiffWith("hello\nworld\n", "hello\nworld", WithColor(false))
-	snapshotTest(t, "inline_trailing_newline", result)
+	result := gd.DiffWith("hello\nworld\n", "hello\nworld", gd.WithColor(false))
+	snapshot.MatchNamed(t, "inline_trailing_newline", result)
 }
 
 func TestSnapshotInlineEmphasis(t *testing.T) {
 	old := `  "name": "Alice",`
 	new := `  "name": "Bob",`
-	result := DiffWith(old, new, WithColor(false))
-	snapshotTest(t, "inline_emphasis", result)
+	result := gd.DiffWith(old, new, gd.WithColor(false))
+	snapshot.MatchNamed(t, "inline_emphasis", result)
 }
 
 func TestSnapshotInlineUnpaired(t *testing.T) {
 	old := "completely different"
 	new := "!@#$%^&*()"
-	result := DiffWith(old, new, WithColor(false))
-	snapshotTest(t, "inline_unpaired", result)
+	result := gd.DiffWith(old, new, gd.WithColor(false))
+	snapshot.MatchNamed(t, "inline_unpaired", result)
 }
 
 func TestSnapshotSideBySideBasic(t *testing.T) {
 	old := "hello world\nfoo bar"
 	new := "hello earth\nfoo baz"
-	result := DiffWith(old, new, WithColor(false), WithLayout(LayoutSideBySide), WithWidth(80))
-	snapshotTest(t, "sbs_basic", result)
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithLayout(gd.LayoutSideBySide), gd.WithWidth(80))
+	snapshot.MatchNamed(t, "sbs_basic", result)
 }
 
 func TestSnapshotSideBySideUnmatched(t *testing.T) {
 	old := "only in old\nshared"
 	new := "shared\nonly in new"
-	result := DiffWith(old, new, WithColor(false), WithLayout(LayoutSideBySide), WithWidth(80))
-	snapshotTest(t, "sbs_unmatched", result)
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithLayout(gd.LayoutSideBySide), gd.WithWidth(80))
+	snapshot.MatchNamed(t, "sbs_unmatched", result)
 }
 
 func TestSnapshotSideBySideLongLine(t *testing.T) {
 	old := "short"
 	new := "this is a very long line that should exceed the panel width in an 80-column terminal and get truncated"
-	result := DiffWith(old, new, WithColor(false), WithLayout(LayoutSideBySide), WithWidth(80))
-	snapshotTest(t, "sbs_long_line", result)
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithLayout(gd.LayoutSideBySide), gd.WithWidth(80))
+	snapshot.MatchNamed(t, "sbs_long_line", result)
 }
 
 func TestSnapshotSideBySideWideUnicode(t *testing.T) {
 	old := "hello 世界"
 	new := "hello 地球"
-	result := DiffWith(old, new, WithColor(false), WithLayout(LayoutSideBySide), WithWidth(80))
-	snapshotTest(t, "sbs_wide_unicode", result)
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithLayout(gd.LayoutSideBySide), gd.WithWidth(80))
+	snapshot.MatchNamed(t, "sbs_wide_unicode", result)
 }
 
 func TestSnapshotSideBySideColorBasic(t *testing.T) {
 	old := "hello world\nfoo bar"
 	new := "hello earth\nfoo baz"
-	result := DiffWith(old, new, WithColor(true), WithLayout(LayoutSideBySide), WithWidth(80))
-	snapshotTest(t, "sbs_color_basic", ansiToMarkers(result))
+	result := gd.DiffWith(old, new, gd.WithColor(true), gd.WithLayout(gd.LayoutSideBySide), gd.WithWidth(80))
+	snapshot.MatchNamed(t, "sbs_color_basic", result, snapshot.WithANSIMarkers(true))
 }
 
 func TestSnapshotSideBySideColorTruncation(t *testing.T) {
@@ -164,27 +136,27 @@ func TestSnapshotSideBySideColorTruncation(t *testing.T) {
     "city": "Springfield",
   }
 }`
-	result := DiffWith(old, new, WithColor(true), WithLayout(LayoutSideBySide), WithWidth(72))
-	marked := ansiToMarkers(result)
+	result := gd.DiffWith(old, new, gd.WithColor(true), gd.WithLayout(gd.LayoutSideBySide), gd.WithWidth(72))
+	marked := snapshot.ANSIToMarkers(result)
 
 	// Every line should have its ANSI state properly closed.
 	// Specifically, the truncated hobbies line must end with a reset «0»
 	// before the newline, not bleed into subsequent lines.
-	snapshotTest(t, "sbs_color_truncation", marked)
+	snapshot.MatchNamed(t, "sbs_color_truncation", marked)
 }
 
 func TestSnapshotPreferSBSFits(t *testing.T) {
 	old := "hello world"
 	new := "hello earth"
-	result := DiffWith(old, new, WithColor(false), WithLayout(LayoutPreferSideBySide), WithWidth(200))
-	snapshotTest(t, "prefer_sbs_fits", result)
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithLayout(gd.LayoutPreferSideBySide), gd.WithWidth(200))
+	snapshot.MatchNamed(t, "prefer_sbs_fits", result)
 }
 
 func TestSnapshotPreferSBSDoesNotFit(t *testing.T) {
 	old := "hello world with some extra content here"
 	new := "hello earth with some extra content here"
-	result := DiffWith(old, new, WithColor(false), WithLayout(LayoutPreferSideBySide), WithWidth(40))
-	snapshotTest(t, "prefer_sbs_no_fit", result)
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithLayout(gd.LayoutPreferSideBySide), gd.WithWidth(40))
+	snapshot.MatchNamed(t, "prefer_sbs_no_fit", result)
 }
 
 func TestSnapshotInlineCaretShift(t *testing.T) {
@@ -207,6 +179,397 @@ func TestSnapshotInlineCaretShift(t *testing.T) {
   |
    = help: The for-loop syntax changed. Use: for item in items with loop: print(loop.idx, item). See: https://amterp.github.io/rad/migrations/v0.7/
    = info: rad explain RAD20033`
-	result := DiffWith(old, new, WithColor(true))
-	snapshotTest(t, "inline_caret_shift", ansiToMarkers(result))
+	result := gd.DiffWith(old, new, gd.WithColor(true))
+	snapshot.MatchNamed(t, "inline_caret_shift", result, snapshot.WithANSIMarkers(true))
+}
+
+func TestSnapshotInlineEmphasisMarkers(t *testing.T) {
+	old := `{"name": "Alice", "age": 30, "city": "NYC"}`
+	new := `{"name": "Alice", "age": 31, "city": "Boston"}`
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithEmphasis(gd.EmphasisMarkers))
+	snapshot.MatchNamed(t, "inline_emphasis_markers", result)
+}
+
+func TestSnapshotSideBySideEmphasisMarkers(t *testing.T) {
+	old := "hello world\nfoo bar"
+	new := "hello earth\nfoo baz"
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithEmphasis(gd.EmphasisMarkers),
+		gd.WithLayout(gd.LayoutSideBySide), gd.WithWidth(80))
+	snapshot.MatchNamed(t, "sbs_emphasis_markers", result)
+}
+
+func TestSnapshotWordDiffBasic(t *testing.T) {
+	old := "{\n  \"name\": \"Alice\",\n  \"age\": 30,\n  \"city\": \"NYC\"\n}"
+	new := "{\n  \"name\": \"Alice\",\n  \"age\": 31,\n  \"city\": \"Boston\",\n  \"zip\": \"02108\"\n}"
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithLayout(gd.LayoutWordDiff))
+	snapshot.MatchNamed(t, "worddiff_basic", result)
+}
+
+func TestSnapshotWordDiffColor(t *testing.T) {
+	old := "hello world\nfoo bar"
+	new := "hello earth\nfoo baz"
+	result := gd.DiffWith(old, new, gd.WithColor(true), gd.WithLayout(gd.LayoutWordDiff))
+	snapshot.MatchNamed(t, "worddiff_color", result, snapshot.WithANSIMarkers(true))
+}
+
+func TestSnapshotSideBySideWrap(t *testing.T) {
+	old := "short\nshared"
+	new := "this is a very long line that should exceed the panel width in an 80-column terminal and get wrapped\nshared"
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithLayout(gd.LayoutSideBySide), gd.WithWidth(80), gd.WithWrap(true))
+	snapshot.MatchNamed(t, "sbs_wrap", result)
+}
+
+func TestSnapshotSideBySideColorWrap(t *testing.T) {
+	old := `  "hobbies": ["reading", "hiking"],`
+	new := `  "hobbies": ["reading", "cycling", "cooking", "gaming"],`
+	result := gd.DiffWith(old, new, gd.WithColor(true), gd.WithLayout(gd.LayoutSideBySide), gd.WithWidth(72), gd.WithWrap(true))
+	snapshot.MatchNamed(t, "sbs_color_wrap", result, snapshot.WithANSIMarkers(true))
+}
+
+func TestSnapshotInlineWrap(t *testing.T) {
+	old := "context line\nthe quick brown fox jumps over the lazy dog and keeps running far away"
+	new := "context line\nthe quick brown cat jumps over the lazy dog and keeps running far away"
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithWidth(40), gd.WithWrap(true))
+	snapshot.MatchNamed(t, "inline_wrap", result)
+}
+
+func TestSnapshotInlineColorWrap(t *testing.T) {
+	old := "the quick brown fox jumps over the lazy dog and keeps running far away"
+	new := "the quick brown cat jumps over the lazy dog and keeps running far away"
+	result := gd.DiffWith(old, new, gd.WithColor(true), gd.WithWidth(40), gd.WithWrap(true))
+	snapshot.MatchNamed(t, "inline_color_wrap", result, snapshot.WithANSIMarkers(true))
+}
+
+func TestSnapshotSideBySideTabs(t *testing.T) {
+	old := "func main() {\n\tx := 1\n\treturn x\n}"
+	new := "func main() {\n\tx := 1\n\treturn x + 1\n}"
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithLayout(gd.LayoutSideBySide), gd.WithWidth(80))
+	snapshot.MatchNamed(t, "sbs_tabs", result)
+}
+
+func TestSnapshotInlineTabsAndControlChars(t *testing.T) {
+	old := "all:\n\tgo build\r\nbell\x07"
+	new := "all:\n\tgo build ./...\r\nbell"
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithTabWidth(8))
+	snapshot.MatchNamed(t, "inline_tabs_control", result)
+}
+
+func TestSnapshotInlineShowWhitespace(t *testing.T) {
+	old := "func f() {\n\treturn 1\n}\nx := 1"
+	new := "func f() {\n    return 1 \n}\nx := 1\r"
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithShowWhitespace(gd.WhitespaceChangedLines))
+	snapshot.MatchNamed(t, "inline_show_whitespace", result)
+}
+
+func TestSnapshotSideBySideShowWhitespaceColor(t *testing.T) {
+	old := "name = a b\nkeep"
+	new := "name = a  b  \nkeep"
+	result := gd.DiffWith(old, new, gd.WithColor(true), gd.WithLayout(gd.LayoutSideBySide),
+		gd.WithShowWhitespace(gd.WhitespaceEmphasized), gd.WithWidth(80))
+	snapshot.MatchNamed(t, "sbs_show_whitespace_color", result, snapshot.WithANSIMarkers(true))
+}
+
+func TestSnapshotInlineMissingNewlineChanged(t *testing.T) {
+	result := gd.DiffWith("a\nb\nc", "a\nb\nC\n", gd.WithColor(false))
+	snapshot.MatchNamed(t, "inline_no_newline_changed", result)
+}
+
+func TestSnapshotSideBySideTrailingNewline(t *testing.T) {
+	result := gd.DiffWith("hello\nworld\n", "hello\nworld", gd.WithColor(false), gd.WithLayout(gd.LayoutSideBySide),
+		gd.WithWidth(80))
+	snapshot.MatchNamed(t, "sbs_trailing_newline", result)
+}
+
+func TestSnapshotWordDiffTrailingNewline(t *testing.T) {
+	result := gd.DiffWith("x = 1", "x = 2\n", gd.WithColor(false), gd.WithLayout(gd.LayoutWordDiff))
+	snapshot.MatchNamed(t, "worddiff_trailing_newline", result)
+}
+
+func TestSnapshotInlineBOM(t *testing.T) {
+	result := gd.DiffWith("\uFEFFname: a\nkeep\n", "name: b\nkeep\n", gd.WithColor(false))
+	snapshot.MatchNamed(t, "inline_bom", result)
+}
+
+func hexFixture() (old, new []byte) {
+	old = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x01\x00\x00\x00\x01\x00\x08\x06\x00\x00\x00\x5c\x72\xa8\x66\x00\x00\x00\x01sRGB\x00\xae\xce\x1c\xe9")
+	new = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x02\x00\x00\x00\x01\x00\x08\x06\x00\x00\x00\x5c\x72\xa8\x66\x00\x00\x00\x09gAMA\x00\x00\xb1\x8f\x00\x00\x00\x01sRGB\x00\xae\xce\x1c\xe9")
+	return old, new
+}
+
+func TestSnapshotHexInline(t *testing.T) {
+	old, new := hexFixture()
+	result := gd.DiffBytes(old, new, gd.WithColor(false), gd.WithEmphasis(gd.EmphasisMarkers))
+	snapshot.MatchNamed(t, "hex_inline", result)
+}
+
+func TestSnapshotHexSideBySideColor(t *testing.T) {
+	old, new := hexFixture()
+	result := gd.DiffBytes(old, new, gd.WithColor(true), gd.WithLayout(gd.LayoutSideBySide), gd.WithWidth(200))
+	snapshot.MatchNamed(t, "hex_sbs_color", result, snapshot.WithANSIMarkers(true))
+}
+
+const goSource = `package server
+
+import "net/http"
+
+type Server struct {
+	name string
+}
+
+func (s *Server) Handle(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(200)
+	w.Write([]byte("hello"))
+	log.Println("handled")
+	return
+}
+
+func (s *Server) Close() error {
+	return nil
+}
+`
+
+func TestSnapshotInlineHunkHeaders(t *testing.T) {
+	new := strings.Replace(goSource, "WriteHeader(200)", "WriteHeader(http.StatusOK)", 1)
+	new = strings.Replace(new, "return nil", "return s.conn.Close()", 1)
+	result := gd.DiffWith(goSource, new, gd.WithColor(false), gd.WithContextLines(1), gd.WithHunkHeaders(gd.LangGo))
+	snapshot.MatchNamed(t, "inline_hunk_headers", result)
+}
+
+func TestSnapshotSideBySideHunkHeaders(t *testing.T) {
+	new := strings.Replace(goSource, "WriteHeader(200)", "WriteHeader(http.StatusOK)", 1)
+	result := gd.DiffWith(goSource, new, gd.WithColor(false), gd.WithContextLines(1),
+		gd.WithLayout(gd.LayoutSideBySide), gd.WithHunkHeaders(gd.LangGo), gd.WithWidth(160))
+	snapshot.MatchNamed(t, "sbs_hunk_headers", result)
+}
+
+func TestSnapshotInlineFunctionContext(t *testing.T) {
+	new := strings.Replace(goSource, `log.Println("handled")`, `log.Println("handled", r.URL)`, 1)
+	result := gd.DiffWith(goSource, new, gd.WithColor(false), gd.WithContextLines(0),
+		gd.WithFunctionContext(gd.LangGo), gd.WithHunkHeaders(gd.LangGo))
+	snapshot.MatchNamed(t, "inline_function_context", result)
+}
+
+func TestSnapshotInlineIndentHeuristic(t *testing.T) {
+	old := `tests := []struct {
+	name string
+}{
+	{
+		name: "empty",
+	},
+	{
+		name: "single",
+	},
+}
+`
+	new := strings.Replace(old, "\t{\n\t\tname: \"single\"", "\t{\n\t\tname: \"nil\",\n\t},\n\t{\n\t\tname: \"single\"", 1)
+	plain := gd.DiffWith(old, new, gd.WithColor(false), gd.WithContextLines(1))
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithContextLines(1), gd.WithIndentHeuristic(true))
+	if plain == result {
+		t.Error("expected the indent heuristic to move the inserted entry")
+	}
+	snapshot.MatchNamed(t, "inline_indent_heuristic", result)
+}
+
+func TestSnapshotInlineAnchors(t *testing.T) {
+	// Moving Close above Handle shows Close as moved, being shorter;
+	// anchoring Close keeps it in place so Handle reads as moved.
+	handle := "func (s *Server) Handle(w http.ResponseWriter, r *http.Request) {\n\tw.WriteHeader(200)\n\tw.Write([]byte(\"hello\"))\n\tlog.Println(\"handled\")\n\treturn\n}\n\n"
+	closeFn := "func (s *Server) Close() error {\n\treturn nil\n}\n"
+	new := strings.Replace(goSource, handle+closeFn, closeFn+"\n"+strings.TrimSuffix(handle, "\n"), 1)
+	if new == goSource {
+		t.Fatal("fixture replacement failed")
+	}
+	plain := gd.DiffWith(goSource, new, gd.WithColor(false), gd.WithContextLines(1))
+	result := gd.DiffWith(goSource, new, gd.WithColor(false), gd.WithContextLines(1), gd.WithAnchors("func (s *Server) Close"))
+	if plain == result {
+		t.Error("expected the anchor to change which function moves")
+	}
+	snapshot.MatchNamed(t, "inline_anchors", result)
+}
+
+const (
+	jsonOld = `{"name":"Alice","age":30,"hobbies":["reading","hiking"],"address":{"street":"123 Main St","city":"Springfield","state":"IL"}}`
+	jsonNew = `{
+  "age": 31, "name": "Alice",
+  "hobbies": ["reading", "cycling", "hiking"],
+  "address": {"city": "Springfield", "street": "456 Oak Ave", "zip": "62704"}
+}`
+)
+
+func TestSnapshotInlineJSON(t *testing.T) {
+	result, err := gd.DiffJSON([]byte(jsonOld), []byte(jsonNew), gd.WithColor(false), gd.WithEmphasis(gd.EmphasisMarkers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot.MatchNamed(t, "inline_json", result)
+}
+
+func TestSnapshotSideBySideJSONColor(t *testing.T) {
+	result, err := gd.DiffJSON([]byte(jsonOld), []byte(jsonNew), gd.WithColor(true),
+		gd.WithLayout(gd.LayoutSideBySide), gd.WithWidth(110), gd.WithContextLines(0), gd.WithHunkHeaders(gd.Language{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot.MatchNamed(t, "sbs_json_color", result, snapshot.WithANSIMarkers(true))
+}
+
+func TestSnapshotInlineYAML(t *testing.T) {
+	service := `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+    - port: 80
+`
+	deployment := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.25
+`
+	old := service + "---\n" + deployment + `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+data:
+  LOG_LEVEL: info
+`
+	new := strings.Replace(deployment, "replicas: 2", "replicas: 3", 1)
+	new = strings.Replace(new, "image: nginx:1.25", "image: nginx:1.27\n          ports:\n            - containerPort: 80", 1)
+	new += "---\n" + service
+	result, err := gd.DiffYAML([]byte(old), []byte(new), gd.WithColor(false), gd.WithContextLines(1),
+		gd.WithHunkHeaders(gd.Language{}), gd.WithEmphasis(gd.EmphasisMarkers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot.MatchNamed(t, "inline_yaml", result)
+}
+
+const (
+	csvOld = "id,name,age,city\n1,Alice,30,Springfield\n2,Bob,25,Shelbyville\n3,Carol,41,Ogdenville\n4,Dan,22,Capital City\n"
+	csvNew = "id,name,email,age\n2,Bob,bob@example.com,25\n1,Alice,alice@example.com,31\n3,Caroline,,41\n5,Eve,eve@example.com,35\n"
+)
+
+func TestSnapshotInlineCSV(t *testing.T) {
+	result, err := gd.DiffCSV([]byte(csvOld), []byte(csvNew), gd.WithColor(false), gd.WithCSVKey("id"), gd.WithEmphasis(gd.EmphasisMarkers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot.MatchNamed(t, "inline_csv", result)
+}
+
+func TestSnapshotSideBySideCSVColor(t *testing.T) {
+	result, err := gd.DiffCSV([]byte(csvOld), []byte(csvNew), gd.WithColor(true), gd.WithLayout(gd.LayoutSideBySide), gd.WithWidth(150))
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot.MatchNamed(t, "sbs_csv_color", result, snapshot.WithANSIMarkers(true))
+}
+
+const (
+	htmlOld = `<!DOCTYPE html>
+<html>
+<body>
+  <table class="wrapper" width="600">
+    <tr><td id="greeting" style="padding: 8px">Hi Alice,</td></tr>
+    <tr><td id="body">Your order <b>#1042</b> has shipped.</td></tr>
+    <tr><td id="footer">Thanks for shopping with us.</td></tr>
+  </table>
+</body>
+</html>`
+	htmlNew = `<!DOCTYPE html>
+<html>
+<body>
+  <table width="600" class="wrapper">
+    <tr><td id="greeting" style="padding: 12px">Hi Alice,</td></tr>
+    <tr><td id="body">Your order <b>#1042</b> is on its way.</td></tr>
+    <tr><td id="tracking"><a href="https://example.com/track/1042">Track it</a></td></tr>
+    <tr><td id="footer">Thanks for shopping with us.</td></tr>
+  </table>
+</body>
+</html>`
+)
+
+func TestSnapshotInlineHTML(t *testing.T) {
+	result, err := gd.DiffHTML([]byte(htmlOld), []byte(htmlNew), gd.WithColor(false), gd.WithEmphasis(gd.EmphasisMarkers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot.MatchNamed(t, "inline_html", result)
+}
+
+type snapshotCustomer struct {
+	Name    string
+	Email   string
+	Address *snapshotAddress
+	Tags    []string
+	Limits  map[string]int
+}
+
+type snapshotAddress struct {
+	Street string
+	City   string
+}
+
+func TestSnapshotInlineValues(t *testing.T) {
+	want := snapshotCustomer{
+		Name:    "Alice",
+		Email:   "alice@example.com",
+		Address: &snapshotAddress{Street: "123 Main St", City: "Springfield"},
+		Tags:    []string{"admin", "beta"},
+		Limits:  map[string]int{"requests": 100, "storage": 5},
+	}
+	got := want
+	got.Address = &snapshotAddress{Street: "456 Oak Ave", City: "Springfield"}
+	got.Tags = []string{"admin"}
+	got.Limits = map[string]int{"requests": 250, "storage": 5}
+	result := gd.DiffValues(want, got, gd.WithColor(false), gd.WithEmphasis(gd.EmphasisMarkers))
+	snapshot.MatchNamed(t, "inline_values", result)
+}
+
+func TestSnapshotInlineNumericTolerance(t *testing.T) {
+	old := "epoch,loss,accuracy\n1,0.6931472,0.5012\n2,0.4312871,0.8812\n3,0.3120442,0.9001\n4,0.2710963,0.9127\n"
+	new := "epoch,loss,accuracy\n1,0.6931469,0.5012\n2,0.4312868,0.8812\n3,0.3120447,0.9043\n4,0.2710961,0.9127\n"
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithEmphasis(gd.EmphasisMarkers),
+		gd.WithNumericTolerance(1e-6, 0))
+	snapshot.MatchNamed(t, "inline_numeric_tolerance", result)
+}
+
+func TestSnapshotInlineIgnoreLineOrder(t *testing.T) {
+	old := "github.com/google/uuid v1.6.0 h1:NIvaJDMOsjHA8n1jAhLSgzrAzy1Hgr+hNrb57e+94F0=\n" +
+		"golang.org/x/term v0.38.0 h1:PQ5pkm/rLO6HnxFR7N2lJHOZX6Kez5Y1gDSJla6jo7Q=\n" +
+		"golang.org/x/sys v0.39.0 h1:CvCKL8MeisomCi6qNZ+wbb0DN9E5AATixKsvNtMoMFk=\n" +
+		"golang.org/x/sys v0.39.0 h1:CvCKL8MeisomCi6qNZ+wbb0DN9E5AATixKsvNtMoMFk=\n"
+	new := "golang.org/x/sys v0.39.0 h1:CvCKL8MeisomCi6qNZ+wbb0DN9E5AATixKsvNtMoMFk=\n" +
+		"golang.org/x/term v0.39.0 h1:RclSuaJf32jOqZz74CkPA9qFuVTX7vhLlpfj/IGWlqY=\n" +
+		"github.com/google/uuid v1.6.0 h1:NIvaJDMOsjHA8n1jAhLSgzrAzy1Hgr+hNrb57e+94F0=\n"
+	result := gd.DiffWith(old, new, gd.WithColor(false), gd.WithEmphasis(gd.EmphasisMarkers),
+		gd.WithIgnoreLineOrder(true))
+	snapshot.MatchNamed(t, "inline_ignore_line_order", result)
+}
+
+func TestSnapshotInlineRecords(t *testing.T) {
+	old := `{"id":"req-1","method":"GET","path":"/users","status":200,"ms":12}
+{"id":"req-2","method":"POST","path":"/users","status":201,"ms":48}
+{"id":"req-3","method":"GET","path":"/users/7","status":200,"ms":9}
+{"id":"req-4","method":"DELETE","path":"/users/7","status":204,"ms":15}
+`
+	new := `{"id":"req-1","method":"GET","path":"/users","status":200,"ms":12}
+{"id":"req-3","method":"GET","path":"/users/7","status":404,"ms":9,"error":"not found"}
+{"id":"req-2","method":"POST","path":"/users","status":201,"ms":48}
+{"id":"req-5","method":"GET","path":"/health","status":200,"ms":1}
+`
+	result, err := gd.DiffRecords([]byte(old), []byte(new), gd.WithColor(false), gd.WithEmphasis(gd.EmphasisMarkers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot.MatchNamed(t, "inline_records", result)
 }
@@ -0,0 +1,223 @@
+package godelta
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/amterp/go-delta/internal/diff"
+	"github.com/mattn/go-runewidth"
+)
+
+// Stats summarizes a diff.
+type Stats struct {
+	Added    int // lines only in the new text, including modified ones
+	Removed  int // lines only in the old text, including modified ones
+	Modified int // removed/added line pairs shown with word-level emphasis
+	Hunks    int // groups of changes, as separated in the rendered diff
+
+	// Similarity is the fraction of lines the two texts share: twice
+	// the unchanged lines over the total lines of both. It is 1 for
+	// identical texts and 0 for texts with no lines in common.
+	Similarity float64
+
+	// Binary is set if either input looks binary. Line counts are then
+	// zero.
+	Binary bool
+
+	// BOMChanged is set if only one input starts with a UTF-8 byte
+	// order mark, which DiffWith notes above the diff. It is a change
+	// even when the lines are the same.
+	BOMChanged bool
+}
+
+// DiffStats computes statistics for the diff DiffWith would render for
+// the same inputs and options. Hunks and Modified depend on the options
+// (context lines); the other counts don't.
+func DiffStats(old, new string, opts ...Option) Stats {
+	if old == new {
+		return Stats{Similarity: 1}
+	}
+	if isBinary(old) || isBinary(new) {
+		return Stats{Binary: true}
+	}
+
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	old, oldBOM := strings.CutPrefix(old, utf8BOM)
+	new, newBOM := strings.CutPrefix(new, utf8BOM)
+	lines, annotated := annotateDiff(old, new, cfg)
+
+	st := Stats{Hunks: len(annotated), BOMChanged: oldBOM != newBOM}
+	equal := 0
+	for _, l := range lines {
+		switch l.Kind {
+		case diff.OpEqual:
+			equal++
+		case diff.OpDelete:
+			st.Removed++
+		case diff.OpInsert:
+			st.Added++
+		}
+	}
+	for _, h := range annotated {
+		st.Modified += len(h.Pairs)
+	}
+	st.Similarity = 1
+	if total := 2*equal + st.Added + st.Removed; total > 0 {
+		st.Similarity = float64(2*equal) / float64(total)
+	}
+	return st
+}
+
+// String renders the stats as a one-line summary, such as
+// "3 hunks, +12 −7 (5 modified)".
+func (s Stats) String() string {
+	switch {
+	case s.Binary:
+		return "binary files differ"
+	case s.Hunks == 0 && s.BOMChanged:
+		return "byte order mark changed"
+	case s.Hunks == 0:
+		return "no changes"
+	}
+	out := fmt.Sprintf("%s, +%d −%d", plural(s.Hunks, "hunk", "hunks"), s.Added, s.Removed)
+	if s.Modified > 0 {
+		out += fmt.Sprintf(" (%d modified)", s.Modified)
+	}
+	if s.BOMChanged {
+		out += ", byte order mark changed"
+	}
+	return out
+}
+
+// FileDiff is one file's old and new content, for multi-file summaries.
+type FileDiff struct {
+	Name string
+	Old  string
+	New  string
+}
+
+// DiffStat renders a summary of changes across files, like
+// git diff --stat: one row per changed file with its count of changed
+// lines and a bar of "+" and "-", then a totals line. Bars are scaled
+// to fit the terminal width (WithWidth, or auto-detected, or 80 when
+// unknown). Unchanged files are omitted. Binary files show their sizes
+// instead of a bar. Colors follow the same options as DiffWith.
+func DiffStat(files []FileDiff, opts ...Option) string {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	styles := resolveStyles(cfg)
+
+	type fileStat struct {
+		name  string
+		stats Stats
+		desc  string // count or binary size description
+	}
+	var rows []fileStat
+	var added, removed, maxChanges, nameWidth, descWidth int
+	for _, f := range files {
+		if f.Old == f.New {
+			continue
+		}
+		st := DiffStats(f.Old, f.New, opts...)
+		row := fileStat{name: f.Name, stats: st}
+		if st.Binary {
+			row.desc = fmt.Sprintf("Bin %d -> %d bytes", len(f.Old), len(f.New))
+		} else {
+			row.desc = fmt.Sprint(st.Added + st.Removed)
+			added += st.Added
+			removed += st.Removed
+			maxChanges = max(maxChanges, st.Added+st.Removed)
+			descWidth = max(descWidth, len(row.desc))
+		}
+		nameWidth = max(nameWidth, runewidth.StringWidth(f.Name))
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+
+	width := cfg.width
+	if width <= 0 {
+		width = terminalWidth()
+	}
+	if width <= 0 {
+		width = 80
+	}
+	// " name | count bar": leave at least a few columns for the bar even
+	// if the names are long.
+	barWidth := max(width-nameWidth-descWidth-5, 10)
+
+	var b strings.Builder
+	for _, r := range rows {
+		pad := strings.Repeat(" ", nameWidth-runewidth.StringWidth(r.name))
+		if r.stats.Binary {
+			fmt.Fprintf(&b, " %s%s | %s\n", r.name, pad, r.desc)
+			continue
+		}
+		plus, minus := statBar(r.stats.Added, r.stats.Removed, maxChanges, barWidth)
+		bar := ""
+		if plus > 0 {
+			bar += styles.Added(strings.Repeat("+", plus))
+		}
+		if minus > 0 {
+			bar += styles.Removed(strings.Repeat("-", minus))
+		}
+		fmt.Fprintf(&b, " %s%s | %*s %s\n", r.name, pad, descWidth, r.desc, bar)
+	}
+
+	fmt.Fprintf(&b, " %s changed", plural(len(rows), "file", "files"))
+	if added > 0 || removed == 0 {
+		fmt.Fprintf(&b, ", %s(+)", plural(added, "insertion", "insertions"))
+	}
+	if removed > 0 || added == 0 {
+		fmt.Fprintf(&b, ", %s(-)", plural(removed, "deletion", "deletions"))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// statBar returns the number of "+" and "-" characters for a file's bar.
+// Bars are drawn to scale only when the largest change doesn't fit in
+// width. As in git, the bar's length is scaled from the total and the
+// "+" part from the additions; unlike git, both parts keep at least one
+// character when their count is nonzero.
+func statBar(added, removed, maxChanges, width int) (plus, minus int) {
+	if maxChanges <= width {
+		return added, removed
+	}
+	total := scaleLinear(added+removed, width, maxChanges)
+	if added > 0 && removed > 0 {
+		total = max(total, 2)
+	}
+	plus = min(scaleLinear(added, width, maxChanges), total)
+	switch {
+	case removed > 0 && plus == total:
+		plus--
+	case added > 0 && plus == 0:
+		plus++
+	}
+	return plus, total - plus
+}
+
+// scaleLinear scales n from [0, maxChanges] to [0, width], rounding
+// to nearest but keeping any nonzero value at least 1.
+func scaleLinear(n, width, maxChanges int) int {
+	if n == 0 {
+		return 0
+	}
+	return 1 + (n*(width-1)*2+maxChanges)/(maxChanges*2)
+}
+
+// plural formats n with the singular or plural noun.
+func plural(n int, singular, plurals string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, plurals)
+}
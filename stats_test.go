@@ -0,0 +1,127 @@
+package godelta
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffStatsIdentical(t *testing.T) {
+	st := DiffStats("a\nb", "a\nb")
+	if st != (Stats{Similarity: 1}) {
+		t.Errorf("got %+v", st)
+	}
+	if st.String() != "no changes" {
+		t.Errorf("got %q", st.String())
+	}
+}
+
+func TestDiffStatsCounts(t *testing.T) {
+	old := "a\nx = 1\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	new := "a\nx = 2\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	st := DiffStats(old, new, WithContextLines(1))
+	want := Stats{Added: 2, Removed: 1, Modified: 1, Hunks: 2, Similarity: 18.0 / 21.0}
+	if st != want {
+		t.Errorf("got %+v, want %+v", st, want)
+	}
+	if got := st.String(); got != "2 hunks, +2 −1 (1 modified)" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestDiffStatsUnpaired(t *testing.T) {
+	st := DiffStats("x\n", "completely different\n")
+	if st.Modified != 0 || st.Similarity != 0 {
+		t.Errorf("got %+v", st)
+	}
+	if got := st.String(); got != "1 hunk, +1 −1" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestDiffStatsBOM(t *testing.T) {
+	st := DiffStats("\uFEFFa\nb\n", "a\nb\n")
+	if !st.BOMChanged || st.Hunks != 0 || st.Similarity != 1 {
+		t.Errorf("got %+v", st)
+	}
+	if got := st.String(); got != "byte order mark changed" {
+		t.Errorf("got %q", got)
+	}
+	if DiffWith("\uFEFFa\nb\n", "a\nb\n", WithColor(false)) == "" {
+		t.Error("DiffWith should note the byte order mark")
+	}
+
+	st = DiffStats("a\nb\n", "\uFEFFa\nc\n")
+	if got := st.String(); got != "1 hunk, +1 −1, byte order mark changed" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestDiffStatsBinary(t *testing.T) {
+	st := DiffStats("a\x00", "b\x00")
+	if !st.Binary || st.String() != "binary files differ" {
+		t.Errorf("got %+v", st)
+	}
+}
+
+func TestDiffStat(t *testing.T) {
+	files := []FileDiff{
+		{Name: "main.go", Old: "a\nb\nc\n", New: "a\nB\nc\nd\n"},
+		{Name: "same.txt", Old: "x", New: "x"},
+		{Name: "docs/README.md", Old: "old\n", New: ""},
+		{Name: "logo.png", Old: "\x89PNG\x00", New: "\x89PNG\x00\x01"},
+	}
+	got := DiffStat(files, WithColor(false), WithWidth(80))
+	want := "" +
+		" main.go        | 3 ++-\n" +
+		" docs/README.md | 1 -\n" +
+		" logo.png       | Bin 5 -> 6 bytes\n" +
+		" 3 files changed, 2 insertions(+), 2 deletions(-)\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffStatScalesToWidth(t *testing.T) {
+	big := strings.Repeat("x\n", 500)
+	files := []FileDiff{
+		{Name: "big", Old: "", New: big},
+		{Name: "small", Old: "a\n", New: "b\n"},
+	}
+	out := DiffStat(files, WithColor(false), WithWidth(40))
+	lines := strings.Split(out, "\n")
+	for _, line := range lines[:len(lines)-2] { // all but the totals
+		if len(line) > 40 {
+			t.Errorf("line exceeds width: %q", line)
+		}
+	}
+	if !strings.Contains(out, " small |   2 +-\n") {
+		t.Errorf("small change should keep one + and one -:\n%s", out)
+	}
+}
+
+func TestDiffStatNoChanges(t *testing.T) {
+	if got := DiffStat([]FileDiff{{Name: "a", Old: "x", New: "x"}}); got != "" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestStatBar(t *testing.T) {
+	tests := []struct {
+		added, removed, maxChanges, width int
+		plus, minus                       int
+	}{
+		{3, 2, 5, 10, 3, 2},
+		{100, 0, 100, 10, 10, 0},
+		{50, 50, 100, 10, 6, 4},
+		{1, 1, 1000, 10, 1, 1},
+		{1, 0, 1000, 10, 1, 0},
+		{0, 1, 1000, 10, 0, 1},
+	}
+	for _, tt := range tests {
+		plus, minus := statBar(tt.added, tt.removed, tt.maxChanges, tt.width)
+		if plus != tt.plus || minus != tt.minus {
+			t.Errorf("statBar(%d, %d, %d, %d) = %d, %d, want %d, %d",
+				tt.added, tt.removed, tt.maxChanges, tt.width, plus, minus, tt.plus, tt.minus)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package godelta
+
+// Color is a single theme color. Construct one with ANSI, ANSI256 or
+// RGB. The zero value means "terminal default" and emits no escape.
+//
+// Colors richer than the output terminal supports are downgraded to
+// the nearest available color at render time, so a single theme works
+// across truecolor, 256-color and 16-color terminals.
+type Color struct {
+	kind    colorKind
+	index   uint8 // palette index for ANSI and ANSI256 colors
+	r, g, b uint8 // components for RGB colors
+}
+
+type colorKind int
+
+const (
+	colorDefault colorKind = iota
+	colorANSI16
+	colorANSI256
+	colorRGB
+)
+
+// ANSI returns one of the 16 basic terminal colors. 0-7 are the
+// standard colors (black, red, green, yellow, blue, magenta, cyan,
+// white) and 8-15 their bright variants. Out-of-range values are
+// clamped.
+func ANSI(n int) Color {
+	return Color{kind: colorANSI16, index: uint8(clampInt(n, 0, 15))}
+}
+
+// ANSI256 returns a color from the xterm 256-color palette.
+// Out-of-range values are clamped.
+func ANSI256(n int) Color {
+	return Color{kind: colorANSI256, index: uint8(clampInt(n, 0, 255))}
+}
+
+// RGB returns a 24-bit truecolor value.
+func RGB(r, g, b uint8) Color {
+	return Color{kind: colorRGB, r: r, g: g, b: b}
+}
+
+// Style describes how one visual element is drawn.
+type Style struct {
+	Fg            Color
+	Bg            Color
+	Bold          bool
+	Faint         bool
+	Italic        bool
+	Underline     bool
+	Reverse       bool
+	Strikethrough bool
+}
+
+// Theme holds the styles for every visual element of a diff.
+type Theme struct {
+	Removed     Style // removed line text
+	Added       Style // added line text
+	RemovedEmph Style // changed segment within a removed line
+	AddedEmph   Style // changed segment within an added line
+	LineNum     Style // gutter line numbers and borders
+	Separator   Style // hunk separators and placeholders
+	WordRemoved Style // removed segment in LayoutWordDiff
+	WordAdded   Style // added segment in LayoutWordDiff
+	Whitespace  Style // trailing whitespace, with WithShowWhitespace
+}
+
+// DefaultTheme returns the built-in theme. It only uses the basic
+// 16 colors, so it looks the same on every color-capable terminal.
+func DefaultTheme() Theme {
+	red, green := ANSI(1), ANSI(2)
+	return Theme{
+		Removed:     Style{Fg: red},
+		Added:       Style{Fg: green},
+		RemovedEmph: Style{Fg: red, Reverse: true},
+		AddedEmph:   Style{Fg: green, Reverse: true},
+		LineNum:     Style{Faint: true},
+		Separator:   Style{Faint: true},
+		WordRemoved: Style{Fg: red, Strikethrough: true},
+		WordAdded:   Style{Fg: green, Underline: true},
+		Whitespace:  Style{Bg: red},
+	}
+}
+
+func clampInt(n, lo, hi int) int {
+	if n < lo {
+		return lo
+	}
+	if n > hi {
+		return hi
+	}
+	return n
+}
@@ -0,0 +1,41 @@
+package godelta
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIgnoreLineOrderReordered(t *testing.T) {
+	old := "*.log\nbin/\n.env\n"
+	new := ".env\n*.log\nbin/\n"
+	if out := DiffWith(old, new, WithIgnoreLineOrder(true)); out != "" {
+		t.Errorf("reordered lines should have no diff, got:\n%s", out)
+	}
+	if st := DiffStats(old, new, WithIgnoreLineOrder(true)); st.Hunks != 0 {
+		t.Errorf("expected no hunks, got %+v", st)
+	}
+}
+
+func TestIgnoreLineOrderChanges(t *testing.T) {
+	old := "*.log\n/bin/\n/bin/\n/vendor/v1.2/\n"
+	new := "/vendor/v1.3/\n/bin/\n*.log\n"
+	out := DiffWith(old, new, WithIgnoreLineOrder(true), WithColor(false), WithEmphasis(EmphasisMarkers),
+		WithHunkHeaders(Language{}))
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected two removed lines and one added, got:\n%s", out)
+	}
+	if !strings.HasPrefix(lines[0], "3 ") || !strings.HasSuffix(lines[0], "- /bin/") {
+		t.Errorf("expected the duplicate first, labeled with its line, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "[-2-]") || !strings.Contains(lines[2], "{+3+}") {
+		t.Errorf("expected the changed version paired and emphasized, got:\n%s", out)
+	}
+}
+
+func TestIgnoreLineOrderDuplicateCount(t *testing.T) {
+	out := DiffWith("a\nb\nb\nb\n", "b\na\n", WithIgnoreLineOrder(true), WithColor(false))
+	if !strings.HasPrefix(out, "3 ×2") || !strings.HasSuffix(out, "- b\n") {
+		t.Errorf("expected the two extra copies counted on one line, got:\n%q", out)
+	}
+}
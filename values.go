@@ -0,0 +1,33 @@
+package godelta
+
+import (
+	"github.com/amterp/go-delta/internal/align"
+	"github.com/amterp/go-delta/internal/diff"
+	"github.com/amterp/go-delta/internal/valuediff"
+)
+
+// DiffValues compares two Go values, such as the want and got of a
+// test, by pretty-printing both in Go-like syntax and diffing the
+// results: want is shown as removed and got as added. Printing is
+// deterministic: struct fields are in declaration order, map entries
+// sorted by key, pointers followed (with cycles cut), time.Time and
+// time.Duration shown as text, and []byte shown as a string or as hex.
+// Unexported fields are left out unless WithUnexportedFields is set.
+// Changed lines are labeled with paths such as .Address.Zip, as in
+// DiffJSON. Returns an empty string if both values print the same.
+func DiffValues(want, got any, opts ...Option) string {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	lines := valuediff.Diff(want, got, valuediff.Options{Unexported: cfg.unexportedFields})
+	hunks := diff.ComputeHunks(lines, cfg.contextLines)
+	if len(hunks) == 0 {
+		return ""
+	}
+	if cfg.hunkHeaders {
+		setPathContext(hunks)
+	}
+	return renderHunks(align.AnnotateHunks(hunks), cfg, resolveStyles(cfg))
+}
@@ -0,0 +1,41 @@
+package godelta
+
+import (
+	"strings"
+	"testing"
+)
+
+type valuesOrder struct {
+	ID    int
+	Items []string
+	Notes map[string]string
+	total int
+}
+
+func TestDiffValuesEqual(t *testing.T) {
+	a := valuesOrder{ID: 1, Notes: map[string]string{"a": "1", "b": "2"}}
+	b := valuesOrder{ID: 1, Notes: map[string]string{"b": "2", "a": "1"}}
+	if out := DiffValues(a, b); out != "" {
+		t.Errorf("equal values should have no diff, got:\n%s", out)
+	}
+}
+
+func TestDiffValuesUnexportedFields(t *testing.T) {
+	a, b := valuesOrder{total: 1}, valuesOrder{total: 2}
+	if out := DiffValues(a, b); out != "" {
+		t.Errorf("unexported fields should be ignored by default, got:\n%s", out)
+	}
+	out := DiffValues(a, b, WithColor(false), WithUnexportedFields(true))
+	if !strings.Contains(out, "total: 1,") || !strings.Contains(out, "total: 2,") {
+		t.Errorf("expected the unexported field in the diff, got:\n%s", out)
+	}
+}
+
+func TestDiffValuesHunkHeaders(t *testing.T) {
+	a := valuesOrder{ID: 1, Items: []string{"apple", "pear"}}
+	b := valuesOrder{ID: 1, Items: []string{"apple", "plum"}}
+	out := DiffValues(a, b, WithColor(false), WithContextLines(0), WithHunkHeaders(Language{}))
+	if !strings.HasPrefix(out, "@@ -5 +5 @@ .Items[1]\n") {
+		t.Errorf("expected a header with the changed path, got:\n%s", out)
+	}
+}
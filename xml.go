@@ -0,0 +1,67 @@
+package godelta
+
+import (
+	"fmt"
+
+	"github.com/amterp/go-delta/internal/align"
+	"github.com/amterp/go-delta/internal/diff"
+	"github.com/amterp/go-delta/internal/xmldiff"
+)
+
+// DiffXML compares two XML documents as trees and renders the
+// differences in canonical pretty-printed form: one element, text run
+// or comment per line, indented two spaces per level, with attributes
+// sorted by name. Attribute order, whitespace between elements and runs
+// of whitespace in text don't show as changes, but a space separating
+// text from an element does, as does any change to text in an element
+// with xml:space="preserve". Sibling elements are matched by tag and id
+// or key attribute, or by content, and matched elements are diffed
+// child by child, so a changed attribute or text run is emphasized in
+// place. Changed lines are labeled with paths such as
+// /feed/entry[@id="42"]/title, as in DiffJSON. Returns an empty string
+// if the documents are equivalent, or an error if either isn't
+// well-formed XML.
+func DiffXML(old, new []byte, opts ...Option) (string, error) {
+	return diffMarkup(old, new, false, opts)
+}
+
+// DiffHTML is like DiffXML for HTML documents, such as rendered email
+// templates. Parsing is tolerant: tag and attribute names are
+// case-insensitive, void elements such as <br> need no end tag,
+// elements such as <li> and <p> may be left unclosed, and <script> and
+// <style> content is raw text. Text in <pre> and <textarea> is compared
+// as written. Returns an error only for markup too broken to tokenize.
+func DiffHTML(old, new []byte, opts ...Option) (string, error) {
+	return diffMarkup(old, new, true, opts)
+}
+
+// diffMarkup implements DiffXML and DiffHTML.
+func diffMarkup(old, new []byte, html bool, opts []Option) (string, error) {
+	format := "XML"
+	if html {
+		format = "HTML"
+	}
+	oldDoc, err := xmldiff.Parse(old, html)
+	if err != nil {
+		return "", fmt.Errorf("old %s: %w", format, err)
+	}
+	newDoc, err := xmldiff.Parse(new, html)
+	if err != nil {
+		return "", fmt.Errorf("new %s: %w", format, err)
+	}
+
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	lines, pairs := xmldiff.Diff(oldDoc, newDoc)
+	hunks := diff.ComputeHunks(lines, cfg.contextLines)
+	if len(hunks) == 0 {
+		return "", nil
+	}
+	if cfg.hunkHeaders {
+		setPathContext(hunks)
+	}
+	return renderHunks(align.AnnotatePairs(hunks, pairs), cfg, resolveStyles(cfg)), nil
+}
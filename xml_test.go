@@ -0,0 +1,62 @@
+package godelta
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffXMLEquivalent(t *testing.T) {
+	old := `<config version="2" name="app"><port>80</port></config>`
+	new := "<config name=\"app\" version=\"2\">\n  <port>80</port>\n</config>\n"
+	out, err := DiffXML([]byte(old), []byte(new))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "" {
+		t.Errorf("reformatted XML should have no diff, got:\n%s", out)
+	}
+}
+
+func TestDiffXMLInvalid(t *testing.T) {
+	if _, err := DiffXML([]byte("<a><b></a>"), []byte("<a/>")); err == nil || !strings.Contains(err.Error(), "old XML") {
+		t.Errorf("expected an old XML error, got %v", err)
+	}
+	if _, err := DiffXML([]byte("<a/>"), []byte("<a>")); err == nil || !strings.Contains(err.Error(), "new XML") {
+		t.Errorf("expected a new XML error, got %v", err)
+	}
+}
+
+func TestDiffHTMLTolerant(t *testing.T) {
+	old := `<ul><li>one<li>two</ul><p>Hi<br>there`
+	new := `<UL><LI>one</LI><LI>2</LI></UL><p>Hi<br/>there</p>`
+	out, err := DiffHTML([]byte(old), []byte(new), WithColor(false), WithContextLines(0), WithHunkHeaders(Language{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "@@ -3 +3 @@ /ul/li[2]\n") {
+		t.Errorf("expected a header with the path of the change, got:\n%s", out)
+	}
+	if !strings.Contains(out, "<li>two</li>") || !strings.Contains(out, "<li>2</li>") {
+		t.Errorf("expected the changed item, got:\n%s", out)
+	}
+}
+
+func TestDiffHTMLWhitespaceAndScripts(t *testing.T) {
+	out, err := DiffHTML([]byte(`<p>Hello <b>world</b></p>`), []byte(`<p>Hello<b>world</b></p>`), WithColor(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out == "" {
+		t.Error("removing the space between words should show as a change")
+	}
+
+	old := `<script>if (a < b) { run() }</script>`
+	new := `<script>if (a <= b) { run() }</script>`
+	out, err = DiffHTML([]byte(old), []byte(new), WithColor(false))
+	if err != nil {
+		t.Fatalf("scripts are raw text: %v", err)
+	}
+	if !strings.Contains(out, "if (a <= b)") {
+		t.Errorf("expected the script change, got:\n%s", out)
+	}
+}
@@ -0,0 +1,64 @@
+package godelta
+
+import (
+	"fmt"
+
+	"github.com/amterp/go-delta/internal/align"
+	"github.com/amterp/go-delta/internal/diff"
+	"github.com/amterp/go-delta/internal/yamldiff"
+)
+
+// DiffYAML compares two YAML streams structurally, such as rendered
+// Helm charts or Kubernetes manifests, and renders the differences in
+// block style with two-space indentation. Documents are matched by
+// apiVersion, kind and metadata.name (see WithYAMLDocumentKey), so
+// reordering documents shows no changes; mappings are compared by key,
+// so reordering keys, comments, quoting and indentation show none
+// either. Changed lines are labeled with paths such as .spec.replicas,
+// as in DiffJSON, but hunk headers name the document a hunk starts in.
+// Returns an empty string if the streams are equivalent, or an error if
+// either isn't valid YAML.
+func DiffYAML(old, new []byte, opts ...Option) (string, error) {
+	oldDocs, err := yamldiff.Parse(old)
+	if err != nil {
+		return "", fmt.Errorf("old YAML: %w", err)
+	}
+	newDocs, err := yamldiff.Parse(new)
+	if err != nil {
+		return "", fmt.Errorf("new YAML: %w", err)
+	}
+
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	res := yamldiff.Diff(oldDocs, newDocs, yamldiff.Options{DocumentKey: cfg.yamlDocumentKey})
+	hunks := diff.ComputeHunks(res.Lines, cfg.contextLines)
+	if len(hunks) == 0 {
+		return "", nil
+	}
+	if cfg.hunkHeaders {
+		setDocumentContext(hunks, res.Documents)
+	}
+	return renderHunks(align.AnnotatePairs(hunks, res.Pairs), cfg, resolveStyles(cfg)), nil
+}
+
+// setDocumentContext sets each hunk's Context to the ID of the document
+// its first line is in, or to the path of its first change for
+// documents without one.
+func setDocumentContext(hunks []diff.Hunk, docs []yamldiff.Document) {
+	start := 0 // index of the hunk's first line among all lines
+	for i, h := range hunks {
+		start += h.Skipped
+		for _, doc := range docs {
+			if doc.Start <= start {
+				hunks[i].Context = doc.ID
+			}
+		}
+		if hunks[i].Context == "" {
+			setPathContext(hunks[i : i+1])
+		}
+		start += len(h.Lines)
+	}
+}
@@ -0,0 +1,77 @@
+package godelta
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	manifestService = `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+    - port: 80
+`
+	manifestDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.25
+`
+)
+
+func TestDiffYAMLReorderedDocuments(t *testing.T) {
+	old := manifestService + "---\n" + manifestDeployment
+	new := "# Source: chart/templates/deployment.yaml\n" + manifestDeployment + "---\n" + manifestService
+	out, err := DiffYAML([]byte(old), []byte(new))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "" {
+		t.Errorf("reordered documents should have no diff, got:\n%s", out)
+	}
+}
+
+func TestDiffYAMLInvalid(t *testing.T) {
+	if _, err := DiffYAML([]byte("a: [1"), []byte("a: 1")); err == nil || !strings.Contains(err.Error(), "old YAML") {
+		t.Errorf("expected an old YAML error, got %v", err)
+	}
+	if _, err := DiffYAML([]byte("a: 1"), []byte("a: {")); err == nil || !strings.Contains(err.Error(), "new YAML") {
+		t.Errorf("expected a new YAML error, got %v", err)
+	}
+}
+
+func TestDiffYAMLHunkHeaders(t *testing.T) {
+	new := strings.Replace(manifestDeployment, "replicas: 2", "replicas: 3", 1)
+	out, err := DiffYAML([]byte(manifestService+"---\n"+manifestDeployment), []byte(manifestService+"---\n"+new),
+		WithColor(false), WithContextLines(0), WithHunkHeaders(Language{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "@@ -15 +15 @@ apps/v1 Deployment web\n") {
+		t.Errorf("expected a header naming the document, got:\n%s", out)
+	}
+	if !strings.HasPrefix(strings.Split(out, "\n")[1], ".spec.replicas ") {
+		t.Errorf("expected the path in the gutter, got:\n%s", out)
+	}
+}
+
+func TestDiffYAMLDocumentKey(t *testing.T) {
+	old := "id: a\nv: 1\n---\nid: b\nv: 2\n"
+	new := "id: b\nv: 2\n---\nid: a\nv: 1\n"
+	out, err := DiffYAML([]byte(old), []byte(new), WithYAMLDocumentKey("id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "" {
+		t.Errorf("expected no diff, got:\n%s", out)
+	}
+}